@@ -15,5 +15,5 @@ require (
 
 require (
 	github.com/go-gl/mathgl v1.1.0
-	golang.org/x/image v0.0.0-20190321063152-3fc05d484e9f // indirect
+	golang.org/x/image v0.0.0-20190321063152-3fc05d484e9f
 )
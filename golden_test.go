@@ -0,0 +1,197 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"opgl-learn/headless"
+	"opgl-learn/renders"
+	"opgl-learn/utils"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden images in testdata/golden")
+
+const (
+	goldenWidth, goldenHeight = 400, 300
+
+	// renders are drawn at this point in time so animated ones are reproducible
+	goldenTime = 1.0
+
+	// max difference allowed per colour channel before a pixel counts as different
+	channelTolerance = 8
+	// fraction of pixels allowed to differ, absorbs rasterisation differences between Mesa versions
+	maxBadPixels = 0.001
+)
+
+// Renders that need assets which are not checked into the repository.
+var requiredAssets = map[string][]string{
+	"model-loading/model":                 {"backpack/backpack.obj"},
+	"pbr/model":                           {"backpack/backpack.obj"},
+	"advanced-opengl/skybox":              {"backpack/backpack.obj", "assets/skybox/right.jpg"},
+	"advanced-opengl/environment-mapping": {"backpack/backpack.obj", "assets/skybox/right.jpg"},
+}
+
+// Draw every registered render with a software OpenGL context and compare it against testdata/golden/<name>.png.
+// Run with -update to regenerate the golden images after an intended change.
+func TestGoldenImages(t *testing.T) {
+	for _, name := range renders.Names() {
+		t.Run(name, func(t *testing.T) {
+			for _, asset := range requiredAssets[name] {
+				if _, err := os.Stat(asset); err != nil {
+					t.Skipf("missing asset %s", asset)
+				}
+			}
+
+			got := renderGolden(t, name)
+			file := strings.ReplaceAll(name, "/", "_") + ".png"
+			goldenPath := filepath.Join("testdata", "golden", file)
+
+			if *update {
+				if err := writePNG(goldenPath, got); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := readPNG(goldenPath)
+			if err != nil {
+				t.Fatalf("reading golden image (run with -update to create it): %v", err)
+			}
+
+			bad, diff := compareImages(got, want, channelTolerance)
+			if limit := int(maxBadPixels * float64(goldenWidth*goldenHeight)); bad > limit {
+				failDir := filepath.Join("testdata", "failures")
+				gotPath := filepath.Join(failDir, file)
+				diffPath := filepath.Join(failDir, strings.TrimSuffix(file, ".png")+"-diff.png")
+				if err := writePNG(gotPath, got); err != nil {
+					t.Log(err)
+				}
+				if err := writePNG(diffPath, diff); err != nil {
+					t.Log(err)
+				}
+				t.Errorf("%d pixels differ from %s (limit %d), output written to %s and %s", bad, goldenPath, limit, gotPath, diffPath)
+			}
+		})
+	}
+}
+
+// Run a single frame of the render in a fresh context so no GL state leaks between renders.
+// The context is bound to the thread, so the goroutine stays locked to it for the whole subtest.
+func renderGolden(t *testing.T, name string) *image.NRGBA {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := headless.NewContext()
+	if err != nil {
+		t.Skipf("no software OpenGL context: %v", err)
+	}
+	defer ctx.Destroy()
+
+	target, err := headless.NewTarget(goldenWidth, goldenHeight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Delete()
+	utils.SetScreen(target.Framebuffer(), target.Width, target.Height)
+
+	render, err := renders.New(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wasHeadless, getTime := renders.Headless, renders.GetTime
+	t.Cleanup(func() { renders.Headless, renders.GetTime = wasHeadless, getTime })
+	renders.Headless = true
+	renders.GetTime = func() float64 { return goldenTime }
+	gl.Enable(gl.DEPTH_TEST)
+	render.InitGLPipeLine()
+	drawOffscreen(render, target)
+
+	if glErr := gl.GetError(); glErr != gl.NO_ERROR {
+		t.Errorf("OpenGL error 0x%x after drawing", glErr)
+	}
+	return target.ReadImage()
+}
+
+// Count the pixels where any channel differs by more than tolerance. The diff image shows
+// those pixels in red on top of a faded copy of the expected image.
+func compareImages(got, want *image.NRGBA, tolerance uint8) (int, *image.NRGBA) {
+	bounds := want.Bounds()
+	diff := image.NewNRGBA(bounds)
+	if got.Bounds() != bounds {
+		return bounds.Dx() * bounds.Dy(), diff
+	}
+
+	bad := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			g, w := got.NRGBAAt(x, y), want.NRGBAAt(x, y)
+			if channelDiff(g.R, w.R) > tolerance || channelDiff(g.G, w.G) > tolerance ||
+				channelDiff(g.B, w.B) > tolerance || channelDiff(g.A, w.A) > tolerance {
+				bad++
+				diff.SetNRGBA(x, y, color.NRGBA{255, 0, 0, 255})
+				continue
+			}
+			gray := uint8((uint32(w.R) + uint32(w.G) + uint32(w.B)) / 3 / 4)
+			diff.SetNRGBA(x, y, color.NRGBA{gray, gray, gray, 255})
+		}
+	}
+	return bad, diff
+}
+
+func channelDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func readPNG(path string) (*image.NRGBA, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, err := png.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+	if nrgba, ok := img.(*image.NRGBA); ok {
+		return nrgba, nil
+	}
+
+	// the encoder may store fully opaque images in other formats
+	nrgba := image.NewNRGBA(img.Bounds())
+	for y := img.Bounds().Min.Y; y < img.Bounds().Max.Y; y++ {
+		for x := img.Bounds().Min.X; x < img.Bounds().Max.X; x++ {
+			nrgba.Set(x, y, img.At(x, y))
+		}
+	}
+	return nrgba, nil
+}
+
+func writePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", path, err)
+	}
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
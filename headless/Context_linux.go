@@ -0,0 +1,108 @@
+//go:build linux
+
+package headless
+
+/*
+#cgo LDFLAGS: -lEGL
+#include <stdlib.h>
+#include <EGL/egl.h>
+#include <EGL/eglext.h>
+
+// Surfaceless displays need no X server or GPU, Mesa falls back to llvmpipe.
+static EGLDisplay surfacelessDisplay() {
+	PFNEGLGETPLATFORMDISPLAYEXTPROC getPlatformDisplay =
+		(PFNEGLGETPLATFORMDISPLAYEXTPROC)eglGetProcAddress("eglGetPlatformDisplayEXT");
+	if (getPlatformDisplay == NULL) {
+		return EGL_NO_DISPLAY;
+	}
+	return getPlatformDisplay(EGL_PLATFORM_SURFACELESS_MESA, EGL_DEFAULT_DISPLAY, NULL);
+}
+
+static void *getProcAddress(const char *name) {
+	return (void *)eglGetProcAddress(name);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// An OpenGL 3.3 core context with no window attached. Everything has to be drawn into a framebuffer object.
+type Context struct {
+	display C.EGLDisplay
+	context C.EGLContext
+}
+
+// Create a surfaceless EGL context, make it current on the calling thread and load the OpenGL function pointers.
+func NewContext() (*Context, error) {
+	display := C.surfacelessDisplay()
+	if display == C.EGLDisplay(C.EGL_NO_DISPLAY) {
+		return nil, fmt.Errorf("headless: EGL_MESA_platform_surfaceless is not available")
+	}
+
+	var major, minor C.EGLint
+	if C.eglInitialize(display, &major, &minor) == C.EGL_FALSE {
+		return nil, fmt.Errorf("headless: eglInitialize failed (0x%x)", int(C.eglGetError()))
+	}
+
+	configAttribs := []C.EGLint{
+		C.EGL_RENDERABLE_TYPE, C.EGL_OPENGL_BIT,
+		C.EGL_SURFACE_TYPE, 0,
+		C.EGL_NONE,
+	}
+	var config C.EGLConfig
+	var numConfigs C.EGLint
+	if C.eglChooseConfig(display, &configAttribs[0], &config, 1, &numConfigs) == C.EGL_FALSE || numConfigs == 0 {
+		C.eglTerminate(display)
+		return nil, fmt.Errorf("headless: no EGL config supports desktop OpenGL")
+	}
+
+	if C.eglBindAPI(C.EGL_OPENGL_API) == C.EGL_FALSE {
+		C.eglTerminate(display)
+		return nil, fmt.Errorf("headless: eglBindAPI failed (0x%x)", int(C.eglGetError()))
+	}
+
+	contextAttribs := []C.EGLint{
+		C.EGL_CONTEXT_MAJOR_VERSION, 3,
+		C.EGL_CONTEXT_MINOR_VERSION, 3,
+		C.EGL_CONTEXT_OPENGL_PROFILE_MASK, C.EGL_CONTEXT_OPENGL_CORE_PROFILE_BIT,
+		C.EGL_NONE,
+	}
+	context := C.eglCreateContext(display, config, C.EGLContext(C.EGL_NO_CONTEXT), &contextAttribs[0])
+	if context == C.EGLContext(C.EGL_NO_CONTEXT) {
+		C.eglTerminate(display)
+		return nil, fmt.Errorf("headless: eglCreateContext failed (0x%x)", int(C.eglGetError()))
+	}
+
+	noSurface := C.EGLSurface(C.EGL_NO_SURFACE)
+	if C.eglMakeCurrent(display, noSurface, noSurface, context) == C.EGL_FALSE {
+		C.eglDestroyContext(display, context)
+		C.eglTerminate(display)
+		return nil, fmt.Errorf("headless: eglMakeCurrent failed (0x%x)", int(C.eglGetError()))
+	}
+
+	err := gl.InitWithProcAddrFunc(func(name string) unsafe.Pointer {
+		cname := C.CString(name)
+		defer C.free(unsafe.Pointer(cname))
+		return C.getProcAddress(cname)
+	})
+	if err != nil {
+		C.eglDestroyContext(display, context)
+		C.eglTerminate(display)
+		return nil, fmt.Errorf("headless: loading OpenGL functions: %w", err)
+	}
+
+	return &Context{display: display, context: context}, nil
+}
+
+// Release the context and the EGL display.
+func (c *Context) Destroy() {
+	noSurface := C.EGLSurface(C.EGL_NO_SURFACE)
+	C.eglMakeCurrent(c.display, noSurface, noSurface, C.EGLContext(C.EGL_NO_CONTEXT))
+	C.eglDestroyContext(c.display, c.context)
+	C.eglTerminate(c.display)
+}
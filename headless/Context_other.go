@@ -0,0 +1,14 @@
+//go:build !linux
+
+package headless
+
+import "errors"
+
+type Context struct{}
+
+// Headless contexts rely on Mesa's surfaceless EGL platform which only exists on Linux.
+func NewContext() (*Context, error) {
+	return nil, errors.New("headless: only supported on linux")
+}
+
+func (c *Context) Destroy() {}
@@ -0,0 +1,103 @@
+package headless
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// An offscreen framebuffer with a RGBA8 (or SRGB8_ALPHA8) colour and a depth/stencil renderbuffer that renders draw into.
+type Target struct {
+	Width, Height int
+
+	fbo, color, depthStencil uint32
+}
+
+// Create the framebuffer, leaves it bound with the viewport covering it.
+func NewTarget(width, height int) (*Target, error) {
+	return newTarget(width, height, gl.RGBA8)
+}
+
+// Same as NewTarget with an sRGB colour buffer, with GL_FRAMEBUFFER_SRGB enabled
+// linear colours written by shaders are encoded to sRGB like an sRGB capable window does.
+func NewSRGBTarget(width, height int) (*Target, error) {
+	return newTarget(width, height, gl.SRGB8_ALPHA8)
+}
+
+func newTarget(width, height int, colorFormat uint32) (*Target, error) {
+	t := &Target{Width: width, Height: height}
+
+	gl.GenFramebuffers(1, &t.fbo)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, t.fbo)
+
+	gl.GenRenderbuffers(1, &t.color)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, t.color)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, colorFormat, int32(width), int32(height))
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.RENDERBUFFER, t.color)
+
+	gl.GenRenderbuffers(1, &t.depthStencil)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, t.depthStencil)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH24_STENCIL8, int32(width), int32(height))
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT, gl.RENDERBUFFER, t.depthStencil)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+
+	if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+		t.Delete()
+		return nil, fmt.Errorf("headless: framebuffer is not complete (0x%x)", status)
+	}
+
+	t.Bind()
+	return t, nil
+}
+
+// Bind the framebuffer and set the viewport to cover it.
+func (t *Target) Bind() {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, t.fbo)
+	gl.Viewport(0, 0, int32(t.Width), int32(t.Height))
+}
+
+// The framebuffer object, for code that needs to bind it without changing the viewport.
+func (t *Target) Framebuffer() uint32 {
+	return t.fbo
+}
+
+// Read the colour attachment back into an image. OpenGL's origin is the bottom left
+// so rows are flipped to get the usual top-down image.
+func (t *Target) ReadImage() *image.NRGBA {
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, t.fbo)
+	gl.ReadBuffer(gl.COLOR_ATTACHMENT0)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+
+	stride := t.Width * 4
+	pixels := make([]uint8, stride*t.Height)
+	gl.ReadPixels(0, 0, int32(t.Width), int32(t.Height), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(pixels))
+
+	img := image.NewNRGBA(image.Rect(0, 0, t.Width, t.Height))
+	for y := 0; y < t.Height; y++ {
+		copy(img.Pix[y*stride:(y+1)*stride], pixels[(t.Height-1-y)*stride:(t.Height-y)*stride])
+	}
+	return img
+}
+
+// Read the colour attachment and write it to a PNG file.
+func (t *Target) WritePNG(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(file, t.ReadImage()); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Release the framebuffer and its renderbuffers.
+func (t *Target) Delete() {
+	gl.DeleteFramebuffers(1, &t.fbo)
+	gl.DeleteRenderbuffers(1, &t.color)
+	gl.DeleteRenderbuffers(1, &t.depthStencil)
+}
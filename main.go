@@ -1,11 +1,22 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"opgl-learn/headless"
 	"opgl-learn/renders"
-	"opgl-learn/renders/ModelLoading"
+	"opgl-learn/utils"
 	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
+
+	// render packages register themselves with the renders registry
+	_ "opgl-learn/renders/AdvancedOpenGL"
+	_ "opgl-learn/renders/Basics"
+	_ "opgl-learn/renders/Lighting"
+	_ "opgl-learn/renders/ModelLoading"
+	_ "opgl-learn/renders/PBR"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
@@ -19,7 +30,76 @@ func init() {
 
 var WIDTH, HEIGHT = 800, 600
 
+var (
+	listRenders = flag.Bool("list", false, "list the available renders and exit")
+	renderName  = flag.String("render", "model-loading/model", "name of the render to run, see -list")
+
+	// headless mode, no window is created and frames are written to PNG files instead
+	headlessMode = flag.Bool("headless", false, "render offscreen with a surfaceless EGL context and write frames as PNG")
+	frameCount   = flag.Int("frames", 1, "number of frames to render in headless mode")
+	frameRate    = flag.Float64("fps", 60, "frames per second of the fixed clock used in headless mode")
+	outDir       = flag.String("out", "frames", "directory the headless frames are written to")
+
+	srgb         = flag.Bool("srgb", false, "draw into an sRGB framebuffer so lighting is done in linear space")
+	hdr          = flag.Bool("hdr", false, "draw into a floating point framebuffer that is tone mapped and gamma corrected")
+	toneMap      = flag.String("tonemap", "aces", "tone map operator used with -hdr: reinhard, aces or uncharted2")
+	autoExposure = flag.Bool("auto-exposure", false, "with -hdr, adapt the exposure to the scene brightness")
+	pointShadows = flag.Int("point-shadows", 4, "number of point lights casting shadows in lighting/multiple-lights, 0 for none")
+	post         = flag.String("post", "", "comma separated post-processing effects to start with, F1 to F8 toggle them while running")
+	iblCache     = flag.String("ibl-cache", defaultIBLCache(), "directory the image based lighting maps are cached in, empty to compute them every run")
+)
+
+// The IBL maps are cached in the user's cache directory, or not at all when there is none.
+func defaultIBLCache() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "opgl-learn", "ibl")
+}
+
 func main() {
+	flag.Parse()
+
+	if *listRenders {
+		for _, name := range renders.Names() {
+			fmt.Println(name)
+		}
+		return
+	}
+
+	// Pick the render before creating a window so a typo fails fast
+	render, err := renders.New(*renderName)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	renders.SRGBFramebuffer = *srgb
+	renders.HDR = *hdr
+	renders.PointShadowCasters = *pointShadows
+	renders.IBLCache = *iblCache
+
+	var effects []string
+	if *post != "" {
+		effects = strings.Split(*post, ",")
+	}
+	postProcessed := renders.WithPostProcessing(render, effects...)
+	postProcessed.Operator, err = utils.ParseToneMapOperator(*toneMap)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	postProcessed.AutoExposure = *autoExposure
+	render = postProcessed
+
+	if *headlessMode {
+		if err := runHeadless(*renderName, render); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	if err := glfw.Init(); err != nil {
 		fmt.Println("Something went wrong with the GLFW Init process")
@@ -27,13 +107,15 @@ func main() {
 	}
 	defer glfw.Terminate()
 	window := setup()
+	width, height := window.GetFramebufferSize()
+	utils.SetScreen(0, width, height)
 
 	// configure global opengl state
 	// -----------------------------
 	gl.Enable(gl.DEPTH_TEST) // Tell opengl to enable depth testing
-	// Load Render here
-	// var render renders.Render = &lighting.MultipleLights{}
-	var render renders.Render = &ModelLoading.ModelLoad{}
+	if *srgb {
+		gl.Enable(gl.FRAMEBUFFER_SRGB) // shader output is linear, let opengl encode it
+	}
 	// set up vertex data (and buffer(s)) and configure vertex attributes
 	render.InitGLPipeLine()
 	window.SetCursorPosCallback(render.MouseCallback)
@@ -55,9 +137,66 @@ func main() {
 	glfw.Terminate()
 }
 
+// Render frames into an offscreen framebuffer and save each one as <out>/<render>-<frame>.png.
+// Time advances by a fixed step per frame so the output is reproducible.
+func runHeadless(name string, render renders.Render) error {
+	ctx, err := headless.NewContext()
+	if err != nil {
+		return err
+	}
+	defer ctx.Destroy()
+
+	newTarget := headless.NewTarget
+	if *srgb {
+		newTarget = headless.NewSRGBTarget
+	}
+	target, err := newTarget(WIDTH, HEIGHT)
+	if err != nil {
+		return err
+	}
+	defer target.Delete()
+	utils.SetScreen(target.Framebuffer(), target.Width, target.Height)
+
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		return err
+	}
+
+	renders.Headless = true
+	frame := 0
+	renders.GetTime = func() float64 { return float64(frame) / *frameRate }
+
+	gl.Enable(gl.DEPTH_TEST)
+	if *srgb {
+		gl.Enable(gl.FRAMEBUFFER_SRGB)
+	}
+	render.InitGLPipeLine()
+
+	prefix := strings.ReplaceAll(name, "/", "_")
+	for ; frame < *frameCount; frame++ {
+		drawOffscreen(render, target)
+
+		path := filepath.Join(*outDir, fmt.Sprintf("%s-%04d.png", prefix, frame))
+		if err := target.WritePNG(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Draw one frame of the render into the offscreen target.
+func drawOffscreen(render renders.Render, target *headless.Target) {
+	// renders may bind their own framebuffers, make sure the frame ends up in ours.
+	// The early chapters only clear the colour buffer, so clear depth for them.
+	target.Bind()
+	gl.Clear(gl.DEPTH_BUFFER_BIT)
+	render.Draw()
+}
+
 func framebuffer_size_callback(window *glfw.Window, width, height int) {
 	// set the viewport
 	gl.Viewport(0, 0, int32(width), int32(height))
+	// framebuffers sized to the window reallocate themselves the next time they are used
+	utils.SetScreen(0, width, height)
 }
 
 // Setup GLFW and OpenGL function loaders
@@ -69,6 +208,9 @@ func setup() *glfw.Window {
 	glfw.WindowHint(glfw.ContextVersionMinor, 1)
 	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
 	glfw.WindowHint(glfw.OpenGLForwardCompatible, gl.TRUE)
+	if *srgb {
+		glfw.WindowHint(glfw.SRGBCapable, glfw.True)
+	}
 
 	// glfw window creation
 	// --------------------
@@ -0,0 +1,141 @@
+package advanced
+
+import (
+	"fmt"
+	"opgl-learn/renders"
+	"opgl-learn/utils"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// The six faces of the skybox, in the order utils.LoadCubemap wants them.
+var skyboxFaces = [6]string{
+	"./assets/skybox/right.jpg",
+	"./assets/skybox/left.jpg",
+	"./assets/skybox/top.jpg",
+	"./assets/skybox/bottom.jpg",
+	"./assets/skybox/front.jpg",
+	"./assets/skybox/back.jpg",
+}
+
+type Cubemaps struct {
+	ShaderProgram uint32
+	camera        utils.Camera
+	model         *utils.Model
+	loader        *utils.Loader
+	skybox        *utils.Skybox
+}
+
+var lastxPos float64 = 1920 / 2.0
+var lastyPos float64 = 1080 / 2.0
+var firstMouse bool = true
+var lastFrame float64 = 0.0
+
+func (ct *Cubemaps) InitGLPipeLine() {
+
+	ct.camera = utils.NewCamera(mgl32.Vec3{0.0, 0.0, 3.0}, mgl32.Vec3{0, 1, 0}, utils.YAW, utils.PITCH)
+
+	ct.ShaderProgram = utils.NewShader("./shaders/ModelLoading/1-ModelVert.glsl", "./shaders/ModelLoading/1-ModelFrag.glsl")
+
+	ct.loader = utils.NewLoader(0)
+	ct.model = ct.loader.LoadModel("./backpack/backpack.obj", renders.LinearLighting())
+	ct.loader.OnProgress = func(done, total int) {
+		if done == total && ct.model.Err != nil {
+			fmt.Println(ct.model.Err)
+		}
+	}
+	if renders.Headless {
+		ct.loader.Wait()
+	}
+
+	// the sky is a colour texture like the diffuse maps
+	space := utils.Linear
+	if renders.LinearLighting() {
+		space = utils.SRGB
+	}
+	cubemap, err := utils.LoadCubemap(skyboxFaces, utils.TextureOptions{ColorSpace: space})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	skybox, err := utils.NewSkybox(cubemap)
+	if err != nil {
+		fmt.Println(err)
+		gl.DeleteTextures(1, &cubemap)
+		return
+	}
+	ct.skybox = skybox
+}
+
+func (ct *Cubemaps) Draw() {
+
+	ct.loader.Process()
+
+	gl.ClearColor(0.1, 0.1, 0.1, 1.0)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+	projection := mgl32.Perspective(mgl32.DegToRad(float32(ct.camera.Zoom)), float32(800/600), 0.1, 100)
+	view := ct.camera.GetViewMatrix()
+
+	// draw the backpack first, the skybox only fills in what's left
+	gl.UseProgram(ct.ShaderProgram)
+	utils.SetMat4(ct.ShaderProgram, "view", &view)
+	utils.SetMat4(ct.ShaderProgram, "projection", &projection)
+	model := mgl32.Ident4()
+	utils.SetMat4(ct.ShaderProgram, "model", &model)
+	ct.model.Draw(ct.ShaderProgram)
+
+	if ct.skybox != nil {
+		ct.skybox.Draw(view, projection)
+	}
+}
+
+func (ct *Cubemaps) KeyboardCallback(window *glfw.Window) {
+
+	currentFrame := glfw.GetTime()
+	deltaTime := currentFrame - lastFrame
+	lastFrame = currentFrame
+
+	if window.GetKey(glfw.KeyEscape) == glfw.Press {
+		window.SetShouldClose(true)
+	}
+	if window.GetKey(glfw.KeyW) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.FORWARD, deltaTime)
+	}
+	if window.GetKey(glfw.KeyS) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.BACKWARD, deltaTime)
+	}
+	if window.GetKey(glfw.KeyA) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.LEFT, deltaTime)
+	}
+	if window.GetKey(glfw.KeyD) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.RIGHT, deltaTime)
+	}
+	if window.GetKey(glfw.KeySpace) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.UP, deltaTime)
+	}
+	if window.GetKey(glfw.KeyLeftControl) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.DOWN, deltaTime)
+	}
+}
+
+func (ct *Cubemaps) MouseCallback(window *glfw.Window, xpos float64, ypos float64) {
+	if firstMouse {
+		firstMouse = false
+		lastxPos = xpos
+		lastyPos = ypos
+	}
+
+	xoffset := xpos - lastxPos
+	yoffset := lastyPos - ypos
+	lastxPos = xpos
+	lastyPos = ypos
+
+	ct.camera.ProcessMouseMovement(xoffset, yoffset, true)
+}
+
+func (ct *Cubemaps) ScrollCallback(window *glfw.Window, xoff float64, yoff float64) {
+	ct.camera.ProcessMouseScroll(yoff)
+}
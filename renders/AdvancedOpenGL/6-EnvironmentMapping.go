@@ -0,0 +1,63 @@
+package advanced
+
+import (
+	"opgl-learn/utils"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// The backpack from the skybox render, reflecting or refracting the sky around it.
+// 1 shows the diffuse texture, 2 chrome and 3 glass. Up/down change the index of refraction, F toggles Fresnel.
+type EnvironmentMapping struct {
+	Cubemaps
+	fresnelHeld bool
+}
+
+func (ct *EnvironmentMapping) InitGLPipeLine() {
+	ct.Cubemaps.InitGLPipeLine()
+
+	gl.DeleteProgram(ct.ShaderProgram)
+	ct.ShaderProgram = utils.NewShader("./shaders/AdvancedOpenGL/6-EnvironmentVert.glsl", "./shaders/AdvancedOpenGL/6-EnvironmentFrag.glsl")
+
+	// the same sky the skybox draws
+	if ct.skybox != nil {
+		ct.model.EnvironmentMap = ct.skybox.Cubemap
+	}
+	ct.model.Environment = utils.EnvironmentMaterial{Mode: utils.EnvironmentReflect, IOR: 1.52, Fresnel: 1}
+}
+
+func (ct *EnvironmentMapping) Draw() {
+	gl.UseProgram(ct.ShaderProgram)
+	utils.SetVec3(ct.ShaderProgram, "cameraPos", &ct.camera.Position)
+
+	ct.Cubemaps.Draw()
+}
+
+func (ct *EnvironmentMapping) KeyboardCallback(window *glfw.Window) {
+	ct.Cubemaps.KeyboardCallback(window)
+
+	env := &ct.model.Environment
+	if window.GetKey(glfw.Key1) == glfw.Press {
+		env.Mode = utils.EnvironmentDiffuse
+	}
+	if window.GetKey(glfw.Key2) == glfw.Press {
+		env.Mode = utils.EnvironmentReflect
+	}
+	if window.GetKey(glfw.Key3) == glfw.Press {
+		env.Mode = utils.EnvironmentRefract
+	}
+	if window.GetKey(glfw.KeyUp) == glfw.Press {
+		env.IOR = min(env.IOR+0.01, 3)
+	}
+	if window.GetKey(glfw.KeyDown) == glfw.Press {
+		env.IOR = max(env.IOR-0.01, 1)
+	}
+
+	// toggle once per key press, not every frame it is held
+	fresnel := window.GetKey(glfw.KeyF) == glfw.Press
+	if fresnel && !ct.fresnelHeld {
+		env.Fresnel = 1 - env.Fresnel
+	}
+	ct.fresnelHeld = fresnel
+}
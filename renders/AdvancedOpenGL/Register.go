@@ -0,0 +1,8 @@
+package advanced
+
+import "opgl-learn/renders"
+
+func init() {
+	renders.Register("advanced-opengl/skybox", func() renders.Render { return &Cubemaps{} })
+	renders.Register("advanced-opengl/environment-mapping", func() renders.Render { return &EnvironmentMapping{} })
+}
@@ -1,8 +1,13 @@
 package basics
 
-import "github.com/go-gl/gl/v3.3-core/gl"
+import (
+	"opgl-learn/renders"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
 
 type EmptyWindow struct {
+	renders.BaseRender
 }
 
 func (ew *EmptyWindow) InitGLPipeLine() {
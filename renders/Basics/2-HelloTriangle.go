@@ -1,6 +1,7 @@
 package basics
 
 import (
+	"opgl-learn/renders"
 	"opgl-learn/utils"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
@@ -28,6 +29,7 @@ void main()
 ` + "\x00"
 
 type Triangle struct {
+	renders.BaseRender
 	ShaderProgram uint32
 	VAO, VBO      uint32
 }
@@ -78,6 +80,7 @@ func (t *Triangle) Draw() {
 }
 
 type TwoTriangles struct {
+	renders.BaseRender
 	ShaderProgram uint32
 	VAO, VBO, EBO uint32
 }
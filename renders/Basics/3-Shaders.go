@@ -2,13 +2,14 @@ package basics
 
 import (
 	"math"
+	"opgl-learn/renders"
 	"opgl-learn/utils"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
-	"github.com/go-gl/glfw/v3.3/glfw"
 )
 
 type ChangingTriangle struct {
+	renders.BaseRender
 	ShaderProgram uint32
 	VAO, VBO      uint32
 }
@@ -71,7 +72,7 @@ func (ct *ChangingTriangle) Draw() {
 	gl.UseProgram(ct.ShaderProgram)
 
 	// update the uniform color
-	timeValue := glfw.GetTime()
+	timeValue := renders.GetTime()
 	greenValue := (math.Sin(timeValue) / (2.0)) + 0.5
 	vertexColorLocation := gl.GetUniformLocation(ct.ShaderProgram, gl.Str("ourColor"+"\x00"))
 	gl.Uniform4f(vertexColorLocation, 0.0, float32(greenValue), 0.0, 1.0)
@@ -82,13 +83,14 @@ func (ct *ChangingTriangle) Draw() {
 }
 
 type RainbowTriangle struct {
+	renders.BaseRender
 	ShaderProgram uint32
 	VAO, VBO      uint32
 }
 
 func (rt *RainbowTriangle) InitGLPipeLine() {
 
-	rt.ShaderProgram = utils.NewShader("./shaders/3-rainbowTriangleVert.glsl", "./shaders/3-rainbowTriangleFrag.glsl")
+	rt.ShaderProgram = utils.NewShader("./shaders/Basics/3-RainbowTriangleVert.glsl", "./shaders/Basics/3-RainbowTriangleFrag.glsl")
 
 	var vertices = []float32{
 		// positions    // colors
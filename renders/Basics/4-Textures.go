@@ -1,12 +1,14 @@
 package basics
 
 import (
+	"opgl-learn/renders"
 	"opgl-learn/utils"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
 )
 
 type ContainerTexture struct {
+	renders.BaseRender
 	ShaderProgram      uint32
 	VAO, VBO, EBO      uint32
 	texture1, texture2 uint32
@@ -14,7 +16,7 @@ type ContainerTexture struct {
 
 func (ct *ContainerTexture) InitGLPipeLine() {
 
-	ct.ShaderProgram = utils.NewShader("./shaders/4-TextureVert.glsl", "./shaders/4-TextureFrag.glsl")
+	ct.ShaderProgram = utils.NewShader("./shaders/Basics/4-TextureVert.glsl", "./shaders/Basics/4-TextureFrag.glsl")
 
 	// Eight per vertex, 3 position, 3 color, 2 texture coords.
 	var vertices = []float32{
@@ -54,8 +56,8 @@ func (ct *ContainerTexture) InitGLPipeLine() {
 	gl.VertexAttribPointerWithOffset(2, 2, gl.FLOAT, false, 8*4, 6*4)
 	gl.EnableVertexAttribArray(2)
 
-	ct.texture1 = utils.New2DTexture(gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR, "./assets/container.png")
-	ct.texture2 = utils.New2DTexture(gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR, "./assets/face.png")
+	ct.texture1 = utils.NewTexture("./assets/container.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR})
+	ct.texture2 = utils.NewTexture("./assets/face.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR})
 
 	// tell opengl for each sampler to which texture unit it belongs to (only has to be done once)
 	// -------------------------------------------------------------------------------------------
@@ -1,21 +1,22 @@
 package basics
 
 import (
+	"opgl-learn/renders"
 	"opgl-learn/utils"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
-	"github.com/go-gl/glfw/v3.3/glfw"
 	"github.com/go-gl/mathgl/mgl32"
 )
 
 type Transformation struct {
+	renders.BaseRender
 	ShaderProgram      uint32
 	VAO, VBO, EBO      uint32
 	texture1, texture2 uint32
 }
 
 func (ct *Transformation) InitGLPipeLine() {
-	ct.ShaderProgram = utils.NewShader("./shaders/5-TransformVert.glsl", "./shaders/5-TransformFrag.glsl")
+	ct.ShaderProgram = utils.NewShader("./shaders/Basics/5-TransformVert.glsl", "./shaders/Basics/5-TransformFrag.glsl")
 
 	// Eight per vertex, 3 position, 3 color, 2 texture coords.
 	var vertices = []float32{
@@ -55,8 +56,8 @@ func (ct *Transformation) InitGLPipeLine() {
 	gl.VertexAttribPointerWithOffset(2, 2, gl.FLOAT, false, 8*4, 6*4)
 	gl.EnableVertexAttribArray(2)
 
-	ct.texture1 = utils.New2DTexture(gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR, "./assets/container.png")
-	ct.texture2 = utils.New2DTexture(gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR, "./assets/face.png")
+	ct.texture1 = utils.NewTexture("./assets/container.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR})
+	ct.texture2 = utils.NewTexture("./assets/face.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR})
 
 	// tell opengl for each sampler to which texture unit it belongs to (only has to be done once)
 	// -------------------------------------------------------------------------------------------
@@ -80,7 +81,7 @@ func (ct *Transformation) Draw() {
 	// create transformations
 	trans := mgl32.Ident4()
 	trans = trans.Mul4(mgl32.Translate3D(0.5, -0.5, 0))
-	trans = trans.Mul4(mgl32.HomogRotate3D(float32(glfw.GetTime()), mgl32.Vec3{0.0, 0.0, 1.0}))
+	trans = trans.Mul4(mgl32.HomogRotate3D(float32(renders.GetTime()), mgl32.Vec3{0.0, 0.0, 1.0}))
 
 	transformLoc := gl.GetUniformLocation(ct.ShaderProgram, gl.Str("transform"+"\x00"))
 	gl.UniformMatrix4fv(transformLoc, 1, false, &trans[0])
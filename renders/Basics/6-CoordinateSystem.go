@@ -1,21 +1,22 @@
 package basics
 
 import (
+	"opgl-learn/renders"
 	"opgl-learn/utils"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
-	"github.com/go-gl/glfw/v3.3/glfw"
 	"github.com/go-gl/mathgl/mgl32"
 )
 
 type Coordinates struct {
+	renders.BaseRender
 	ShaderProgram      uint32
 	VAO, VBO, EBO      uint32
 	texture1, texture2 uint32
 }
 
 func (ct *Coordinates) InitGLPipeLine() {
-	ct.ShaderProgram = utils.NewShader("./shaders/6-CoordinatesVert.glsl", "./shaders/6-CoordinatesFrag.glsl")
+	ct.ShaderProgram = utils.NewShader("./shaders/Basics/6-CoordinatesVert.glsl", "./shaders/Basics/6-CoordinatesFrag.glsl")
 
 	// Eight per vertex, 3 position, 2 texture coords.
 	var vertices = []float32{
@@ -51,8 +52,8 @@ func (ct *Coordinates) InitGLPipeLine() {
 	gl.VertexAttribPointerWithOffset(1, 2, gl.FLOAT, false, 5*4, 3*4)
 	gl.EnableVertexAttribArray(1)
 
-	ct.texture1 = utils.New2DTexture(gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR, "./assets/container.png")
-	ct.texture2 = utils.New2DTexture(gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR, "./assets/face.png")
+	ct.texture1 = utils.NewTexture("./assets/container.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR})
+	ct.texture2 = utils.NewTexture("./assets/face.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR})
 
 	// tell opengl for each sampler to which texture unit it belongs to (only has to be done once)
 	// -------------------------------------------------------------------------------------------
@@ -141,6 +142,7 @@ var cube_vertices = []float32{
 }
 
 type Cube struct {
+	renders.BaseRender
 	ShaderProgram      uint32
 	VAO, VBO, EBO      uint32
 	texture1, texture2 uint32
@@ -148,7 +150,7 @@ type Cube struct {
 
 func (ct *Cube) InitGLPipeLine() {
 
-	ct.ShaderProgram = utils.NewShader("./shaders/6-CoordinatesVert.glsl", "./shaders/6-CoordinatesFrag.glsl")
+	ct.ShaderProgram = utils.NewShader("./shaders/Basics/6-CoordinatesVert.glsl", "./shaders/Basics/6-CoordinatesFrag.glsl")
 
 	var vertices = cube_vertices
 
@@ -166,8 +168,8 @@ func (ct *Cube) InitGLPipeLine() {
 	gl.VertexAttribPointerWithOffset(1, 2, gl.FLOAT, false, 5*4, 3*4)
 	gl.EnableVertexAttribArray(1)
 
-	ct.texture1 = utils.New2DTexture(gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR, "./assets/container.png")
-	ct.texture2 = utils.New2DTexture(gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR, "./assets/face.png")
+	ct.texture1 = utils.NewTexture("./assets/container.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR})
+	ct.texture2 = utils.NewTexture("./assets/face.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR})
 
 	// tell opengl for each sampler to which texture unit it belongs to (only has to be done once)
 	// -------------------------------------------------------------------------------------------
@@ -189,7 +191,7 @@ func (ct *Cube) Draw() {
 
 	// create transformations
 	model, view, projection := mgl32.Ident4(), mgl32.Ident4(), mgl32.Ident4()
-	model = model.Mul4(mgl32.HomogRotate3D(float32(glfw.GetTime()), mgl32.Vec3{0.5, 1.0, 0.0}))
+	model = model.Mul4(mgl32.HomogRotate3D(float32(renders.GetTime()), mgl32.Vec3{0.5, 1.0, 0.0}))
 	view = view.Mul4(mgl32.Translate3D(0, 0, -3))
 	projection = mgl32.Perspective(mgl32.DegToRad(45), 16.0/9.0, 0.1, 100)
 
@@ -211,6 +213,7 @@ func (ct *Cube) Draw() {
 }
 
 type MoreCubes struct {
+	renders.BaseRender
 	ShaderProgram      uint32
 	VAO, VBO, EBO      uint32
 	texture1, texture2 uint32
@@ -219,7 +222,7 @@ type MoreCubes struct {
 
 func (ct *MoreCubes) InitGLPipeLine() {
 
-	ct.ShaderProgram = utils.NewShader("./shaders/6-CoordinatesVert.glsl", "./shaders/6-CoordinatesFrag.glsl")
+	ct.ShaderProgram = utils.NewShader("./shaders/Basics/6-CoordinatesVert.glsl", "./shaders/Basics/6-CoordinatesFrag.glsl")
 
 	var vertices = cube_vertices
 
@@ -250,8 +253,8 @@ func (ct *MoreCubes) InitGLPipeLine() {
 	gl.VertexAttribPointerWithOffset(1, 2, gl.FLOAT, false, 5*4, 3*4)
 	gl.EnableVertexAttribArray(1)
 
-	ct.texture1 = utils.New2DTexture(gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR, "./assets/container.png")
-	ct.texture2 = utils.New2DTexture(gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR, "./assets/face.png")
+	ct.texture1 = utils.NewTexture("./assets/container.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR})
+	ct.texture2 = utils.NewTexture("./assets/face.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR})
 
 	// tell opengl for each sampler to which texture unit it belongs to (only has to be done once)
 	// -------------------------------------------------------------------------------------------
@@ -28,7 +28,7 @@ var (
 func (ct *Camera) InitGLPipeLine() {
 	ct.camera = utils.NewCamera(mgl32.Vec3{0, 0, 3}, mgl32.Vec3{0, 1, 0}, utils.YAW, utils.PITCH)
 
-	ct.ShaderProgram = utils.NewShader("./shaders/6-CoordinatesVert.glsl", "./shaders/6-CoordinatesFrag.glsl")
+	ct.ShaderProgram = utils.NewShader("./shaders/Basics/6-CoordinatesVert.glsl", "./shaders/Basics/6-CoordinatesFrag.glsl")
 
 	var vertices = cube_vertices
 
@@ -59,8 +59,8 @@ func (ct *Camera) InitGLPipeLine() {
 	gl.VertexAttribPointerWithOffset(1, 2, gl.FLOAT, false, 5*4, 3*4)
 	gl.EnableVertexAttribArray(1)
 
-	ct.texture1 = utils.New2DTexture(gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR, "./assets/container.png")
-	ct.texture2 = utils.New2DTexture(gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR, "./assets/face.png")
+	ct.texture1 = utils.NewTexture("./assets/container.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR})
+	ct.texture2 = utils.NewTexture("./assets/face.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR})
 
 	// tell opengl for each sampler to which texture unit it belongs to (only has to be done once)
 	// -------------------------------------------------------------------------------------------
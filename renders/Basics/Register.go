@@ -0,0 +1,17 @@
+package basics
+
+import "opgl-learn/renders"
+
+func init() {
+	renders.Register("basics/hello-window", func() renders.Render { return &EmptyWindow{} })
+	renders.Register("basics/triangle", func() renders.Render { return &Triangle{} })
+	renders.Register("basics/two-triangles", func() renders.Render { return &TwoTriangles{} })
+	renders.Register("basics/changing-triangle", func() renders.Render { return &ChangingTriangle{} })
+	renders.Register("basics/rainbow-triangle", func() renders.Render { return &RainbowTriangle{} })
+	renders.Register("basics/textures", func() renders.Render { return &ContainerTexture{} })
+	renders.Register("basics/transformation", func() renders.Render { return &Transformation{} })
+	renders.Register("basics/coordinates", func() renders.Render { return &Coordinates{} })
+	renders.Register("basics/cube", func() renders.Render { return &Cube{} })
+	renders.Register("basics/more-cubes", func() renders.Render { return &MoreCubes{} })
+	renders.Register("basics/camera", func() renders.Render { return &Camera{} })
+}
@@ -0,0 +1,7 @@
+package renders
+
+import "github.com/go-gl/glfw/v3.3/glfw"
+
+// Time in seconds used by Draw for animations. Defaults to the GLFW timer,
+// headless runs replace it with a fixed step clock since GLFW is never initialised there.
+var GetTime = glfw.GetTime
@@ -0,0 +1,31 @@
+package renders
+
+// Runtime configuration of the renders, set from the command line flags before InitGLPipeLine.
+
+// Set when the default framebuffer is sRGB with GL_FRAMEBUFFER_SRGB enabled, so renders
+// know they can light in linear space and should upload colour textures as sRGB.
+var SRGBFramebuffer bool
+
+// Set when renders draw into a floating point framebuffer that PostProcessed tone maps and gamma corrects
+// at the end. Lighting is done in linear space like with SRGBFramebuffer, and can go above 1.
+var HDR bool
+
+// Whether lighting is done in linear space, colour textures should then be uploaded as sRGB.
+func LinearLighting() bool {
+	return SRGBFramebuffer || HDR
+}
+
+// Set when frames are drawn offscreen and saved, renders loading in the background wait for it
+// to finish in InitGLPipeLine so every saved frame shows everything.
+var Headless bool
+
+// The Radiance HDR image, in equirectangular projection, that lights the renders with image based lighting.
+// None is checked in, without it they are lit by a generated sky, see LoadEnvironment.
+var EnvironmentPath = "./assets/environment.hdr"
+
+// Directory the image based lighting maps are cached in, so they are only computed on the first run.
+// Nothing is cached when empty.
+var IBLCache string
+
+// How many point lights of lighting/multiple-lights cast shadows, the others only light the scene.
+var PointShadowCasters = 4
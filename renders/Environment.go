@@ -0,0 +1,55 @@
+package renders
+
+import (
+	"image"
+	"math"
+	"opgl-learn/utils"
+	"os"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Load the image based lighting of EnvironmentPath, or of the generated sky when there is no such file.
+func LoadEnvironment() (*utils.IBL, error) {
+	opts := utils.DefaultIBLOptions
+	opts.CacheDir = IBLCache
+	if _, err := os.Stat(EnvironmentPath); err == nil {
+		return utils.LoadIBL(EnvironmentPath, opts)
+	}
+	return utils.NewIBL(generatedSky(256, 128), opts)
+}
+
+// Where the sun of the generated sky is, up and behind the default camera position on the right.
+var sunDirection = mgl32.Vec3{0.5, 0.6, 0.6}.Normalize()
+
+// A blue sky fading into a haze at the horizon over brown ground, with a small sun far brighter than the rest.
+func generatedSky(width, height int) *utils.HDRImage {
+	img := utils.NewHDRImage(image.Rect(0, 0, width, height))
+	for y := range height {
+		// rows go from straight up to straight down
+		latitude := (0.5 - (float64(y)+0.5)/float64(height)) * math.Pi
+		for x := range width {
+			longitude := ((float64(x)+0.5)/float64(width) - 0.5) * 2 * math.Pi
+			direction := mgl32.Vec3{
+				float32(math.Cos(latitude) * math.Cos(longitude)),
+				float32(math.Sin(latitude)),
+				float32(math.Cos(latitude) * math.Sin(longitude)),
+			}
+
+			var c mgl32.Vec3
+			if up := direction.Y(); up >= 0 {
+				horizon, zenith := mgl32.Vec3{0.9, 0.95, 1.0}, mgl32.Vec3{0.15, 0.35, 0.8}
+				t := float32(math.Sqrt(float64(up)))
+				c = horizon.Mul(1 - t).Add(zenith.Mul(t))
+			} else {
+				c = mgl32.Vec3{0.25, 0.2, 0.15}
+			}
+			// the sun's disc is about 5 degrees wide
+			if direction.Dot(sunDirection) > float32(math.Cos(2.5*math.Pi/180)) {
+				c = mgl32.Vec3{50, 45, 40}
+			}
+			img.SetRGB(x, y, [3]float32(c))
+		}
+	}
+	return img
+}
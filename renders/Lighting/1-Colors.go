@@ -13,6 +13,7 @@ type Color struct {
 	VBO, cubeVAO                   uint32
 	lightCubeVAO                   uint32
 	camera                         utils.Camera
+	shaders                        utils.ShaderManager
 }
 
 func (ct *Color) InitGLPipeLine() {
@@ -63,8 +64,8 @@ func (ct *Color) InitGLPipeLine() {
 
 	ct.camera = utils.NewCamera(mgl32.Vec3{0.0, 0.0, 3.0}, mgl32.Vec3{0, 1, 0}, utils.YAW, utils.PITCH)
 
-	ct.ShaderProgram = utils.NewShader("./shaders/Lighting/1-ColorsVert.glsl", "./shaders/Lighting/1-ColorsFrag.glsl")
-	ct.LightCubeShader = utils.NewShader("./shaders/Lighting/1-LightVert.glsl", "./shaders/Lighting/1-LightFrag.glsl")
+	ct.shaders.Watch(&ct.ShaderProgram, "./shaders/Lighting/1-ColorsVert.glsl", "./shaders/Lighting/1-ColorsFrag.glsl", nil)
+	ct.shaders.Watch(&ct.LightCubeShader, "./shaders/Lighting/1-LightVert.glsl", "./shaders/Lighting/1-LightFrag.glsl", nil)
 
 	gl.GenVertexArrays(1, &ct.cubeVAO)
 	gl.GenBuffers(1, &ct.VBO)
@@ -95,6 +96,8 @@ func (ct *Color) InitGLPipeLine() {
 }
 
 func (ct *Color) Draw() {
+
+	ct.shaders.Poll()
 	gl.ClearColor(0.1, 0.1, 0.1, 1.0)
 	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 
@@ -121,6 +124,7 @@ func (ct *Color) Draw() {
 	gl.UseProgram(ct.LightCubeShader)
 	utils.SetMat4(ct.LightCubeShader, "view", &view)
 	utils.SetMat4(ct.LightCubeShader, "projection", &projection)
+	utils.SetFloat(ct.LightCubeShader, "intensity", lampIntensity())
 	model = mgl32.Ident4()
 	model = model.Mul4(mgl32.Translate3D(1.2, 1.0, 2.0)).Mul4(mgl32.Scale3D(0.2, 0.2, 0.2))
 	utils.SetMat4(ct.LightCubeShader, "model", &model)
@@ -2,6 +2,7 @@ package lighting
 
 import (
 	"math"
+	"opgl-learn/renders"
 	"opgl-learn/utils"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
@@ -15,6 +16,8 @@ type Phong struct {
 	lightCubeVAO                   uint32
 	camera                         utils.Camera
 	lightPos                       mgl32.Vec3
+	shaders                        utils.ShaderManager
+	cameraUBO                      *utils.UniformBuffer
 }
 
 func (ct *Phong) InitGLPipeLine() {
@@ -23,8 +26,11 @@ func (ct *Phong) InitGLPipeLine() {
 
 	ct.camera = utils.NewCamera(mgl32.Vec3{0.5, 1.0, 4.0}, mgl32.Vec3{0, 1, 0}, utils.YAW, utils.PITCH)
 
-	ct.ShaderProgram = utils.NewShader("./shaders/Lighting/2-PhongVert.glsl", "./shaders/Lighting/2-PhongFrag.glsl")
-	ct.LightCubeShader = utils.NewShader("./shaders/Lighting/1-LightVert.glsl", "./shaders/Lighting/1-LightFrag.glsl")
+	ct.shaders.Watch(&ct.ShaderProgram, "./shaders/Lighting/2-PhongVert.glsl", "./shaders/Lighting/2-PhongFrag.glsl", nil)
+	ct.shaders.Watch(&ct.LightCubeShader, "./shaders/Lighting/6-LightCubeVert.glsl", "./shaders/Lighting/1-LightFrag.glsl", nil)
+
+	// both programs read the matrices from this, see Draw
+	ct.cameraUBO = utils.NewUniformBuffer(utils.CameraBinding)
 
 	vertices := []float32{
 		-0.5, -0.5, -0.5, 0.0, 0.0, -1.0,
@@ -104,24 +110,26 @@ func (ct *Phong) InitGLPipeLine() {
 
 func (ct *Phong) Draw() {
 
+	ct.shaders.Poll()
+
 	gl.ClearColor(0.1, 0.1, 0.1, 1.0)
 	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 
-	ct.lightPos[0] = float32(1.0 + math.Sin(float64(glfw.GetTime()))*2)
-	ct.lightPos[1] = float32(math.Sin(float64(glfw.GetTime())/2.0)) * 1.0
+	ct.lightPos[0] = float32(1.0 + math.Sin(float64(renders.GetTime()))*2)
+	ct.lightPos[1] = float32(math.Sin(float64(renders.GetTime())/2.0)) * 1.0
+
+	// camera/view transformation, uploaded once for both programs
+	ct.cameraUBO.Update(&utils.CameraUniforms{
+		Projection: mgl32.Perspective(mgl32.DegToRad(float32(ct.camera.Zoom)), float32(800/600), 0.1, 100),
+		View:       ct.camera.GetViewMatrix(),
+		ViewPos:    ct.camera.Position,
+	})
 
 	// activate shader
 	gl.UseProgram(ct.ShaderProgram)
 	utils.SetVec3(ct.ShaderProgram, "objectColor", &mgl32.Vec3{1.0, 0.5, 0.31})
 	utils.SetVec3(ct.ShaderProgram, "lightColor", &mgl32.Vec3{1.0, 1.0, 1.0})
 	utils.SetVec3(ct.ShaderProgram, "lightPos", &ct.lightPos)
-	utils.SetVec3(ct.ShaderProgram, "viewPos", &(ct.camera.Position))
-
-	// camera/view transformation
-	projection := mgl32.Perspective(mgl32.DegToRad(float32(ct.camera.Zoom)), float32(800/600), 0.1, 100)
-	view := ct.camera.GetViewMatrix()
-	utils.SetMat4(ct.ShaderProgram, "view", &view)
-	utils.SetMat4(ct.ShaderProgram, "projection", &projection)
 
 	// world transforms
 	model := mgl32.Ident4()
@@ -134,8 +142,7 @@ func (ct *Phong) Draw() {
 
 	// Draw the Lamp
 	gl.UseProgram(ct.LightCubeShader)
-	utils.SetMat4(ct.LightCubeShader, "view", &view)
-	utils.SetMat4(ct.LightCubeShader, "projection", &projection)
+	utils.SetFloat(ct.LightCubeShader, "intensity", lampIntensity())
 	model = mgl32.Ident4()
 	model = model.Mul4(mgl32.Translate3D(ct.lightPos.X(), ct.lightPos.Y(), ct.lightPos.Z())).Mul4(mgl32.Scale3D(0.2, 0.2, 0.2))
 	utils.SetMat4(ct.LightCubeShader, "model", &model)
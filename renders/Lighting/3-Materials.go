@@ -2,6 +2,7 @@ package lighting
 
 import (
 	"math"
+	"opgl-learn/renders"
 	"opgl-learn/utils"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
@@ -10,11 +11,13 @@ import (
 )
 
 type Materials struct {
-	ShaderProgram, LightCubeShader uint32
+	ShaderProgram, LightCubeShader utils.Shader
 	VBO, cubeVAO                   uint32
 	lightCubeVAO                   uint32
 	camera                         utils.Camera
 	lightPos                       mgl32.Vec3
+	shaders                        utils.ShaderManager
+	cameraUBO, lightsUBO           *utils.UniformBuffer
 }
 
 func (ct *Materials) InitGLPipeLine() {
@@ -23,8 +26,12 @@ func (ct *Materials) InitGLPipeLine() {
 
 	ct.camera = utils.NewCamera(mgl32.Vec3{0.5, 1.0, 4.0}, mgl32.Vec3{0, 1, 0}, utils.YAW, utils.PITCH)
 
-	ct.ShaderProgram = utils.NewShader("./shaders/Lighting/2-PhongVert.glsl", "./shaders/Lighting/3-MaterialFrag.glsl")
-	ct.LightCubeShader = utils.NewShader("./shaders/Lighting/1-LightVert.glsl", "./shaders/Lighting/1-LightFrag.glsl")
+	ct.shaders.Watch(&ct.ShaderProgram.ID, "./shaders/Lighting/2-PhongVert.glsl", "./shaders/Lighting/3-MaterialFrag.glsl", nil)
+	ct.shaders.Watch(&ct.LightCubeShader.ID, "./shaders/Lighting/6-LightCubeVert.glsl", "./shaders/Lighting/1-LightFrag.glsl", nil)
+
+	// both programs read the matrices and the light from these, see Draw
+	ct.cameraUBO = utils.NewUniformBuffer(utils.CameraBinding)
+	ct.lightsUBO = utils.NewUniformBuffer(utils.LightsBinding)
 
 	vertices := []float32{
 		-0.5, -0.5, -0.5, 0.0, 0.0, -1.0,
@@ -98,67 +105,74 @@ func (ct *Materials) InitGLPipeLine() {
 
 	// tell opengl for each sampler to which texture unit it belongs to (only has to be done once)
 	// -------------------------------------------------------------------------------------------
-	gl.UseProgram(ct.ShaderProgram) // don't forget to activate/use the shader before setting uniforms!
+	ct.ShaderProgram.Use() // don't forget to activate/use the shader before setting uniforms!
 
 }
 
 func (ct *Materials) Draw() {
 
+	ct.shaders.Poll()
+
 	gl.ClearColor(0.1, 0.1, 0.1, 1.0)
 	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 
-	ct.lightPos[0] = float32(1.0 + math.Sin(float64(glfw.GetTime()))*2)
-	ct.lightPos[1] = float32(math.Sin(float64(glfw.GetTime())/2.0)) * 1.0
-
-	// activate shader
-	gl.UseProgram(ct.ShaderProgram)
-	utils.SetVec3(ct.ShaderProgram, "light.position", &ct.lightPos)
-	utils.SetVec3(ct.ShaderProgram, "viewPos", &(ct.camera.Position))
+	ct.lightPos[0] = float32(1.0 + math.Sin(float64(renders.GetTime()))*2)
+	ct.lightPos[1] = float32(math.Sin(float64(renders.GetTime())/2.0)) * 1.0
 
-	// material stuff
-	utils.SetVec3(ct.ShaderProgram, "material.ambient", &mgl32.Vec3{1.0, 0.5, 0.31})
-	utils.SetVec3(ct.ShaderProgram, "material.diffuse", &mgl32.Vec3{1.0, 0.5, 0.31})
-	utils.SetVec3(ct.ShaderProgram, "material.specular", &mgl32.Vec3{0.5, 0.5, 0.5})
-	utils.SetFloat(ct.ShaderProgram, "material.shininess", 32.0)
+	// camera/view transformation, uploaded once for both programs
+	ct.cameraUBO.Update(&utils.CameraUniforms{
+		Projection: mgl32.Perspective(mgl32.DegToRad(float32(ct.camera.Zoom)), float32(800/600), 0.1, 100),
+		View:       ct.camera.GetViewMatrix(),
+		ViewPos:    ct.camera.Position,
+	})
 
 	// Light stuff
 
 	// Time varing light color
 	lightColor := mgl32.Vec3{
-		float32(math.Sin(glfw.GetTime() * 2)),
-		float32(math.Sin(glfw.GetTime() * 0.7)),
-		float32(math.Sin(glfw.GetTime() * 1.3)),
+		float32(math.Sin(renders.GetTime() * 2)),
+		float32(math.Sin(renders.GetTime() * 0.7)),
+		float32(math.Sin(renders.GetTime() * 1.3)),
 	}
 
 	diffuseColor := lightColor.Mul(0.5)
 	ambientColor := lightColor.Mul(0.2)
 
-	utils.SetVec3(ct.ShaderProgram, "light.ambient", &ambientColor)
-	utils.SetVec3(ct.ShaderProgram, "light.diffuse", &diffuseColor)
-	utils.SetVec3(ct.ShaderProgram, "light.specular", &mgl32.Vec3{1, 1, 1})
+	// the shader only reads the first point light and doesn't attenuate it
+	ct.lightsUBO.Update(&utils.LightsUniforms{
+		PointLights: []utils.PointLightUniform{{
+			Position: ct.lightPos,
+			Constant: 1.0,
+			Ambient:  ambientColor,
+			Diffuse:  diffuseColor,
+			Specular: mgl32.Vec3{1, 1, 1},
+		}},
+	})
+
+	// activate shader
+	ct.ShaderProgram.Use()
 
-	// camera/view transformation
-	projection := mgl32.Perspective(mgl32.DegToRad(float32(ct.camera.Zoom)), float32(800/600), 0.1, 100)
-	view := ct.camera.GetViewMatrix()
-	utils.SetMat4(ct.ShaderProgram, "view", &view)
-	utils.SetMat4(ct.ShaderProgram, "projection", &projection)
+	// material stuff
+	ct.ShaderProgram.SetVec3("material.ambient", &mgl32.Vec3{1.0, 0.5, 0.31})
+	ct.ShaderProgram.SetVec3("material.diffuse", &mgl32.Vec3{1.0, 0.5, 0.31})
+	ct.ShaderProgram.SetVec3("material.specular", &mgl32.Vec3{0.5, 0.5, 0.5})
+	ct.ShaderProgram.SetFloat("material.shininess", 32.0)
 
 	// world transforms
 	model := mgl32.Ident4()
 	model = model.Mul4(mgl32.Scale3D(3, 3, 3))
-	utils.SetMat4(ct.ShaderProgram, "model", &model)
+	ct.ShaderProgram.SetMat4("model", &model)
 
 	// render the cube
 	gl.BindVertexArray(ct.cubeVAO)
 	gl.DrawArrays(gl.TRIANGLES, 0, 36)
 
 	// Draw the Lamp
-	gl.UseProgram(ct.LightCubeShader)
-	utils.SetMat4(ct.LightCubeShader, "view", &view)
-	utils.SetMat4(ct.LightCubeShader, "projection", &projection)
+	ct.LightCubeShader.Use()
+	ct.LightCubeShader.SetFloat("intensity", lampIntensity())
 	model = mgl32.Ident4()
 	model = model.Mul4(mgl32.Translate3D(ct.lightPos.X(), ct.lightPos.Y(), ct.lightPos.Z())).Mul4(mgl32.Scale3D(0.2, 0.2, 0.2))
-	utils.SetMat4(ct.LightCubeShader, "model", &model)
+	ct.LightCubeShader.SetMat4("model", &model)
 
 	gl.BindVertexArray(ct.lightCubeVAO)
 	gl.DrawArrays(gl.TRIANGLES, 0, 36)
@@ -2,6 +2,7 @@ package lighting
 
 import (
 	"math"
+	"opgl-learn/renders"
 	"opgl-learn/utils"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
@@ -10,12 +11,14 @@ import (
 )
 
 type LightingMaps struct {
-	ShaderProgram, LightCubeShader uint32
+	ShaderProgram, LightCubeShader utils.Shader
 	VBO, cubeVAO                   uint32
 	lightCubeVAO                   uint32
 	camera                         utils.Camera
 	lightPos                       mgl32.Vec3
 	diffuseMap, specularMap        uint32
+	shaders                        utils.ShaderManager
+	cameraUBO, lightsUBO           *utils.UniformBuffer
 }
 
 func (ct *LightingMaps) InitGLPipeLine() {
@@ -24,8 +27,12 @@ func (ct *LightingMaps) InitGLPipeLine() {
 
 	ct.camera = utils.NewCamera(mgl32.Vec3{0.5, 1.0, 4.0}, mgl32.Vec3{0, 1, 0}, utils.YAW, utils.PITCH)
 
-	ct.ShaderProgram = utils.NewShader("./shaders/Lighting/4-LightingMapsVert.glsl", "./shaders/Lighting/4-LightingMapsFrag.glsl")
-	ct.LightCubeShader = utils.NewShader("./shaders/Lighting/1-LightVert.glsl", "./shaders/Lighting/1-LightFrag.glsl")
+	ct.shaders.Watch(&ct.ShaderProgram.ID, "./shaders/Lighting/4-LightingMapsVert.glsl", "./shaders/Lighting/4-LightingMapsFrag.glsl", setMaterialSamplers)
+	ct.shaders.Watch(&ct.LightCubeShader.ID, "./shaders/Lighting/6-LightCubeVert.glsl", "./shaders/Lighting/1-LightFrag.glsl", nil)
+
+	// both programs read the matrices and the light from these, see Draw
+	ct.cameraUBO = utils.NewUniformBuffer(utils.CameraBinding)
+	ct.lightsUBO = utils.NewUniformBuffer(utils.LightsBinding)
 
 	vertices := []float32{
 		// positions          // normals           // texture coords
@@ -103,29 +110,51 @@ func (ct *LightingMaps) InitGLPipeLine() {
 	gl.EnableVertexAttribArray(0)
 
 	// Texture Stuff
-	ct.diffuseMap = utils.New2DTexture(gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR, "./assets/container2.png")
-	ct.specularMap = utils.New2DTexture(gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR, "./assets/container2_specular.png")
-	gl.UseProgram(ct.ShaderProgram)
-	utils.SetInt(ct.ShaderProgram, "material.diffuse", 0)
-	utils.SetInt(ct.ShaderProgram, "material.specular", 1)
+	ct.diffuseMap = utils.NewTexture("./assets/container2.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR, ColorSpace: diffuseColorSpace()})
+	ct.specularMap = utils.NewTexture("./assets/container2_specular.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR})
+	setMaterialSamplers(ct.ShaderProgram.ID)
+
+}
+
+// tell the shader which texture unit each material map is on, has to be redone when the shader is reloaded
+func setMaterialSamplers(program uint32) {
+	gl.UseProgram(program)
+	utils.SetInt(program, "material.diffuse", 0)
+	utils.SetInt(program, "material.specular", 1)
+}
+
+// the colour space of the diffuse maps, sRGB when lighting in linear space
+func diffuseColorSpace() utils.ColorSpace {
+	if renders.LinearLighting() {
+		return utils.SRGB
+	}
+	return utils.Linear
+}
 
+// brightness of the lamps, above 1 in HDR so they stand out and glow with bloom
+func lampIntensity() float32 {
+	if renders.HDR {
+		return 4
+	}
+	return 1
 }
 
 func (ct *LightingMaps) Draw() {
 
+	ct.shaders.Poll()
+
 	gl.ClearColor(0.1, 0.1, 0.1, 1.0)
 	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 
-	ct.lightPos[0] = float32(1.0 + math.Sin(float64(glfw.GetTime()))*2)
-	ct.lightPos[1] = float32(math.Sin(float64(glfw.GetTime()) / 2.0))
-
-	// activate shader
-	gl.UseProgram(ct.ShaderProgram)
-	utils.SetVec3(ct.ShaderProgram, "light.position", &ct.lightPos)
-	utils.SetVec3(ct.ShaderProgram, "viewPos", &(ct.camera.Position))
+	ct.lightPos[0] = float32(1.0 + math.Sin(float64(renders.GetTime()))*2)
+	ct.lightPos[1] = float32(math.Sin(float64(renders.GetTime()) / 2.0))
 
-	// material stuff
-	utils.SetFloat(ct.ShaderProgram, "material.shininess", 64.0)
+	// camera/view transformation, uploaded once for both programs
+	ct.cameraUBO.Update(&utils.CameraUniforms{
+		Projection: mgl32.Perspective(mgl32.DegToRad(float32(ct.camera.Zoom)), float32(800/600), 0.1, 100),
+		View:       ct.camera.GetViewMatrix(),
+		ViewPos:    ct.camera.Position,
+	})
 
 	// Time varing light color
 	lightColor := mgl32.Vec3{1, 1, 1}
@@ -133,20 +162,27 @@ func (ct *LightingMaps) Draw() {
 	diffuseColor := lightColor.Mul(0.5)
 	ambientColor := lightColor.Mul(0.2)
 
-	utils.SetVec3(ct.ShaderProgram, "light.ambient", &ambientColor)
-	utils.SetVec3(ct.ShaderProgram, "light.diffuse", &diffuseColor)
-	utils.SetVec3(ct.ShaderProgram, "light.specular", &mgl32.Vec3{1, 1, 1})
+	// the shader only reads the first point light and doesn't attenuate it
+	ct.lightsUBO.Update(&utils.LightsUniforms{
+		PointLights: []utils.PointLightUniform{{
+			Position: ct.lightPos,
+			Constant: 1.0,
+			Ambient:  ambientColor,
+			Diffuse:  diffuseColor,
+			Specular: mgl32.Vec3{1, 1, 1},
+		}},
+	})
+
+	// activate shader
+	ct.ShaderProgram.Use()
 
-	// camera/view transformation
-	projection := mgl32.Perspective(mgl32.DegToRad(float32(ct.camera.Zoom)), float32(800/600), 0.1, 100)
-	view := ct.camera.GetViewMatrix()
-	utils.SetMat4(ct.ShaderProgram, "view", &view)
-	utils.SetMat4(ct.ShaderProgram, "projection", &projection)
+	// material stuff
+	ct.ShaderProgram.SetFloat("material.shininess", 64.0)
 
 	// world transforms
 	model := mgl32.Ident4()
 	// model = model.Mul4(mgl32.Scale3D(3, 3, 3))
-	utils.SetMat4(ct.ShaderProgram, "model", &model)
+	ct.ShaderProgram.SetMat4("model", &model)
 
 	// texture stuff
 	gl.ActiveTexture(gl.TEXTURE0)
@@ -160,12 +196,11 @@ func (ct *LightingMaps) Draw() {
 	gl.DrawArrays(gl.TRIANGLES, 0, 36)
 
 	// Draw the Lamp
-	gl.UseProgram(ct.LightCubeShader)
-	utils.SetMat4(ct.LightCubeShader, "view", &view)
-	utils.SetMat4(ct.LightCubeShader, "projection", &projection)
+	ct.LightCubeShader.Use()
+	ct.LightCubeShader.SetFloat("intensity", lampIntensity())
 	model = mgl32.Ident4()
 	model = model.Mul4(mgl32.Translate3D(ct.lightPos.X(), ct.lightPos.Y(), ct.lightPos.Z())).Mul4(mgl32.Scale3D(0.2, 0.2, 0.2))
-	utils.SetMat4(ct.LightCubeShader, "model", &model)
+	ct.LightCubeShader.SetMat4("model", &model)
 
 	gl.BindVertexArray(ct.lightCubeVAO)
 	gl.DrawArrays(gl.TRIANGLES, 0, 36)
@@ -67,20 +67,29 @@ var cubePositions []mgl32.Vec3 = []mgl32.Vec3{
 	{-1.3, 1.0, -1.5},
 }
 
+// direction of the sun of the directional light renders, it shines down and slightly forward
+var sunDirection = mgl32.Vec3{-0.2, -1.0, -0.3}
+
 type DirectionalLight struct {
-	ShaderProgram, LightCubeShader uint32
+	ShaderProgram, LightCubeShader utils.Shader
 	VBO, cubeVAO                   uint32
 	lightCubeVAO                   uint32
 	camera                         utils.Camera
 	diffuseMap, specularMap        uint32
+	shaders                        utils.ShaderManager
+	cameraUBO, lightsUBO           *utils.UniformBuffer
 }
 
 func (ct *DirectionalLight) InitGLPipeLine() {
 
 	ct.camera = utils.NewCamera(mgl32.Vec3{0.5, 1.0, 4.0}, mgl32.Vec3{0, 1, 0}, utils.YAW, utils.PITCH)
 
-	ct.ShaderProgram = utils.NewShader("./shaders/Lighting/4-LightingMapsVert.glsl", "./shaders/Lighting/5-DirectionalLightFrag.glsl")
-	ct.LightCubeShader = utils.NewShader("./shaders/Lighting/1-LightVert.glsl", "./shaders/Lighting/1-LightFrag.glsl")
+	ct.shaders.Watch(&ct.ShaderProgram.ID, "./shaders/Lighting/4-LightingMapsVert.glsl", "./shaders/Lighting/5-DirectionalLightFrag.glsl", setMaterialSamplers)
+	ct.shaders.Watch(&ct.LightCubeShader.ID, "./shaders/Lighting/6-LightCubeVert.glsl", "./shaders/Lighting/1-LightFrag.glsl", nil)
+
+	// both programs read the matrices and the light from these, see Draw
+	ct.cameraUBO = utils.NewUniformBuffer(utils.CameraBinding)
+	ct.lightsUBO = utils.NewUniformBuffer(utils.LightsBinding)
 
 	gl.GenVertexArrays(1, &ct.cubeVAO)
 	gl.GenBuffers(1, &ct.VBO)
@@ -113,45 +122,49 @@ func (ct *DirectionalLight) InitGLPipeLine() {
 	gl.EnableVertexAttribArray(0)
 
 	// Texture Stuff
-	ct.diffuseMap = utils.New2DTexture(gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR, "./assets/container2.png")
-	ct.specularMap = utils.New2DTexture(gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR, "./assets/container2_specular.png")
-	gl.UseProgram(ct.ShaderProgram)
-	utils.SetInt(ct.ShaderProgram, "material.diffuse", 0)
-	utils.SetInt(ct.ShaderProgram, "material.specular", 1)
+	ct.diffuseMap = utils.NewTexture("./assets/container2.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR, ColorSpace: diffuseColorSpace()})
+	ct.specularMap = utils.NewTexture("./assets/container2_specular.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR})
+	setMaterialSamplers(ct.ShaderProgram.ID)
 
 }
 
 func (ct *DirectionalLight) Draw() {
 
+	ct.shaders.Poll()
+
 	gl.ClearColor(0.1, 0.1, 0.1, 1.0)
 	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 
-	// activate shader
-	gl.UseProgram(ct.ShaderProgram)
-	utils.SetVec3(ct.ShaderProgram, "light.direction", &mgl32.Vec3{-0.2, -1.0, -0.3})
-	utils.SetVec3(ct.ShaderProgram, "viewPos", &(ct.camera.Position))
-
-	// material stuff
-	utils.SetFloat(ct.ShaderProgram, "material.shininess", 32.0)
+	// camera/view transformation
+	ct.cameraUBO.Update(&utils.CameraUniforms{
+		Projection: mgl32.Perspective(mgl32.DegToRad(float32(ct.camera.Zoom)), float32(800/600), 0.1, 100),
+		View:       ct.camera.GetViewMatrix(),
+		ViewPos:    ct.camera.Position,
+	})
 
 	// Light color
 	lightColor := mgl32.Vec3{1, 1, 1}
-	diffuseColor := lightColor.Mul(0.5)
-	ambientColor := lightColor.Mul(0.2)
-	utils.SetVec3(ct.ShaderProgram, "light.ambient", &ambientColor)
-	utils.SetVec3(ct.ShaderProgram, "light.diffuse", &diffuseColor)
-	utils.SetVec3(ct.ShaderProgram, "light.specular", &mgl32.Vec3{1, 1, 1})
+	ct.lightsUBO.Update(&utils.LightsUniforms{
+		DirLight: utils.DirLightUniform{
+			Direction: sunDirection,
+			Ambient:   lightColor.Mul(0.2),
+			Diffuse:   lightColor.Mul(0.5),
+			Specular:  mgl32.Vec3{1, 1, 1},
+		},
+		// unused, but the block always has room for one
+		PointLights: make([]utils.PointLightUniform, 1),
+	})
 
-	// camera/view transformation
-	projection := mgl32.Perspective(mgl32.DegToRad(float32(ct.camera.Zoom)), float32(800/600), 0.1, 100)
-	view := ct.camera.GetViewMatrix()
-	utils.SetMat4(ct.ShaderProgram, "view", &view)
-	utils.SetMat4(ct.ShaderProgram, "projection", &projection)
+	// activate shader
+	ct.ShaderProgram.Use()
+
+	// material stuff
+	ct.ShaderProgram.SetFloat("material.shininess", 32.0)
 
 	// world transforms
 	model := mgl32.Ident4()
 	// model = model.Mul4(mgl32.Scale3D(3, 3, 3))
-	utils.SetMat4(ct.ShaderProgram, "model", &model)
+	ct.ShaderProgram.SetMat4("model", &model)
 
 	// texture stuff
 	gl.ActiveTexture(gl.TEXTURE0)
@@ -170,7 +183,7 @@ func (ct *DirectionalLight) Draw() {
 		model := mgl32.Ident4().Mul4(mgl32.Translate3D(cubePositions[i][0], cubePositions[i][1], cubePositions[i][2]))
 		angle := i * 20.0
 		model = model.Mul4(mgl32.HomogRotate3D(mgl32.DegToRad(float32(angle)), mgl32.Vec3{1, 0.3, 0.5}))
-		utils.SetMat4(ct.ShaderProgram, "model", &model)
+		ct.ShaderProgram.SetMat4("model", &model)
 		gl.DrawArrays(gl.TRIANGLES, 0, 36)
 	}
 }
@@ -224,12 +237,14 @@ func (ct *DirectionalLight) ScrollCallback(window *glfw.Window, xoff float64, yo
 }
 
 type PointLight struct {
-	ShaderProgram, LightCubeShader uint32
+	ShaderProgram, LightCubeShader utils.Shader
 	VBO, cubeVAO                   uint32
 	lightCubeVAO                   uint32
 	camera                         utils.Camera
 	diffuseMap, specularMap        uint32
 	lightPos                       mgl32.Vec3
+	shaders                        utils.ShaderManager
+	cameraUBO, lightsUBO           *utils.UniformBuffer
 }
 
 func (ct *PointLight) InitGLPipeLine() {
@@ -238,8 +253,12 @@ func (ct *PointLight) InitGLPipeLine() {
 
 	ct.camera = utils.NewCamera(mgl32.Vec3{0.5, 1.0, 4.0}, mgl32.Vec3{0, 1, 0}, utils.YAW, utils.PITCH)
 
-	ct.ShaderProgram = utils.NewShader("./shaders/Lighting/4-LightingMapsVert.glsl", "./shaders/Lighting/5-PointLightFrag.glsl")
-	ct.LightCubeShader = utils.NewShader("./shaders/Lighting/1-LightVert.glsl", "./shaders/Lighting/1-LightFrag.glsl")
+	ct.shaders.Watch(&ct.ShaderProgram.ID, "./shaders/Lighting/4-LightingMapsVert.glsl", "./shaders/Lighting/5-PointLightFrag.glsl", setMaterialSamplers)
+	ct.shaders.Watch(&ct.LightCubeShader.ID, "./shaders/Lighting/6-LightCubeVert.glsl", "./shaders/Lighting/1-LightFrag.glsl", nil)
+
+	// both programs read the matrices and the light from these, see Draw
+	ct.cameraUBO = utils.NewUniformBuffer(utils.CameraBinding)
+	ct.lightsUBO = utils.NewUniformBuffer(utils.LightsBinding)
 
 	gl.GenVertexArrays(1, &ct.cubeVAO)
 	gl.GenBuffers(1, &ct.VBO)
@@ -272,44 +291,48 @@ func (ct *PointLight) InitGLPipeLine() {
 	gl.EnableVertexAttribArray(0)
 
 	// Texture Stuff
-	ct.diffuseMap = utils.New2DTexture(gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR, "./assets/container2.png")
-	ct.specularMap = utils.New2DTexture(gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR, "./assets/container2_specular.png")
-	gl.UseProgram(ct.ShaderProgram)
-	utils.SetInt(ct.ShaderProgram, "material.diffuse", 0)
-	utils.SetInt(ct.ShaderProgram, "material.specular", 1)
+	ct.diffuseMap = utils.NewTexture("./assets/container2.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR, ColorSpace: diffuseColorSpace()})
+	ct.specularMap = utils.NewTexture("./assets/container2_specular.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR})
+	setMaterialSamplers(ct.ShaderProgram.ID)
 
 }
 
 func (ct *PointLight) Draw() {
 
+	ct.shaders.Poll()
+
 	gl.ClearColor(0.1, 0.1, 0.1, 1.0)
 	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 
-	// activate shader
-	gl.UseProgram(ct.ShaderProgram)
-	utils.SetVec3(ct.ShaderProgram, "light.position", &(ct.lightPos))
-	utils.SetVec3(ct.ShaderProgram, "viewPos", &(ct.camera.Position))
+	// camera/view transformation, uploaded once for both programs
+	ct.cameraUBO.Update(&utils.CameraUniforms{
+		Projection: mgl32.Perspective(mgl32.DegToRad(float32(ct.camera.Zoom)), float32(800/600), 0.1, 100),
+		View:       ct.camera.GetViewMatrix(),
+		ViewPos:    ct.camera.Position,
+	})
 
 	// Light color
-	utils.SetVec3(ct.ShaderProgram, "light.ambient", &mgl32.Vec3{0.2, 0.2, 0.2})
-	utils.SetVec3(ct.ShaderProgram, "light.diffuse", &mgl32.Vec3{0.5, 0.5, 0.5})
-	utils.SetVec3(ct.ShaderProgram, "light.specular", &mgl32.Vec3{1, 1, 1})
-	utils.SetFloat(ct.ShaderProgram, "light.constant", 1.0)
-	utils.SetFloat(ct.ShaderProgram, "light.linear", 0.09)
-	utils.SetFloat(ct.ShaderProgram, "light.quadratic", 0.032)
+	ct.lightsUBO.Update(&utils.LightsUniforms{
+		PointLights: []utils.PointLightUniform{{
+			Position:  ct.lightPos,
+			Constant:  1.0,
+			Linear:    0.09,
+			Quadratic: 0.032,
+			Ambient:   mgl32.Vec3{0.2, 0.2, 0.2},
+			Diffuse:   mgl32.Vec3{0.5, 0.5, 0.5},
+			Specular:  mgl32.Vec3{1, 1, 1},
+		}},
+	})
 
-	// material stuff
-	utils.SetFloat(ct.ShaderProgram, "material.shininess", 32.0)
+	// activate shader
+	ct.ShaderProgram.Use()
 
-	// camera/view transformation
-	projection := mgl32.Perspective(mgl32.DegToRad(float32(ct.camera.Zoom)), float32(800/600), 0.1, 100)
-	view := ct.camera.GetViewMatrix()
-	utils.SetMat4(ct.ShaderProgram, "view", &view)
-	utils.SetMat4(ct.ShaderProgram, "projection", &projection)
+	// material stuff
+	ct.ShaderProgram.SetFloat("material.shininess", 32.0)
 
 	// world transforms
 	model := mgl32.Ident4()
-	utils.SetMat4(ct.ShaderProgram, "model", &model)
+	ct.ShaderProgram.SetMat4("model", &model)
 
 	// texture stuff
 	gl.ActiveTexture(gl.TEXTURE0)
@@ -324,17 +347,16 @@ func (ct *PointLight) Draw() {
 		model := mgl32.Ident4().Mul4(mgl32.Translate3D(cubePositions[i][0], cubePositions[i][1], cubePositions[i][2]))
 		angle := i * 20.0
 		model = model.Mul4(mgl32.HomogRotate3D(mgl32.DegToRad(float32(angle)), mgl32.Vec3{1, 0.3, 0.5}))
-		utils.SetMat4(ct.ShaderProgram, "model", &model)
+		ct.ShaderProgram.SetMat4("model", &model)
 		gl.DrawArrays(gl.TRIANGLES, 0, 36)
 	}
 
 	// Draw the Lamp
-	gl.UseProgram(ct.LightCubeShader)
-	utils.SetMat4(ct.LightCubeShader, "view", &view)
-	utils.SetMat4(ct.LightCubeShader, "projection", &projection)
+	ct.LightCubeShader.Use()
+	ct.LightCubeShader.SetFloat("intensity", lampIntensity())
 	model = mgl32.Ident4()
 	model = model.Mul4(mgl32.Translate3D(ct.lightPos.X(), ct.lightPos.Y(), ct.lightPos.Z())).Mul4(mgl32.Scale3D(0.2, 0.2, 0.2))
-	utils.SetMat4(ct.LightCubeShader, "model", &model)
+	ct.LightCubeShader.SetMat4("model", &model)
 
 	gl.BindVertexArray(ct.lightCubeVAO)
 	gl.DrawArrays(gl.TRIANGLES, 0, 36)
@@ -389,19 +411,25 @@ func (ct *PointLight) ScrollCallback(window *glfw.Window, xoff float64, yoff flo
 }
 
 type Spotlight struct {
-	ShaderProgram, LightCubeShader uint32
+	ShaderProgram, LightCubeShader utils.Shader
 	VBO, cubeVAO                   uint32
 	lightCubeVAO                   uint32
 	camera                         utils.Camera
 	diffuseMap, specularMap        uint32
 	lightPos                       mgl32.Vec3
+	shaders                        utils.ShaderManager
+	cameraUBO, lightsUBO           *utils.UniformBuffer
 }
 
 func (ct *Spotlight) InitGLPipeLine() {
 
 	ct.camera = utils.NewCamera(mgl32.Vec3{0.5, 1.0, 4.0}, mgl32.Vec3{0, 1, 0}, utils.YAW, utils.PITCH)
-	ct.ShaderProgram = utils.NewShader("./shaders/Lighting/4-LightingMapsVert.glsl", "./shaders/Lighting/5-SpotLightFrag.glsl")
-	ct.LightCubeShader = utils.NewShader("./shaders/Lighting/1-LightVert.glsl", "./shaders/Lighting/1-LightFrag.glsl")
+	ct.shaders.Watch(&ct.ShaderProgram.ID, "./shaders/Lighting/4-LightingMapsVert.glsl", "./shaders/Lighting/5-SpotLightFrag.glsl", setMaterialSamplers)
+	ct.shaders.Watch(&ct.LightCubeShader.ID, "./shaders/Lighting/6-LightCubeVert.glsl", "./shaders/Lighting/1-LightFrag.glsl", nil)
+
+	// both programs read the matrices and the light from these, see Draw
+	ct.cameraUBO = utils.NewUniformBuffer(utils.CameraBinding)
+	ct.lightsUBO = utils.NewUniformBuffer(utils.LightsBinding)
 
 	gl.GenVertexArrays(1, &ct.cubeVAO)
 	gl.GenBuffers(1, &ct.VBO)
@@ -434,48 +462,53 @@ func (ct *Spotlight) InitGLPipeLine() {
 	gl.EnableVertexAttribArray(0)
 
 	// Texture Stuff
-	ct.diffuseMap = utils.New2DTexture(gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR, "./assets/container2.png")
-	ct.specularMap = utils.New2DTexture(gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR, "./assets/container2_specular.png")
-	gl.UseProgram(ct.ShaderProgram)
-	utils.SetInt(ct.ShaderProgram, "material.diffuse", 0)
-	utils.SetInt(ct.ShaderProgram, "material.specular", 1)
+	ct.diffuseMap = utils.NewTexture("./assets/container2.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR, ColorSpace: diffuseColorSpace()})
+	ct.specularMap = utils.NewTexture("./assets/container2_specular.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR})
+	setMaterialSamplers(ct.ShaderProgram.ID)
 
 }
 
 func (ct *Spotlight) Draw() {
 
+	ct.shaders.Poll()
+
 	gl.ClearColor(0.1, 0.1, 0.1, 1.0)
 	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 
+	// camera/view transformation, uploaded once for both programs
+	ct.cameraUBO.Update(&utils.CameraUniforms{
+		Projection: mgl32.Perspective(mgl32.DegToRad(float32(ct.camera.Zoom)), float32(800/600), 0.1, 100),
+		View:       ct.camera.GetViewMatrix(),
+		ViewPos:    ct.camera.Position,
+	})
+
+	// Light stuff, the spotlight is a flashlight held by the camera
+	ct.lightsUBO.Update(&utils.LightsUniforms{
+		SpotLight: utils.SpotLightUniform{
+			Position:    ct.camera.Position,
+			Direction:   ct.camera.Front,
+			CutOff:      float32(math.Cos(float64(mgl32.DegToRad(12.5)))),
+			OuterCutOff: float32(math.Cos(float64(mgl32.DegToRad(17.5)))),
+			Constant:    1.0,
+			Linear:      0.09,
+			Quadratic:   0.032,
+			Ambient:     mgl32.Vec3{0.2, 0.2, 0.2},
+			Diffuse:     mgl32.Vec3{0.5, 0.5, 0.5},
+			Specular:    mgl32.Vec3{1, 1, 1},
+		},
+		// unused, but the block always has room for one
+		PointLights: make([]utils.PointLightUniform, 1),
+	})
+
 	// activate shader
-	gl.UseProgram(ct.ShaderProgram)
-	utils.SetVec3(ct.ShaderProgram, "light.position", &ct.camera.Position)
-	utils.SetVec3(ct.ShaderProgram, "light.direction", &ct.camera.Front)
-	utils.SetFloat(ct.ShaderProgram, "light.cutOff", float32(math.Cos(float64(mgl32.DegToRad(12.5)))))
-	utils.SetFloat(ct.ShaderProgram, "light.outerCutOff", float32(math.Cos(float64(mgl32.DegToRad(17.5)))))
-	utils.SetVec3(ct.ShaderProgram, "viewPos", &(ct.camera.Position))
-
-	// Light stuff
-	utils.SetVec3(ct.ShaderProgram, "light.ambient", &mgl32.Vec3{0.2, 0.2, 0.2})
-	utils.SetVec3(ct.ShaderProgram, "light.diffuse", &mgl32.Vec3{0.5, 0.5, 0.5})
-	utils.SetVec3(ct.ShaderProgram, "light.specular", &mgl32.Vec3{1, 1, 1})
-
-	utils.SetFloat(ct.ShaderProgram, "light.constant", 1.0)
-	utils.SetFloat(ct.ShaderProgram, "light.linear", 0.09)
-	utils.SetFloat(ct.ShaderProgram, "light.quadratic", 0.032)
+	ct.ShaderProgram.Use()
 
 	// material stuff
-	utils.SetFloat(ct.ShaderProgram, "material.shininess", 32.0)
-
-	// camera/view transformation
-	projection := mgl32.Perspective(mgl32.DegToRad(float32(ct.camera.Zoom)), float32(800/600), 0.1, 100)
-	view := ct.camera.GetViewMatrix()
-	utils.SetMat4(ct.ShaderProgram, "view", &view)
-	utils.SetMat4(ct.ShaderProgram, "projection", &projection)
+	ct.ShaderProgram.SetFloat("material.shininess", 32.0)
 
 	// world transforms
 	model := mgl32.Ident4()
-	utils.SetMat4(ct.ShaderProgram, "model", &model)
+	ct.ShaderProgram.SetMat4("model", &model)
 
 	// texture stuff
 	gl.ActiveTexture(gl.TEXTURE0)
@@ -490,17 +523,16 @@ func (ct *Spotlight) Draw() {
 		model := mgl32.Ident4().Mul4(mgl32.Translate3D(cubePositions[i][0], cubePositions[i][1], cubePositions[i][2]))
 		angle := i * 20.0
 		model = model.Mul4(mgl32.HomogRotate3D(mgl32.DegToRad(float32(angle)), mgl32.Vec3{1, 0.3, 0.5}))
-		utils.SetMat4(ct.ShaderProgram, "model", &model)
+		ct.ShaderProgram.SetMat4("model", &model)
 		gl.DrawArrays(gl.TRIANGLES, 0, 36)
 	}
 
 	// Draw the Lamp
-	gl.UseProgram(ct.LightCubeShader)
-	utils.SetMat4(ct.LightCubeShader, "view", &view)
-	utils.SetMat4(ct.LightCubeShader, "projection", &projection)
+	ct.LightCubeShader.Use()
+	ct.LightCubeShader.SetFloat("intensity", lampIntensity())
 	model = mgl32.Ident4()
 	model = model.Mul4(mgl32.Translate3D(ct.lightPos.X(), ct.lightPos.Y(), ct.lightPos.Z())).Mul4(mgl32.Scale3D(0.2, 0.2, 0.2))
-	utils.SetMat4(ct.LightCubeShader, "model", &model)
+	ct.LightCubeShader.SetMat4("model", &model)
 
 	gl.BindVertexArray(ct.lightCubeVAO)
 	gl.DrawArrays(gl.TRIANGLES, 0, 36)
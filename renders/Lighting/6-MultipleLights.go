@@ -3,7 +3,9 @@ package lighting
 import (
 	"fmt"
 	"math"
+	"opgl-learn/renders"
 	"opgl-learn/utils"
+	"strconv"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
@@ -17,19 +19,43 @@ var pointLightPositions []mgl32.Vec3 = []mgl32.Vec3{
 	{0.0, 0.0, -3.0},
 }
 
+// pointShadows.glsl has room for this many shadow casting point lights
+const maxPointShadowCasters = 4
+
 type MultipleLights struct {
-	ShaderProgram, LightCubeShader uint32
+	ShaderProgram, LightCubeShader utils.Shader
 	VBO, cubeVAO                   uint32
 	lightCubeVAO                   uint32
 	camera                         utils.Camera
 	diffuseMap, specularMap        uint32
+	shaders                        utils.ShaderManager
+	cameraUBO, lightsUBO           *utils.UniformBuffer
+	// one for each of the first renders.PointShadowCasters point lights
+	pointShadows []*utils.PointShadowMap
 }
 
 func (ct *MultipleLights) InitGLPipeLine() {
 
 	ct.camera = utils.NewCamera(mgl32.Vec3{0.5, 1.0, 4.0}, mgl32.Vec3{0, 1, 0}, utils.YAW, utils.PITCH)
-	ct.ShaderProgram = utils.NewShader("./shaders/Lighting/4-LightingMapsVert.glsl", "./shaders/Lighting/6-MultipleLightsFrag.glsl")
-	ct.LightCubeShader = utils.NewShader("./shaders/Lighting/1-LightVert.glsl", "./shaders/Lighting/1-LightFrag.glsl")
+	for range min(renders.PointShadowCasters, len(pointLightPositions), maxPointShadowCasters) {
+		shadow, err := utils.NewPointShadowMap(1024)
+		if err != nil {
+			fmt.Println(err)
+			break
+		}
+		ct.pointShadows = append(ct.pointShadows, shadow)
+	}
+
+	defines := utils.Defines{
+		"NR_POINT_LIGHTS":   strconv.Itoa(len(pointLightPositions)),
+		"NR_SHADOW_CASTERS": strconv.Itoa(len(ct.pointShadows)),
+	}
+	ct.shaders.WatchWithDefines(&ct.ShaderProgram.ID, "./shaders/Lighting/6-MultipleLightsVert.glsl", "./shaders/Lighting/6-MultipleLightsFrag.glsl", defines, setMaterialSamplers)
+	ct.shaders.Watch(&ct.LightCubeShader.ID, "./shaders/Lighting/6-LightCubeVert.glsl", "./shaders/Lighting/1-LightFrag.glsl", nil)
+
+	// both programs read the matrices and lights from these, see Draw
+	ct.cameraUBO = utils.NewUniformBuffer(utils.CameraBinding)
+	ct.lightsUBO = utils.NewUniformBuffer(utils.LightsBinding)
 
 	gl.GenVertexArrays(1, &ct.cubeVAO)
 	gl.GenBuffers(1, &ct.VBO)
@@ -62,64 +88,77 @@ func (ct *MultipleLights) InitGLPipeLine() {
 	gl.EnableVertexAttribArray(0)
 
 	// Texture Stuff
-	ct.diffuseMap = utils.New2DTexture(gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR, "./assets/container2.png")
-	ct.specularMap = utils.New2DTexture(gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR, "./assets/container2_specular.png")
-	gl.UseProgram(ct.ShaderProgram)
-	utils.SetInt(ct.ShaderProgram, "material.diffuse", 0)
-	utils.SetInt(ct.ShaderProgram, "material.specular", 1)
+	ct.diffuseMap = utils.NewTexture("./assets/container2.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR, ColorSpace: diffuseColorSpace()})
+	ct.specularMap = utils.NewTexture("./assets/container2_specular.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR})
+	setMaterialSamplers(ct.ShaderProgram.ID)
 
 }
 
 func (ct *MultipleLights) Draw() {
 
+	ct.shaders.Poll()
+
+	// distance from each shadow casting light to the containers around it
+	gl.BindVertexArray(ct.cubeVAO)
+	for i, shadow := range ct.pointShadows {
+		shadow.Render(pointLightPositions[i], func(depth *utils.Shader) {
+			ct.drawContainers(func(model *mgl32.Mat4) { depth.SetMat4("model", model) })
+		})
+	}
+
 	gl.ClearColor(0.1, 0.1, 0.1, 1.0)
 	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 
-	// activate shader
-	gl.UseProgram(ct.ShaderProgram)
-	utils.SetVec3(ct.ShaderProgram, "viewPos", &(ct.camera.Position))
-	utils.SetFloat(ct.ShaderProgram, "material.shininess", 32.0)
-
-	// directional Light
-	utils.SetVec3(ct.ShaderProgram, "dirLight.direction", &mgl32.Vec3{-0.2, -1.0, -0.3})
-	utils.SetVec3(ct.ShaderProgram, "dirLight.ambient", &mgl32.Vec3{0.05, 0.05, 0.05})
-	utils.SetVec3(ct.ShaderProgram, "dirLight.diffuse", &mgl32.Vec3{0.4, 0.4, 0.4})
-	utils.SetVec3(ct.ShaderProgram, "dirLight.specular", &mgl32.Vec3{0.5, 0.5, 0.5})
-
-	// The 4 Point Lights
-	for i := 0; i < 4; i++ {
-		utils.SetVec3(ct.ShaderProgram, fmt.Sprintf("pointLights[%d].position", i), &pointLightPositions[i])
-		utils.SetVec3(ct.ShaderProgram, fmt.Sprintf("pointLights[%d].ambient", i), &mgl32.Vec3{0.05, 0.05, 0.05})
-		utils.SetVec3(ct.ShaderProgram, fmt.Sprintf("pointLights[%d].diffuse", i), &mgl32.Vec3{0.8, 0.8, 0.8})
-		utils.SetVec3(ct.ShaderProgram, fmt.Sprintf("pointLights[%d].specular", i), &mgl32.Vec3{1.0, 1.0, 1.0})
-		utils.SetFloat(ct.ShaderProgram, fmt.Sprintf("pointLights[%d].constant", i), 1.0)
-		utils.SetFloat(ct.ShaderProgram, fmt.Sprintf("pointLights[%d].linear", i), 0.09)
-		utils.SetFloat(ct.ShaderProgram, fmt.Sprintf("pointLights[%d].quadratic", i), 0.032)
-	}
-
-	// SpotLight
-	utils.SetVec3(ct.ShaderProgram, "spotLight.position", &ct.camera.Position)
-	utils.SetVec3(ct.ShaderProgram, "spotLight.direction", &ct.camera.Front)
-	utils.SetVec3(ct.ShaderProgram, "spotLight.ambient", &mgl32.Vec3{0.2, 0.2, 0.2})
-	utils.SetVec3(ct.ShaderProgram, "spotLight.diffuse", &mgl32.Vec3{1.0, 1.0, 1.0})
-	utils.SetVec3(ct.ShaderProgram, "spotLight.specular", &mgl32.Vec3{1, 1, 1})
-	utils.SetFloat(ct.ShaderProgram, "spotLight.constant", 1.0)
-	utils.SetFloat(ct.ShaderProgram, "spotLight.linear", 0.09)
-	utils.SetFloat(ct.ShaderProgram, "spotLight.quadratic", 0.032)
-	utils.SetFloat(ct.ShaderProgram, "spotLight.cutOff", float32(math.Cos(float64(mgl32.DegToRad(12.5)))))
-	utils.SetFloat(ct.ShaderProgram, "spotLight.outerCutOff", float32(math.Cos(float64(mgl32.DegToRad(15)))))
-
-	// material stuff
-
-	// camera/view transformation
+	// camera/view transformation, uploaded once for every program
 	projection := mgl32.Perspective(mgl32.DegToRad(float32(ct.camera.Zoom)), float32(800/600), 0.1, 100)
 	view := ct.camera.GetViewMatrix()
-	utils.SetMat4(ct.ShaderProgram, "view", &view)
-	utils.SetMat4(ct.ShaderProgram, "projection", &projection)
+	ct.cameraUBO.Update(&utils.CameraUniforms{
+		Projection: projection,
+		View:       view,
+		ViewPos:    ct.camera.Position,
+	})
+
+	// lights, the spotlight follows the camera so this changes every frame too
+	lights := utils.LightsUniforms{
+		DirLight: utils.DirLightUniform{
+			Direction: sunDirection,
+			Ambient:   mgl32.Vec3{0.05, 0.05, 0.05},
+			Diffuse:   mgl32.Vec3{0.4, 0.4, 0.4},
+			Specular:  mgl32.Vec3{0.5, 0.5, 0.5},
+		},
+		SpotLight: utils.SpotLightUniform{
+			Position:    ct.camera.Position,
+			Direction:   ct.camera.Front,
+			CutOff:      float32(math.Cos(float64(mgl32.DegToRad(12.5)))),
+			OuterCutOff: float32(math.Cos(float64(mgl32.DegToRad(15)))),
+			Constant:    1.0,
+			Linear:      0.09,
+			Quadratic:   0.032,
+			Ambient:     mgl32.Vec3{0.2, 0.2, 0.2},
+			Diffuse:     mgl32.Vec3{1.0, 1.0, 1.0},
+			Specular:    mgl32.Vec3{1, 1, 1},
+		},
+	}
+	for i := 0; i < len(pointLightPositions); i++ {
+		lights.PointLights = append(lights.PointLights, utils.PointLightUniform{
+			Position:  pointLightPositions[i],
+			Constant:  1.0,
+			Linear:    0.09,
+			Quadratic: 0.032,
+			Ambient:   mgl32.Vec3{0.05, 0.05, 0.05},
+			Diffuse:   mgl32.Vec3{0.8, 0.8, 0.8},
+			Specular:  mgl32.Vec3{1.0, 1.0, 1.0},
+		})
+	}
+	ct.lightsUBO.Update(&lights)
+
+	// activate shader
+	ct.ShaderProgram.Use()
+	ct.ShaderProgram.SetFloat("material.shininess", 32.0)
 
 	// world transforms
 	model := mgl32.Ident4()
-	utils.SetMat4(ct.ShaderProgram, "model", &model)
+	ct.ShaderProgram.SetMat4("model", &model)
 
 	// texture stuff
 	gl.ActiveTexture(gl.TEXTURE0)
@@ -128,31 +167,40 @@ func (ct *MultipleLights) Draw() {
 	gl.ActiveTexture(gl.TEXTURE1)
 	gl.BindTexture(gl.TEXTURE_2D, ct.specularMap)
 
+	// shadow cubemaps on the units after the material
+	for i, shadow := range ct.pointShadows {
+		shadow.SetUniforms(ct.ShaderProgram.ID, i, uint32(2+i))
+	}
+
 	// Render containers
 	gl.BindVertexArray(ct.cubeVAO)
-	for i := 0; i < len(cubePositions); i++ {
-		model := mgl32.Ident4().Mul4(mgl32.Translate3D(cubePositions[i][0], cubePositions[i][1], cubePositions[i][2]))
-		angle := i * 20.0
-		model = model.Mul4(mgl32.HomogRotate3D(mgl32.DegToRad(float32(angle)), mgl32.Vec3{1, 0.3, 0.5}))
-		utils.SetMat4(ct.ShaderProgram, "model", &model)
-		gl.DrawArrays(gl.TRIANGLES, 0, 36)
-	}
+	ct.drawContainers(func(model *mgl32.Mat4) { ct.ShaderProgram.SetMat4("model", model) })
 
 	// Draw the Lamp
-	gl.UseProgram(ct.LightCubeShader)
-	utils.SetMat4(ct.LightCubeShader, "view", &view)
-	utils.SetMat4(ct.LightCubeShader, "projection", &projection)
+	ct.LightCubeShader.Use()
+	ct.LightCubeShader.SetFloat("intensity", lampIntensity())
 	gl.BindVertexArray(ct.lightCubeVAO)
 
-	for i := 0; i < 4; i++ {
+	for i := 0; i < len(pointLightPositions); i++ {
 		model = mgl32.Ident4()
 		model = model.Mul4(mgl32.Translate3D(pointLightPositions[i].X(), pointLightPositions[i].Y(), pointLightPositions[i].Z())).Mul4(mgl32.Scale3D(0.2, 0.2, 0.2))
-		utils.SetMat4(ct.LightCubeShader, "model", &model)
+		ct.LightCubeShader.SetMat4("model", &model)
 		gl.DrawArrays(gl.TRIANGLES, 0, 36)
 	}
 
 }
 
+// Draw the containers with the cube VAO bound, setModel uploads the model matrix to the program in use.
+func (ct *MultipleLights) drawContainers(setModel func(model *mgl32.Mat4)) {
+	for i := 0; i < len(cubePositions); i++ {
+		model := mgl32.Ident4().Mul4(mgl32.Translate3D(cubePositions[i][0], cubePositions[i][1], cubePositions[i][2]))
+		angle := i * 20.0
+		model = model.Mul4(mgl32.HomogRotate3D(mgl32.DegToRad(float32(angle)), mgl32.Vec3{1, 0.3, 0.5}))
+		setModel(&model)
+		gl.DrawArrays(gl.TRIANGLES, 0, 36)
+	}
+}
+
 func (ct *MultipleLights) KeyboardCallback(window *glfw.Window) {
 
 	currentFrame := glfw.GetTime()
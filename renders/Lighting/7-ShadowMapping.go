@@ -0,0 +1,242 @@
+package lighting
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"opgl-learn/utils"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// the ground the containers cast their shadows on
+const groundHeight, groundSize = -3.5, 25.0
+
+var groundVertices = []float32{
+	// positions                             // normals    // texture coords
+	groundSize / 2, groundHeight, groundSize / 2, 0.0, 1.0, 0.0, 1.0, 0.0,
+	-groundSize / 2, groundHeight, -groundSize / 2, 0.0, 1.0, 0.0, 0.0, 1.0,
+	-groundSize / 2, groundHeight, groundSize / 2, 0.0, 1.0, 0.0, 0.0, 0.0,
+
+	groundSize / 2, groundHeight, groundSize / 2, 0.0, 1.0, 0.0, 1.0, 0.0,
+	groundSize / 2, groundHeight, -groundSize / 2, 0.0, 1.0, 0.0, 1.0, 1.0,
+	-groundSize / 2, groundHeight, -groundSize / 2, 0.0, 1.0, 0.0, 0.0, 1.0,
+}
+
+// The containers of the directional light render over a ground plane, casting shadows from the sun.
+// P cycles the PCF radius from hard shadows up to the softest edges.
+type ShadowMapping struct {
+	ShaderProgram           utils.Shader
+	VBO, cubeVAO            uint32
+	groundVBO, groundVAO    uint32
+	camera                  utils.Camera
+	diffuseMap, specularMap uint32
+	groundDiffuse           uint32
+	groundSpecular          uint32
+	shaders                 utils.ShaderManager
+	cameraUBO, lightsUBO    *utils.UniformBuffer
+	shadowMap               *utils.ShadowMap
+	// what the sun has to cover, the ground and every container
+	bounds  utils.Bounds
+	pcfHeld bool
+}
+
+func (ct *ShadowMapping) InitGLPipeLine() {
+
+	ct.camera = utils.NewCamera(mgl32.Vec3{0.5, 2.0, 7.0}, mgl32.Vec3{0, 1, 0}, utils.YAW, -15)
+
+	ct.shaders.Watch(&ct.ShaderProgram.ID, "./shaders/Lighting/7-ShadowMappingVert.glsl", "./shaders/Lighting/7-ShadowMappingFrag.glsl", setMaterialSamplers)
+
+	// the program reads the matrices and the sun from these, see Draw
+	ct.cameraUBO = utils.NewUniformBuffer(utils.CameraBinding)
+	ct.lightsUBO = utils.NewUniformBuffer(utils.LightsBinding)
+
+	var err error
+	ct.shadowMap, err = utils.NewShadowMap(2048)
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	// Containers
+	gl.GenVertexArrays(1, &ct.cubeVAO)
+	gl.GenBuffers(1, &ct.VBO)
+	gl.BindVertexArray(ct.cubeVAO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, ct.VBO)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*len(vertices), gl.Ptr(vertices), gl.STATIC_DRAW)
+	setLitVertexLayout()
+
+	// Ground
+	gl.GenVertexArrays(1, &ct.groundVAO)
+	gl.GenBuffers(1, &ct.groundVBO)
+	gl.BindVertexArray(ct.groundVAO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, ct.groundVBO)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*len(groundVertices), gl.Ptr(groundVertices), gl.STATIC_DRAW)
+	setLitVertexLayout()
+
+	// the ground is a plain grey without highlights
+	ct.groundDiffuse = solidTexture(color.NRGBA{160, 160, 160, 255}, diffuseColorSpace())
+	ct.groundSpecular = solidTexture(color.NRGBA{0, 0, 0, 255}, utils.Linear)
+
+	// Texture Stuff
+	ct.diffuseMap = utils.NewTexture("./assets/container2.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR, ColorSpace: diffuseColorSpace()})
+	ct.specularMap = utils.NewTexture("./assets/container2_specular.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR})
+	setMaterialSamplers(ct.ShaderProgram.ID)
+
+	// a rotated unit cube never reaches further than half its diagonal from its centre
+	ct.bounds = utils.BoundsOf(
+		mgl32.Vec3{-groundSize / 2, groundHeight, -groundSize / 2},
+		mgl32.Vec3{groundSize / 2, groundHeight, groundSize / 2},
+	)
+	for _, position := range cubePositions {
+		ct.bounds = ct.bounds.Extend(position, 0.87)
+	}
+}
+
+// positions, normals and texture coordinates, 8 floats per vertex like vertices
+func setLitVertexLayout() {
+	gl.VertexAttribPointerWithOffset(0, 3, gl.FLOAT, false, 8*4, 0)
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointerWithOffset(1, 3, gl.FLOAT, false, 8*4, 3*4)
+	gl.EnableVertexAttribArray(1)
+	gl.VertexAttribPointerWithOffset(2, 2, gl.FLOAT, false, 8*4, 6*4)
+	gl.EnableVertexAttribArray(2)
+}
+
+// a 1x1 texture of a single colour
+func solidTexture(c color.NRGBA, space utils.ColorSpace) uint32 {
+	img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	img.SetNRGBA(0, 0, c)
+	return utils.NewTextureFromImage(img, utils.TextureOptions{MinFilter: gl.NEAREST, MagFilter: gl.NEAREST, NoMipmaps: true, ColorSpace: space})
+}
+
+// Draw the containers and the ground, setModel uploads the model matrix to the program in use.
+func (ct *ShadowMapping) drawScene(setModel func(model *mgl32.Mat4), textured bool) {
+	if textured {
+		gl.ActiveTexture(gl.TEXTURE0)
+		gl.BindTexture(gl.TEXTURE_2D, ct.diffuseMap)
+		gl.ActiveTexture(gl.TEXTURE1)
+		gl.BindTexture(gl.TEXTURE_2D, ct.specularMap)
+	}
+	gl.BindVertexArray(ct.cubeVAO)
+	for i := 0; i < len(cubePositions); i++ {
+		model := mgl32.Ident4().Mul4(mgl32.Translate3D(cubePositions[i][0], cubePositions[i][1], cubePositions[i][2]))
+		angle := i * 20.0
+		model = model.Mul4(mgl32.HomogRotate3D(mgl32.DegToRad(float32(angle)), mgl32.Vec3{1, 0.3, 0.5}))
+		setModel(&model)
+		gl.DrawArrays(gl.TRIANGLES, 0, 36)
+	}
+
+	if textured {
+		gl.ActiveTexture(gl.TEXTURE0)
+		gl.BindTexture(gl.TEXTURE_2D, ct.groundDiffuse)
+		gl.ActiveTexture(gl.TEXTURE1)
+		gl.BindTexture(gl.TEXTURE_2D, ct.groundSpecular)
+	}
+	model := mgl32.Ident4()
+	setModel(&model)
+	gl.BindVertexArray(ct.groundVAO)
+	gl.DrawArrays(gl.TRIANGLES, 0, 6)
+}
+
+func (ct *ShadowMapping) Draw() {
+
+	ct.shaders.Poll()
+
+	// first pass: depth as seen from the sun
+	if ct.shadowMap != nil {
+		depth := ct.shadowMap.Begin(utils.DirectionalLightSpace(sunDirection, ct.bounds))
+		ct.drawScene(func(model *mgl32.Mat4) { depth.SetMat4("model", model) }, false)
+		ct.shadowMap.End()
+	}
+
+	// second pass: the scene as usual, in shadow wherever the depth map has something closer to the sun
+	gl.ClearColor(0.1, 0.1, 0.1, 1.0)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+	// camera/view transformation
+	projection := mgl32.Perspective(mgl32.DegToRad(float32(ct.camera.Zoom)), 800.0/600.0, 0.1, 100)
+	view := ct.camera.GetViewMatrix()
+	ct.cameraUBO.Update(&utils.CameraUniforms{
+		Projection: projection,
+		View:       view,
+		ViewPos:    ct.camera.Position,
+	})
+
+	ct.lightsUBO.Update(&utils.LightsUniforms{
+		DirLight: utils.DirLightUniform{
+			Direction: sunDirection,
+			Ambient:   mgl32.Vec3{0.2, 0.2, 0.2},
+			Diffuse:   mgl32.Vec3{0.6, 0.6, 0.6},
+			Specular:  mgl32.Vec3{1, 1, 1},
+		},
+		// unused, but the block always has room for one
+		PointLights: make([]utils.PointLightUniform, 1),
+	})
+
+	ct.ShaderProgram.Use()
+	ct.ShaderProgram.SetFloat("material.shininess", 32.0)
+
+	if ct.shadowMap != nil {
+		ct.shadowMap.SetUniforms(ct.ShaderProgram.ID, 2)
+	}
+
+	ct.drawScene(func(model *mgl32.Mat4) { ct.ShaderProgram.SetMat4("model", model) }, true)
+}
+
+func (ct *ShadowMapping) KeyboardCallback(window *glfw.Window) {
+
+	currentFrame := glfw.GetTime()
+	deltaTime := currentFrame - lastFrame
+	lastFrame = currentFrame
+
+	if window.GetKey(glfw.KeyEscape) == glfw.Press {
+		window.SetShouldClose(true)
+	}
+	if window.GetKey(glfw.KeyW) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.FORWARD, deltaTime)
+	}
+	if window.GetKey(glfw.KeyS) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.BACKWARD, deltaTime)
+	}
+	if window.GetKey(glfw.KeyA) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.LEFT, deltaTime)
+	}
+	if window.GetKey(glfw.KeyD) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.RIGHT, deltaTime)
+	}
+	if window.GetKey(glfw.KeySpace) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.UP, deltaTime)
+	}
+	if window.GetKey(glfw.KeyLeftControl) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.DOWN, deltaTime)
+	}
+
+	// toggle once per key press, not every frame it is held
+	pcf := window.GetKey(glfw.KeyP) == glfw.Press
+	if pcf && !ct.pcfHeld && ct.shadowMap != nil {
+		ct.shadowMap.PCFRadius = (ct.shadowMap.PCFRadius + 1) % 4
+		fmt.Printf("pcf radius: %d\n", ct.shadowMap.PCFRadius)
+	}
+	ct.pcfHeld = pcf
+}
+
+func (ct *ShadowMapping) MouseCallback(window *glfw.Window, xpos float64, ypos float64) {
+	if firstMouse {
+		firstMouse = false
+		lastxPos = xpos
+		lastyPos = ypos
+	}
+
+	xoffset := xpos - lastxPos
+	yoffset := lastyPos - ypos
+	lastxPos = xpos
+	lastyPos = ypos
+
+	ct.camera.ProcessMouseMovement(xoffset, yoffset, true)
+}
+
+func (ct *ShadowMapping) ScrollCallback(window *glfw.Window, xoff float64, yoff float64) {
+	ct.camera.ProcessMouseScroll(yoff)
+}
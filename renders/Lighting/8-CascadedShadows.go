@@ -0,0 +1,234 @@
+package lighting
+
+import (
+	"fmt"
+	"image/color"
+	"opgl-learn/utils"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// How far the camera of the cascaded shadows render sees, and how many cascades cover that.
+const (
+	outdoorNear, outdoorFar = 0.1, 150.0
+	outdoorCascades         = 4
+	// the ground of the shadow mapping render, stretched out to the horizon
+	outdoorGroundScale = 12
+)
+
+// lower than the sun of the other renders, for long shadows
+var outdoorSun = mgl32.Vec3{-0.6, -0.6, -0.4}
+
+// A low sun over a field of crates stretching to the horizon, shadowed with cascaded shadow maps
+// so the shadows stay sharp near the camera and still reach far away.
+// C shows the cascades in colour, P cycles the PCF radius.
+type CascadedShadows struct {
+	ShaderProgram           utils.Shader
+	VBO, cubeVAO            uint32
+	groundVBO, groundVAO    uint32
+	camera                  utils.Camera
+	diffuseMap, specularMap uint32
+	groundDiffuse           uint32
+	groundSpecular          uint32
+	shaders                 utils.ShaderManager
+	cameraUBO, lightsUBO    *utils.UniformBuffer
+	shadows                 *utils.CascadedShadowMap
+	// model matrix of every crate
+	crates    []mgl32.Mat4
+	debugHeld bool
+	pcfHeld   bool
+}
+
+func (ct *CascadedShadows) InitGLPipeLine() {
+
+	ct.camera = utils.NewCamera(mgl32.Vec3{0.0, 1.0, 8.0}, mgl32.Vec3{0, 1, 0}, utils.YAW, -10)
+
+	ct.shaders.Watch(&ct.ShaderProgram.ID, "./shaders/Lighting/8-CascadedShadowsVert.glsl", "./shaders/Lighting/8-CascadedShadowsFrag.glsl", setMaterialSamplers)
+
+	// the program reads the matrices and the sun from these, see Draw
+	ct.cameraUBO = utils.NewUniformBuffer(utils.CameraBinding)
+	ct.lightsUBO = utils.NewUniformBuffer(utils.LightsBinding)
+
+	var err error
+	ct.shadows, err = utils.NewCascadedShadowMap(2048, outdoorCascades)
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	gl.GenVertexArrays(1, &ct.cubeVAO)
+	gl.GenBuffers(1, &ct.VBO)
+	gl.BindVertexArray(ct.cubeVAO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, ct.VBO)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*len(vertices), gl.Ptr(vertices), gl.STATIC_DRAW)
+	setLitVertexLayout()
+
+	gl.GenVertexArrays(1, &ct.groundVAO)
+	gl.GenBuffers(1, &ct.groundVBO)
+	gl.BindVertexArray(ct.groundVAO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, ct.groundVBO)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*len(groundVertices), gl.Ptr(groundVertices), gl.STATIC_DRAW)
+	setLitVertexLayout()
+
+	ct.groundDiffuse = solidTexture(color.NRGBA{120, 150, 100, 255}, diffuseColorSpace())
+	ct.groundSpecular = solidTexture(color.NRGBA{0, 0, 0, 255}, utils.Linear)
+
+	ct.diffuseMap = utils.NewTexture("./assets/container2.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR, ColorSpace: diffuseColorSpace()})
+	ct.specularMap = utils.NewTexture("./assets/container2_specular.png", utils.TextureOptions{MinFilter: gl.LINEAR, MagFilter: gl.LINEAR})
+	setMaterialSamplers(ct.ShaderProgram.ID)
+
+	// rows of crates of different heights, turned a little each, standing on the ground
+	ct.crates = nil
+	for row := 0; row < 16; row++ {
+		for col := -6; col <= 6; col++ {
+			height := float32(1 + (row*7+col*3+64)%4)
+			x := float32(col*8 + row%2*4)
+			z := float32(2 - row*9)
+			angle := mgl32.DegToRad(float32((row*37 + col*53 + 360) % 90))
+			model := mgl32.Translate3D(x, groundHeight+height/2, z).
+				Mul4(mgl32.HomogRotate3DY(angle)).
+				Mul4(mgl32.Scale3D(1.5, height, 1.5))
+			ct.crates = append(ct.crates, model)
+		}
+	}
+}
+
+// Draw the crates and the ground, setModel uploads the model matrix to the program in use.
+func (ct *CascadedShadows) drawScene(setModel func(model *mgl32.Mat4), textured bool) {
+	if textured {
+		gl.ActiveTexture(gl.TEXTURE0)
+		gl.BindTexture(gl.TEXTURE_2D, ct.diffuseMap)
+		gl.ActiveTexture(gl.TEXTURE1)
+		gl.BindTexture(gl.TEXTURE_2D, ct.specularMap)
+	}
+	gl.BindVertexArray(ct.cubeVAO)
+	for i := range ct.crates {
+		setModel(&ct.crates[i])
+		gl.DrawArrays(gl.TRIANGLES, 0, 36)
+	}
+
+	if textured {
+		gl.ActiveTexture(gl.TEXTURE0)
+		gl.BindTexture(gl.TEXTURE_2D, ct.groundDiffuse)
+		gl.ActiveTexture(gl.TEXTURE1)
+		gl.BindTexture(gl.TEXTURE_2D, ct.groundSpecular)
+	}
+	model := mgl32.Scale3D(outdoorGroundScale, 1, outdoorGroundScale)
+	setModel(&model)
+	gl.BindVertexArray(ct.groundVAO)
+	gl.DrawArrays(gl.TRIANGLES, 0, 6)
+}
+
+func (ct *CascadedShadows) Draw() {
+
+	ct.shaders.Poll()
+
+	// first pass: a depth map from the sun for every cascade of the camera frustum
+	aspect := float32(800.0 / 600.0)
+	if ct.shadows != nil {
+		ct.shadows.Update(&ct.camera, aspect, outdoorNear, outdoorFar, outdoorSun)
+		ct.shadows.Render(func(depth *utils.Shader) {
+			ct.drawScene(func(model *mgl32.Mat4) { depth.SetMat4("model", model) }, false)
+		})
+	}
+
+	// second pass: the scene, shadowed from the cascade each fragment falls into
+	gl.ClearColor(0.6, 0.7, 0.8, 1.0)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+	// camera/view transformation
+	projection := mgl32.Perspective(mgl32.DegToRad(float32(ct.camera.Zoom)), aspect, outdoorNear, outdoorFar)
+	view := ct.camera.GetViewMatrix()
+	ct.cameraUBO.Update(&utils.CameraUniforms{
+		Projection: projection,
+		View:       view,
+		ViewPos:    ct.camera.Position,
+	})
+
+	ct.lightsUBO.Update(&utils.LightsUniforms{
+		DirLight: utils.DirLightUniform{
+			Direction: outdoorSun,
+			Ambient:   mgl32.Vec3{0.25, 0.25, 0.3},
+			Diffuse:   mgl32.Vec3{0.8, 0.8, 0.7},
+			Specular:  mgl32.Vec3{0.5, 0.5, 0.5},
+		},
+		// unused, but the block always has room for one
+		PointLights: make([]utils.PointLightUniform, 1),
+	})
+
+	ct.ShaderProgram.Use()
+	ct.ShaderProgram.SetFloat("material.shininess", 32.0)
+
+	if ct.shadows != nil {
+		ct.shadows.SetUniforms(ct.ShaderProgram.ID, 2)
+	}
+
+	ct.drawScene(func(model *mgl32.Mat4) { ct.ShaderProgram.SetMat4("model", model) }, true)
+}
+
+func (ct *CascadedShadows) KeyboardCallback(window *glfw.Window) {
+
+	currentFrame := glfw.GetTime()
+	deltaTime := currentFrame - lastFrame
+	lastFrame = currentFrame
+
+	if window.GetKey(glfw.KeyEscape) == glfw.Press {
+		window.SetShouldClose(true)
+	}
+	if window.GetKey(glfw.KeyW) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.FORWARD, deltaTime)
+	}
+	if window.GetKey(glfw.KeyS) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.BACKWARD, deltaTime)
+	}
+	if window.GetKey(glfw.KeyA) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.LEFT, deltaTime)
+	}
+	if window.GetKey(glfw.KeyD) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.RIGHT, deltaTime)
+	}
+	if window.GetKey(glfw.KeySpace) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.UP, deltaTime)
+	}
+	if window.GetKey(glfw.KeyLeftControl) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.DOWN, deltaTime)
+	}
+	if ct.shadows == nil {
+		return
+	}
+
+	// toggle once per key press, not every frame it is held
+	debug := window.GetKey(glfw.KeyC) == glfw.Press
+	if debug && !ct.debugHeld {
+		ct.shadows.Debug = !ct.shadows.Debug
+		fmt.Printf("cascade debug view: %v\n", ct.shadows.Debug)
+	}
+	ct.debugHeld = debug
+
+	pcf := window.GetKey(glfw.KeyP) == glfw.Press
+	if pcf && !ct.pcfHeld {
+		ct.shadows.PCFRadius = (ct.shadows.PCFRadius + 1) % 4
+		fmt.Printf("pcf radius: %d\n", ct.shadows.PCFRadius)
+	}
+	ct.pcfHeld = pcf
+}
+
+func (ct *CascadedShadows) MouseCallback(window *glfw.Window, xpos float64, ypos float64) {
+	if firstMouse {
+		firstMouse = false
+		lastxPos = xpos
+		lastyPos = ypos
+	}
+
+	xoffset := xpos - lastxPos
+	yoffset := lastyPos - ypos
+	lastxPos = xpos
+	lastyPos = ypos
+
+	ct.camera.ProcessMouseMovement(xoffset, yoffset, true)
+}
+
+func (ct *CascadedShadows) ScrollCallback(window *glfw.Window, xoff float64, yoff float64) {
+	ct.camera.ProcessMouseScroll(yoff)
+}
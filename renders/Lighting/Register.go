@@ -0,0 +1,16 @@
+package lighting
+
+import "opgl-learn/renders"
+
+func init() {
+	renders.Register("lighting/colors", func() renders.Render { return &Color{} })
+	renders.Register("lighting/basic-lighting", func() renders.Render { return &Phong{} })
+	renders.Register("lighting/materials", func() renders.Render { return &Materials{} })
+	renders.Register("lighting/lighting-maps", func() renders.Render { return &LightingMaps{} })
+	renders.Register("lighting/directional-light", func() renders.Render { return &DirectionalLight{} })
+	renders.Register("lighting/point-light", func() renders.Render { return &PointLight{} })
+	renders.Register("lighting/spotlight", func() renders.Render { return &Spotlight{} })
+	renders.Register("lighting/multiple-lights", func() renders.Render { return &MultipleLights{} })
+	renders.Register("lighting/shadow-mapping", func() renders.Render { return &ShadowMapping{} })
+	renders.Register("lighting/cascaded-shadows", func() renders.Render { return &CascadedShadows{} })
+}
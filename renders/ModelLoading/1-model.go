@@ -1,6 +1,8 @@
 package ModelLoading
 
 import (
+	"fmt"
+	"opgl-learn/renders"
 	"opgl-learn/utils"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
@@ -11,7 +13,8 @@ import (
 type ModelLoad struct {
 	ShaderProgram uint32
 	camera        utils.Camera
-	model         utils.Model
+	model         *utils.Model
+	loader        *utils.Loader
 }
 
 var lastxPos float64 = 1920 / 2.0
@@ -24,13 +27,25 @@ func (ct *ModelLoad) InitGLPipeLine() {
 	ct.camera = utils.NewCamera(mgl32.Vec3{0.0, 0.0, 3.0}, mgl32.Vec3{0, 1, 0}, utils.YAW, utils.PITCH)
 
 	ct.ShaderProgram = utils.NewShader("./shaders/ModelLoading/1-ModelVert.glsl", "./shaders/ModelLoading/1-ModelFrag.glsl")
-	ct.model = utils.NewModel("./backpack/backpack.obj")
+	// the backpack textures are big, load them in the background and draw what's there meanwhile
+	ct.loader = utils.NewLoader(0)
+	ct.model = ct.loader.LoadModel("./backpack/backpack.obj", renders.LinearLighting())
+	ct.loader.OnProgress = func(done, total int) {
+		if done == total && ct.model.Err != nil {
+			fmt.Println(ct.model.Err)
+		}
+	}
+	if renders.Headless {
+		ct.loader.Wait()
+	}
 
 	gl.UseProgram(ct.ShaderProgram)
 }
 
 func (ct *ModelLoad) Draw() {
 
+	ct.loader.Process()
+
 	gl.ClearColor(0.05, 0.05, 0.05, 1.0)
 	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 
@@ -0,0 +1,7 @@
+package ModelLoading
+
+import "opgl-learn/renders"
+
+func init() {
+	renders.Register("model-loading/model", func() renders.Render { return &ModelLoad{} })
+}
@@ -0,0 +1,293 @@
+package pbr
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"opgl-learn/renders"
+	"opgl-learn/utils"
+	"strconv"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+var lightPositions = []mgl32.Vec3{
+	{-10.0, 10.0, 10.0},
+	{10.0, 10.0, 10.0},
+	{-10.0, -10.0, 10.0},
+	{10.0, -10.0, 10.0},
+}
+
+// bright enough to light the spheres from 10 units away, the falloff is physically correct
+var lightColor = mgl32.Vec3{300.0, 300.0, 300.0}
+
+// The grid of spheres, rows get more metallic from bottom to top and columns rougher from left to right.
+const (
+	gridRows, gridColumns = 7, 7
+	gridSpacing           = 2.5
+)
+
+var lastxPos float64 = 1920 / 2.0
+var lastyPos float64 = 1080 / 2.0
+var firstMouse bool = true
+var lastFrame float64 = 0.0
+
+// Spheres of every metallic and roughness combination lit by four point lights, with the Cook-Torrance BRDF.
+// T swaps them for three tiled spheres adding the material maps one after another: albedo,
+// then metallic and roughness, then ambient occlusion and normals.
+//
+// The environment drawn around them lights them as well, I switches back to a constant ambient light.
+type Lighting struct {
+	ShaderProgram uint32
+	camera        utils.Camera
+	shaders       utils.ShaderManager
+	cameraUBO     *utils.UniformBuffer
+	sphere        utils.Mesh
+	// every map of the tiles, albedo, metallic, roughness, ao and normal
+	tiles        []utils.Texture
+	textured     bool
+	texturedHeld bool
+	// nil when the environment failed to load
+	ibl     *utils.IBL
+	skybox  *utils.Skybox
+	useIBL  bool
+	iblHeld bool
+}
+
+func (ct *Lighting) InitGLPipeLine() {
+
+	ct.camera = utils.NewCamera(mgl32.Vec3{0.0, 0.0, 21.0}, mgl32.Vec3{0, 1, 0}, utils.YAW, utils.PITCH)
+
+	// the lighting is linear, without HDR or an sRGB framebuffer the shader has to display it itself
+	defines := utils.Defines{"NR_LIGHTS": strconv.Itoa(len(lightPositions))}
+	if !renders.HDR {
+		defines["TONE_MAP"] = "1"
+	}
+	if !renders.LinearLighting() {
+		defines["GAMMA_CORRECT"] = "1"
+	}
+	ct.shaders.WatchWithDefines(&ct.ShaderProgram, "./shaders/PBR/1-LightingVert.glsl", "./shaders/PBR/1-LightingFrag.glsl", defines, nil)
+	ct.cameraUBO = utils.NewUniformBuffer(utils.CameraBinding)
+
+	ct.sphere = utils.NewSphere(64, 32)
+	ct.tiles = tileMaps()
+
+	ibl, skybox, err := loadEnvironment()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	ct.ibl, ct.skybox, ct.useIBL = ibl, skybox, true
+}
+
+// The image based lighting of renders.EnvironmentPath and a skybox showing the environment.
+func loadEnvironment() (*utils.IBL, *utils.Skybox, error) {
+	ibl, err := renders.LoadEnvironment()
+	if err != nil {
+		return nil, nil, err
+	}
+	skybox, err := utils.NewSkybox(ibl.Environment)
+	if err != nil {
+		ibl.Delete()
+		return nil, nil, err
+	}
+	return ibl, skybox, nil
+}
+
+// Procedural maps of copper and slate tiles in grout, 8 around the sphere and 4 from pole to pole.
+func tileMaps() []utils.Texture {
+	const tileSize = 64
+	const grout, bevel = 0.06, 0.1
+	bounds := image.Rect(0, 0, 8*tileSize, 4*tileSize)
+	albedo, normal := image.NewNRGBA(bounds), image.NewNRGBA(bounds)
+	metallic, roughness, ao := image.NewGray(bounds), image.NewGray(bounds), image.NewGray(bounds)
+
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			// where in its tile the texel is, and how far from the nearest edge
+			fx := (float64(x%tileSize) + 0.5) / tileSize
+			fy := (float64(y%tileSize) + 0.5) / tileSize
+			edge := min(fx, 1-fx, fy, 1-fy)
+
+			if edge < grout {
+				albedo.SetNRGBA(x, y, color.NRGBA{90, 85, 80, 255})
+				metallic.SetGray(x, y, color.Gray{0})
+				roughness.SetGray(x, y, color.Gray{255})
+				ao.SetGray(x, y, color.Gray{90})
+				normal.SetNRGBA(x, y, color.NRGBA{128, 128, 255, 255})
+				continue
+			}
+
+			if (x/tileSize+y/tileSize)%2 == 0 {
+				albedo.SetNRGBA(x, y, color.NRGBA{250, 190, 160, 255})
+				metallic.SetGray(x, y, color.Gray{255})
+				roughness.SetGray(x, y, color.Gray{80})
+			} else {
+				albedo.SetNRGBA(x, y, color.NRGBA{70, 80, 95, 255})
+				metallic.SetGray(x, y, color.Gray{0})
+				roughness.SetGray(x, y, color.Gray{170})
+			}
+
+			// The tiles are raised, their bevelled edges slope down towards the grout which shadows them a little.
+			// Image rows run down while the texture's v runs up, so the top edge slopes towards +v.
+			n := mgl32.Vec3{0, 0, 1}
+			if edge < grout+bevel {
+				switch edge {
+				case fx:
+					n = mgl32.Vec3{-0.7, 0, 1}
+				case 1 - fx:
+					n = mgl32.Vec3{0.7, 0, 1}
+				case fy:
+					n = mgl32.Vec3{0, 0.7, 1}
+				default:
+					n = mgl32.Vec3{0, -0.7, 1}
+				}
+			}
+			n = n.Normalize()
+			normal.SetNRGBA(x, y, color.NRGBA{uint8(127.5 + 127.5*n.X()), uint8(127.5 + 127.5*n.Y()), uint8(127.5 + 127.5*n.Z()), 255})
+			occlusion := 0.6 + 0.4*min((edge-grout)/bevel, 1)
+			ao.SetGray(x, y, color.Gray{uint8(math.Round(255 * occlusion))})
+		}
+	}
+
+	// the albedo holds colours, the other maps data
+	return []utils.Texture{
+		utils.NewMeshTexture(utils.NewTextureFromImage(albedo, utils.TextureOptions{ColorSpace: utils.SRGB}), "texture_albedo"),
+		utils.NewMeshTexture(utils.NewTextureFromImage(metallic, utils.TextureOptions{}), "texture_metallic"),
+		utils.NewMeshTexture(utils.NewTextureFromImage(roughness, utils.TextureOptions{}), "texture_roughness"),
+		utils.NewMeshTexture(utils.NewTextureFromImage(ao, utils.TextureOptions{}), "texture_ao"),
+		utils.NewMeshTexture(utils.NewTextureFromImage(normal, utils.TextureOptions{}), "texture_normal"),
+	}
+}
+
+func (ct *Lighting) Draw() {
+
+	ct.shaders.Poll()
+
+	gl.ClearColor(0.1, 0.1, 0.1, 1.0)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+	projection := mgl32.Perspective(mgl32.DegToRad(float32(ct.camera.Zoom)), 800.0/600.0, 0.1, 100)
+	ct.cameraUBO.Update(&utils.CameraUniforms{
+		Projection: projection,
+		View:       ct.camera.GetViewMatrix(),
+		ViewPos:    ct.camera.Position,
+	})
+
+	gl.UseProgram(ct.ShaderProgram)
+	for i := range lightPositions {
+		utils.SetVec3(ct.ShaderProgram, fmt.Sprintf("lightPositions[%d]", i), &lightPositions[i])
+		utils.SetVec3(ct.ShaderProgram, fmt.Sprintf("lightColors[%d]", i), &lightColor)
+	}
+	utils.SetVec3(ct.ShaderProgram, "ambient", &mgl32.Vec3{0.03, 0.03, 0.03})
+	// the maps of the spheres take the texture units from 0 up, the IBL maps go after them
+	if ct.useIBL {
+		ct.ibl.SetUniforms(ct.ShaderProgram, uint32(len(ct.tiles)))
+	} else {
+		utils.DisableIBL(ct.ShaderProgram, uint32(len(ct.tiles)))
+	}
+
+	if ct.textured {
+		ct.drawTiledSpheres()
+	} else {
+		ct.drawGrid()
+	}
+
+	if ct.useIBL {
+		ct.skybox.Draw(ct.camera.GetViewMatrix(), projection)
+	}
+}
+
+// The 7x7 grid of red spheres.
+func (ct *Lighting) drawGrid() {
+	material := utils.PBRMaterial{Albedo: mgl32.Vec3{0.5, 0.0, 0.0}, AO: 1.0}
+	for row := 0; row < gridRows; row++ {
+		material.Metallic = float32(row) / gridRows
+		for col := 0; col < gridColumns; col++ {
+			// perfectly smooth spheres lose their highlight under point lights, keep a bit of roughness
+			material.Roughness = mgl32.Clamp(float32(col)/gridColumns, 0.05, 1.0)
+			material.Apply(ct.ShaderProgram, nil)
+
+			model := mgl32.Translate3D(float32(col-gridColumns/2)*gridSpacing, float32(row-gridRows/2)*gridSpacing, 0)
+			utils.SetMat4(ct.ShaderProgram, "model", &model)
+			ct.sphere.Draw(ct.ShaderProgram)
+		}
+	}
+}
+
+// Three big spheres, each with more of the tile maps than the one left of it.
+func (ct *Lighting) drawTiledSpheres() {
+	for i, maps := range [][]utils.Texture{ct.tiles[:1], ct.tiles[:3], ct.tiles} {
+		ct.sphere.Textures = maps
+		utils.DefaultPBRMaterial.Apply(ct.ShaderProgram, maps)
+
+		model := mgl32.Translate3D(float32(i-1)*7, 0, 0).Mul4(mgl32.HomogRotate3DX(mgl32.DegToRad(20))).Mul4(mgl32.Scale3D(3, 3, 3))
+		utils.SetMat4(ct.ShaderProgram, "model", &model)
+		ct.sphere.Draw(ct.ShaderProgram)
+	}
+	ct.sphere.Textures = nil
+}
+
+func (ct *Lighting) KeyboardCallback(window *glfw.Window) {
+
+	currentFrame := glfw.GetTime()
+	deltaTime := currentFrame - lastFrame
+	lastFrame = currentFrame
+
+	if window.GetKey(glfw.KeyEscape) == glfw.Press {
+		window.SetShouldClose(true)
+	}
+	if window.GetKey(glfw.KeyW) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.FORWARD, deltaTime)
+	}
+	if window.GetKey(glfw.KeyS) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.BACKWARD, deltaTime)
+	}
+	if window.GetKey(glfw.KeyA) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.LEFT, deltaTime)
+	}
+	if window.GetKey(glfw.KeyD) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.RIGHT, deltaTime)
+	}
+	if window.GetKey(glfw.KeySpace) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.UP, deltaTime)
+	}
+	if window.GetKey(glfw.KeyLeftControl) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.DOWN, deltaTime)
+	}
+
+	// toggle once per key press, not every frame it is held
+	textured := window.GetKey(glfw.KeyT) == glfw.Press
+	if textured && !ct.texturedHeld {
+		ct.textured = !ct.textured
+	}
+	ct.texturedHeld = textured
+
+	useIBL := window.GetKey(glfw.KeyI) == glfw.Press
+	if useIBL && !ct.iblHeld && ct.ibl != nil {
+		ct.useIBL = !ct.useIBL
+	}
+	ct.iblHeld = useIBL
+}
+
+func (ct *Lighting) MouseCallback(window *glfw.Window, xpos float64, ypos float64) {
+	if firstMouse {
+		firstMouse = false
+		lastxPos = xpos
+		lastyPos = ypos
+	}
+
+	xoffset := xpos - lastxPos
+	yoffset := lastyPos - ypos
+	lastxPos = xpos
+	lastyPos = ypos
+
+	ct.camera.ProcessMouseMovement(xoffset, yoffset, true)
+}
+
+func (ct *Lighting) ScrollCallback(window *glfw.Window, xoff float64, yoff float64) {
+	ct.camera.ProcessMouseScroll(yoff)
+}
@@ -0,0 +1,138 @@
+package pbr
+
+import (
+	"fmt"
+	"opgl-learn/renders"
+	"opgl-learn/utils"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Texture unit of the first IBL map, after any the model's meshes can have.
+const modelIBLUnit = 10
+
+// The backpack of the model loading render with the PBR shaders, lit only by the environment around it.
+type Model struct {
+	ShaderProgram uint32
+	camera        utils.Camera
+	shaders       utils.ShaderManager
+	cameraUBO     *utils.UniformBuffer
+	model         *utils.Model
+	loader        *utils.Loader
+	// nil when the environment failed to load, the model is lit by a constant ambient light then
+	ibl    *utils.IBL
+	skybox *utils.Skybox
+}
+
+func (ct *Model) InitGLPipeLine() {
+
+	ct.camera = utils.NewCamera(mgl32.Vec3{0.0, 0.0, 3.0}, mgl32.Vec3{0, 1, 0}, utils.YAW, utils.PITCH)
+
+	// no point lights, the lighting is linear and displayed by the shader without HDR or an sRGB framebuffer
+	defines := utils.Defines{"NR_LIGHTS": "0"}
+	if !renders.HDR {
+		defines["TONE_MAP"] = "1"
+	}
+	if !renders.LinearLighting() {
+		defines["GAMMA_CORRECT"] = "1"
+	}
+	ct.shaders.WatchWithDefines(&ct.ShaderProgram, "./shaders/PBR/1-LightingVert.glsl", "./shaders/PBR/1-LightingFrag.glsl", defines, nil)
+	ct.cameraUBO = utils.NewUniformBuffer(utils.CameraBinding)
+
+	ct.loader = utils.NewLoader(0)
+	ct.model = ct.loader.LoadPBRModel("./backpack/backpack.obj", utils.DefaultPBRMaterial)
+	ct.loader.OnProgress = func(done, total int) {
+		if done == total && ct.model.Err != nil {
+			fmt.Println(ct.model.Err)
+		}
+	}
+	if renders.Headless {
+		ct.loader.Wait()
+	}
+
+	ibl, skybox, err := loadEnvironment()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	ct.ibl, ct.skybox = ibl, skybox
+}
+
+func (ct *Model) Draw() {
+
+	ct.shaders.Poll()
+	ct.loader.Process()
+
+	gl.ClearColor(0.05, 0.05, 0.05, 1.0)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+	projection := mgl32.Perspective(mgl32.DegToRad(float32(ct.camera.Zoom)), 800.0/600.0, 0.1, 100)
+	view := ct.camera.GetViewMatrix()
+	ct.cameraUBO.Update(&utils.CameraUniforms{Projection: projection, View: view, ViewPos: ct.camera.Position})
+
+	gl.UseProgram(ct.ShaderProgram)
+	if ct.ibl != nil {
+		ct.ibl.SetUniforms(ct.ShaderProgram, modelIBLUnit)
+	} else {
+		utils.DisableIBL(ct.ShaderProgram, modelIBLUnit)
+	}
+	utils.SetVec3(ct.ShaderProgram, "ambient", &mgl32.Vec3{0.3, 0.3, 0.3})
+
+	model := mgl32.Ident4()
+	utils.SetMat4(ct.ShaderProgram, "model", &model)
+	ct.model.Draw(ct.ShaderProgram)
+
+	if ct.skybox != nil {
+		ct.skybox.Draw(view, projection)
+	}
+}
+
+func (ct *Model) KeyboardCallback(window *glfw.Window) {
+
+	currentFrame := glfw.GetTime()
+	deltaTime := currentFrame - lastFrame
+	lastFrame = currentFrame
+
+	if window.GetKey(glfw.KeyEscape) == glfw.Press {
+		window.SetShouldClose(true)
+	}
+	if window.GetKey(glfw.KeyW) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.FORWARD, deltaTime)
+	}
+	if window.GetKey(glfw.KeyS) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.BACKWARD, deltaTime)
+	}
+	if window.GetKey(glfw.KeyA) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.LEFT, deltaTime)
+	}
+	if window.GetKey(glfw.KeyD) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.RIGHT, deltaTime)
+	}
+	if window.GetKey(glfw.KeySpace) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.UP, deltaTime)
+	}
+	if window.GetKey(glfw.KeyLeftControl) == glfw.Press {
+		ct.camera.ProcessKeyboard(utils.DOWN, deltaTime)
+	}
+}
+
+func (ct *Model) MouseCallback(window *glfw.Window, xpos float64, ypos float64) {
+	if firstMouse {
+		firstMouse = false
+		lastxPos = xpos
+		lastyPos = ypos
+	}
+
+	xoffset := xpos - lastxPos
+	yoffset := lastyPos - ypos
+	lastxPos = xpos
+	lastyPos = ypos
+
+	ct.camera.ProcessMouseMovement(xoffset, yoffset, true)
+}
+
+func (ct *Model) ScrollCallback(window *glfw.Window, xoff float64, yoff float64) {
+	ct.camera.ProcessMouseScroll(yoff)
+}
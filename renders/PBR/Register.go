@@ -0,0 +1,8 @@
+package pbr
+
+import "opgl-learn/renders"
+
+func init() {
+	renders.Register("pbr/lighting", func() renders.Render { return &Lighting{} })
+	renders.Register("pbr/model", func() renders.Render { return &Model{} })
+}
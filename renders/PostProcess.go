@@ -0,0 +1,142 @@
+package renders
+
+import (
+	"fmt"
+	"opgl-learn/utils"
+	"slices"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// Keys toggling the effects of a PostProcessed render, in the order of utils.DefaultPostEffects.
+var postEffectKeys = []glfw.Key{glfw.KeyF1, glfw.KeyF2, glfw.KeyF3, glfw.KeyF4, glfw.KeyF5, glfw.KeyF6, glfw.KeyF7, glfw.KeyF8}
+
+// Runs any render through the built-in post-processing effects. F1 to F8 toggle them one by one,
+// with everything off the render draws straight to the screen as if it wasn't wrapped.
+//
+// B toggles bloom, which makes whatever is brighter than white glow.
+//
+// With HDR set the scene is tone mapped and gamma corrected as well. F9 cycles through the tone map
+// operators, F10 and F11 lower and raise the exposure and F12 toggles automatic exposure.
+type PostProcessed struct {
+	Render
+	Post  *utils.PostProcessor
+	Bloom *utils.Bloom
+	// Only used with HDR, nil otherwise.
+	ToneMapping *utils.ToneMapping
+	// Tone map operator and exposure mode to start with.
+	Operator     utils.ToneMapOperator
+	AutoExposure bool
+
+	// effects to enable once the pipeline is up
+	enable []string
+	// the effects toggled by postEffectKeys
+	effects []*utils.PostEffect
+	held    map[glfw.Key]bool
+}
+
+// Wrap render, the effects called enable start switched on.
+func WithPostProcessing(render Render, enable ...string) *PostProcessed {
+	return &PostProcessed{Render: render, Operator: utils.ACESFilmic, enable: enable, held: map[glfw.Key]bool{}}
+}
+
+func (pp *PostProcessed) InitGLPipeLine() {
+	pp.Render.InitGLPipeLine()
+
+	var err error
+	pp.effects, err = utils.DefaultPostEffects()
+	if err != nil {
+		fmt.Println("post-processing disabled:", err)
+		return
+	}
+	pp.Bloom, err = utils.NewBloom()
+	if err != nil {
+		fmt.Println("post-processing disabled:", err)
+		return
+	}
+	// bloom works on the colours as drawn, before tone mapping squeezes them into [0, 1]
+	chain := slices.Concat([]*utils.PostEffect{pp.Bloom.Effect}, pp.effects)
+	if HDR {
+		pp.ToneMapping, err = utils.NewToneMapping(func() float64 { return GetTime() })
+		if err != nil {
+			fmt.Println("post-processing disabled:", err)
+			return
+		}
+		pp.ToneMapping.Operator = pp.Operator
+		pp.ToneMapping.AutoExposure = pp.AutoExposure
+		// an sRGB framebuffer encodes the output by itself
+		pp.ToneMapping.GammaEffect.Enabled = !SRGBFramebuffer
+		chain = slices.Concat(chain[:1], []*utils.PostEffect{pp.ToneMapping.ToneMapEffect}, chain[1:], []*utils.PostEffect{pp.ToneMapping.GammaEffect})
+	}
+	pp.Post, err = utils.NewPostProcessor(chain)
+	if err != nil {
+		fmt.Println("post-processing disabled:", err)
+		return
+	}
+
+	for _, name := range pp.enable {
+		if effect := pp.Post.Effect(name); effect != nil {
+			effect.Enabled = true
+		} else {
+			fmt.Printf("unknown post effect %q\n", name)
+		}
+	}
+}
+
+func (pp *PostProcessed) Draw() {
+	if pp.Post == nil {
+		pp.Render.Draw()
+		return
+	}
+	pp.Post.Begin()
+	pp.Render.Draw()
+	pp.Post.End()
+}
+
+func (pp *PostProcessed) KeyboardCallback(window *glfw.Window) {
+	pp.Render.KeyboardCallback(window)
+	if pp.Post == nil {
+		return
+	}
+
+	for i, key := range postEffectKeys {
+		if pp.pressed(window, key) && i < len(pp.effects) {
+			effect := pp.effects[i]
+			effect.Enabled = !effect.Enabled
+			fmt.Printf("%s: %v\n", effect.Name, effect.Enabled)
+		}
+	}
+
+	if pp.pressed(window, glfw.KeyB) {
+		pp.Bloom.Effect.Enabled = !pp.Bloom.Effect.Enabled
+		fmt.Println("bloom:", pp.Bloom.Effect.Enabled)
+	}
+
+	tm := pp.ToneMapping
+	if tm == nil {
+		return
+	}
+	if pp.pressed(window, glfw.KeyF9) {
+		tm.Operator = tm.Operator.Next()
+		fmt.Println("tone mapping:", tm.Operator)
+	}
+	if window.GetKey(glfw.KeyF10) == glfw.Press {
+		tm.Exposure /= 1.02
+	}
+	if window.GetKey(glfw.KeyF11) == glfw.Press {
+		tm.Exposure *= 1.02
+	}
+	if pp.pressed(window, glfw.KeyF12) {
+		tm.AutoExposure = !tm.AutoExposure
+		fmt.Println("auto exposure:", tm.AutoExposure)
+	}
+}
+
+// Whether key went down since the last frame, so toggles flip once per key press
+// and not every frame the key is held.
+func (pp *PostProcessed) pressed(window *glfw.Window, key glfw.Key) bool {
+	down := window.GetKey(key) == glfw.Press
+	wasDown := pp.held[key]
+	pp.held[key] = down
+	return down && !wasDown
+}
@@ -0,0 +1,39 @@
+package renders
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Creates a fresh, uninitialised render. InitGLPipeLine is called by whoever runs it.
+type Factory func() Render
+
+var registry = map[string]Factory{}
+
+// Register a render under a stable name like "lighting/multiple-lights".
+// Meant to be called from the init() of each render package, panics on duplicate names.
+func Register(name string, factory Factory) {
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("render %q registered twice", name))
+	}
+	registry[name] = factory
+}
+
+// Create a new instance of the render registered under name.
+func New(name string) (Render, error) {
+	factory, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown render %q, use -list to see the available renders", name)
+	}
+	return factory(), nil
+}
+
+// Names of every registered render, sorted.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -20,6 +20,19 @@ type Render interface {
 	ScrollCallback(window *glfw.Window, xoff float64, yoff float64)
 }
 
+// Embed BaseRender in renders that have no camera, it provides default input callbacks
+// so only Draw and InitGLPipeLine need to be written.
 type BaseRender struct {
 	Render
 }
+
+// Default keyboard handling, escape closes the window.
+func (br *BaseRender) KeyboardCallback(window *glfw.Window) {
+	if window.GetKey(glfw.KeyEscape) == glfw.Press {
+		window.SetShouldClose(true)
+	}
+}
+
+func (br *BaseRender) MouseCallback(window *glfw.Window, xpos float64, ypos float64) {}
+
+func (br *BaseRender) ScrollCallback(window *glfw.Window, xoff float64, yoff float64) {}
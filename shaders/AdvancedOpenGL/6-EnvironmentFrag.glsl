@@ -0,0 +1,54 @@
+#version 330 core
+out vec4 FragColor;
+
+in vec3 Normal;
+in vec3 Position;
+in vec2 TexCoords;
+
+// see utils.EnvironmentMaterial
+#define MODE_DIFFUSE 0
+#define MODE_REFLECT 1
+#define MODE_REFRACT 2
+
+struct Environment {
+    int mode;
+    float ior;
+    float fresnel;
+};
+
+uniform Environment environment;
+uniform samplerCube environmentMap;
+// the samplers utils.Mesh.Draw sets, material.<type><n>
+struct Material {
+    sampler2D texture_diffuse1;
+};
+uniform Material material;
+uniform vec3 cameraPos;
+
+// Schlick's approximation, the share of light reflected at the surface going from air into the material
+float FresnelSchlick(float cosTheta, float ior)
+{
+    float f0 = pow((1.0 - ior) / (1.0 + ior), 2.0);
+    return f0 + (1.0 - f0) * pow(1.0 - cosTheta, 5.0);
+}
+
+void main()
+{
+    if (environment.mode == MODE_DIFFUSE) {
+        FragColor = texture(material.texture_diffuse1, TexCoords);
+        return;
+    }
+
+    vec3 I = normalize(Position - cameraPos);
+    vec3 N = normalize(Normal);
+    vec3 reflection = texture(environmentMap, reflect(I, N)).rgb;
+    if (environment.mode == MODE_REFLECT) {
+        FragColor = vec4(reflection, 1.0);
+        return;
+    }
+
+    // from air (1.00) into the material
+    vec3 refraction = texture(environmentMap, refract(I, N, 1.00 / environment.ior)).rgb;
+    float fresnel = FresnelSchlick(max(dot(-I, N), 0.0), environment.ior);
+    FragColor = vec4(mix(refraction, reflection, fresnel * environment.fresnel), 1.0);
+}
@@ -1,7 +1,10 @@
 #version 330 core
 out vec4 FragColor;
 
+// raised above 1 when drawing in HDR, the lamp is brighter than anything it lights and blooms
+uniform float intensity = 1.0;
+
 void main()
 {
-    FragColor = vec4(1.0); // set all 4 vector values to 1.0
-}
\ No newline at end of file
+    FragColor = vec4(vec3(intensity), 1.0);
+}
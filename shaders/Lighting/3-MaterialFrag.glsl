@@ -8,23 +8,19 @@ struct Material {
     float shininess;
 }; 
 
-struct Light {
-    vec3 position;
-
-    vec3 ambient;
-    vec3 diffuse;
-    vec3 specular;
-};
-
 in vec3 FragPos;  
 in vec3 Normal;  
   
-uniform vec3 viewPos;
 uniform Material material;
-uniform Light light;
+
+#include "../common/camera.glsl"
+#include "common/lightsBlock.glsl"
 
 void main()
 {
+    // the only light of the scene, no attenuation yet
+    PointLight light = pointLights[0];
+
     // ambient
     vec3 ambient = light.ambient * material.ambient;
   	
@@ -1,44 +1,18 @@
 #version 330 core
 out vec4 FragColor;
 
-struct Material {
-    sampler2D diffuse;
-    sampler2D specular;
-    float shininess;
-}; 
-
-struct Light {
-    vec3 direction;
-
-    vec3 ambient;
-    vec3 diffuse;
-    vec3 specular;
-};
+#include "common/lights.glsl"
 
 in vec3 FragPos;  
 in vec3 Normal;  
-in vec2 TexCoords;
   
-uniform vec3 viewPos;
-uniform Material material;
-uniform Light light;
+#include "../common/camera.glsl"
+#include "common/lightsBlock.glsl"
 
 void main()
 {
-    // ambient
-    vec3 ambient  = light.ambient  * vec3(texture(material.diffuse, TexCoords));
-  	
-    // diffuse 
     vec3 norm = normalize(Normal);
-    vec3 lightDir = normalize(-light.direction);
-    float diff = max(dot(norm, lightDir), 0.0);
-    vec3 diffuse  = light.diffuse  * diff * vec3(texture(material.diffuse, TexCoords));  
-    
-    // specular
     vec3 viewDir = normalize(viewPos - FragPos);
-    vec3 reflectDir = reflect(-lightDir, norm);  
-    float spec = pow(max(dot(viewDir, reflectDir), 0.0), material.shininess);
-    vec3 specular = light.specular * spec * vec3(texture(material.specular, TexCoords));
         
-    FragColor = vec4(ambient + diffuse + specular, 1.0);   
-} 
\ No newline at end of file
+    FragColor = vec4(CalcDirLight(dirLight, norm, viewDir), 1.0);   
+} 
@@ -1,37 +1,18 @@
 #version 330 core
 out vec4 FragColor;
 
-struct Material {
-    sampler2D diffuse;
-    sampler2D specular;    
-    float shininess;
-}; 
-
-struct Light {
-    vec3 position;  
-    vec3 direction;
-    float cutOff;
-    float outerCutOff;
-  
-    vec3 ambient;
-    vec3 diffuse;
-    vec3 specular;
-	
-    float constant;
-    float linear;
-    float quadratic;
-};
+#include "common/lights.glsl"
 
 in vec3 FragPos;  
 in vec3 Normal;  
-in vec2 TexCoords;
   
-uniform vec3 viewPos;
-uniform Material material;
-uniform Light light;
+#include "../common/camera.glsl"
+#include "common/lightsBlock.glsl"
 
 void main()
 {
+    SpotLight light = spotLight;
+
     // ambient
     vec3 ambient = light.ambient * texture(material.diffuse, TexCoords).rgb;
     
@@ -43,20 +24,16 @@ void main()
     
     // specular
     vec3 viewDir = normalize(viewPos - FragPos);
-    vec3 reflectDir = reflect(-lightDir, norm);  
-    float spec = pow(max(dot(viewDir, reflectDir), 0.0), material.shininess);
+    float spec = CalcSpecular(lightDir, norm, viewDir);
     vec3 specular = light.specular * spec * texture(material.specular, TexCoords).rgb;  
     
-    // spotlight (soft edges)
-    float theta = dot(lightDir, normalize(-light.direction)); 
-    float epsilon = (light.cutOff - light.outerCutOff);
-    float intensity = clamp((theta - light.outerCutOff) / epsilon, 0.0, 1.0);
+    // spotlight (soft edges), the ambient part is left alone so the scene isn't black outside the cone
+    float intensity = CalcSpotIntensity(light, lightDir);
     diffuse  *= intensity;
     specular *= intensity;
     
     // attenuation
-    float distance    = length(light.position - FragPos);
-    float attenuation = 1.0 / (light.constant + light.linear * distance + light.quadratic * (distance * distance));    
+    float attenuation = CalcAttenuation(light.position, FragPos, light.constant, light.linear, light.quadratic);
     ambient  *= attenuation; 
     diffuse   *= attenuation;
     specular *= attenuation;   
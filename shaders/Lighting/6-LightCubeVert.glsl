@@ -0,0 +1,11 @@
+#version 330 core
+layout (location = 0) in vec3 aPos;
+
+#include "../common/camera.glsl"
+
+uniform mat4 model;
+
+void main()
+{
+	gl_Position = projection * view * model * vec4(aPos, 1.0);
+}
@@ -0,0 +1,21 @@
+#version 330 core
+out vec4 FragColor;
+
+#include "common/lights.glsl"
+#include "common/shadows.glsl"
+
+in vec3 FragPos;
+in vec3 Normal;
+in vec4 FragPosLightSpace;
+
+#include "../common/camera.glsl"
+#include "common/lightsBlock.glsl"
+
+void main()
+{
+    vec3 norm = normalize(Normal);
+    vec3 viewDir = normalize(viewPos - FragPos);
+    float shadow = CalcShadow(FragPosLightSpace);
+
+    FragColor = vec4(CalcDirLightShadow(dirLight, norm, viewDir, shadow), 1.0);
+}
@@ -0,0 +1,24 @@
+#version 330 core
+out vec4 FragColor;
+
+#include "common/lights.glsl"
+#include "common/cascadedShadows.glsl"
+
+in vec3 FragPos;
+in vec3 Normal;
+in float ViewDepth;
+
+#include "../common/camera.glsl"
+#include "common/lightsBlock.glsl"
+
+void main()
+{
+    vec3 norm = normalize(Normal);
+    vec3 viewDir = normalize(viewPos - FragPos);
+    float shadow = CalcCascadedShadow(FragPos, ViewDepth);
+
+    vec3 result = CalcDirLightShadow(dirLight, norm, viewDir, shadow);
+    if (cascadeDebug)
+        result = CascadeDebugColor(result, ViewDepth);
+    FragColor = vec4(result, 1.0);
+}
@@ -0,0 +1,25 @@
+#version 330 core
+layout (location = 0) in vec3 aPos;
+layout (location = 1) in vec3 aNormal;
+layout (location = 2) in vec2 aTexCoords;
+
+#include "../common/camera.glsl"
+
+out vec3 FragPos;
+out vec3 Normal;
+out vec2 TexCoords;
+out float ViewDepth;
+
+uniform mat4 model;
+
+void main()
+{
+    FragPos = vec3(model * vec4(aPos, 1.0));
+    Normal = mat3(transpose(inverse(model))) * aNormal;
+    TexCoords = aTexCoords;
+    // distance along the view direction picks the cascade
+    vec4 viewSpacePos = view * vec4(FragPos, 1.0);
+    ViewDepth = -viewSpacePos.z;
+
+    gl_Position = projection * viewSpacePos;
+}
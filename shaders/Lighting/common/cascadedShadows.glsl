@@ -0,0 +1,73 @@
+// Shadows from a utils.CascadedShadowMap, pulled in with #include "common/cascadedShadows.glsl".
+
+// utils.MaxCascades
+#define MAX_CASCADES 8
+
+uniform sampler2DArray cascadeShadowMap;
+uniform mat4 cascadeLightSpaces[MAX_CASCADES];
+// where each cascade ends, as a distance along the camera's view direction
+uniform float cascadeSplits[MAX_CASCADES];
+uniform int cascadeCount;
+// fraction of each cascade that fades into the next one
+uniform float cascadeBlend;
+uniform int cascadePCFRadius;
+uniform bool cascadeDebug;
+
+// The cascade covering a fragment viewDepth in front of the camera, cascadeCount past the last one.
+int CascadeIndex(float viewDepth)
+{
+    for (int i = 0; i < cascadeCount; i++) {
+        if (viewDepth < cascadeSplits[i])
+            return i;
+    }
+    return cascadeCount;
+}
+
+// Shadow of the fragment at fragPos in the map of one cascade, PCF filtered like CalcShadow.
+float SampleCascade(int cascade, vec3 fragPos)
+{
+    vec4 fragPosLightSpace = cascadeLightSpaces[cascade] * vec4(fragPos, 1.0);
+    vec3 projCoords = fragPosLightSpace.xyz / fragPosLightSpace.w * 0.5 + 0.5;
+    if (projCoords.z > 1.0)
+        return 0.0;
+
+    vec2 texelSize = 1.0 / vec2(textureSize(cascadeShadowMap, 0).xy);
+    float shadow = 0.0;
+    for (int x = -cascadePCFRadius; x <= cascadePCFRadius; x++) {
+        for (int y = -cascadePCFRadius; y <= cascadePCFRadius; y++) {
+            float closestDepth = texture(cascadeShadowMap, vec3(projCoords.xy + vec2(x, y) * texelSize, cascade)).r;
+            shadow += projCoords.z > closestDepth ? 1.0 : 0.0;
+        }
+    }
+    float samples = float((2 * cascadePCFRadius + 1) * (2 * cascadePCFRadius + 1));
+    return shadow / samples;
+}
+
+// 1 where the light doesn't reach the fragment, 0 where it does. Past the last cascade nothing is in shadow.
+float CalcCascadedShadow(vec3 fragPos, float viewDepth)
+{
+    int cascade = CascadeIndex(viewDepth);
+    if (cascade >= cascadeCount)
+        return 0.0;
+    float shadow = SampleCascade(cascade, fragPos);
+
+    // fade into the next cascade over the far end of this one, the last one fades out
+    float start = cascade == 0 ? 0.0 : cascadeSplits[cascade - 1];
+    float end = cascadeSplits[cascade];
+    float blendStart = end - (end - start) * cascadeBlend;
+    if (viewDepth > blendStart) {
+        float next = cascade + 1 < cascadeCount ? SampleCascade(cascade + 1, fragPos) : 0.0;
+        shadow = mix(shadow, next, (viewDepth - blendStart) / (end - blendStart));
+    }
+    return shadow;
+}
+
+// Tint color with the colour of the cascade the fragment is in, for the debug view.
+vec3 CascadeDebugColor(vec3 color, float viewDepth)
+{
+    const vec3 tints[4] = vec3[](vec3(1.0, 0.3, 0.3), vec3(0.3, 1.0, 0.3), vec3(0.3, 0.3, 1.0), vec3(1.0, 1.0, 0.3));
+    int cascade = CascadeIndex(viewDepth);
+    if (cascade >= cascadeCount)
+        return color;
+    return color * tints[cascade % 4];
+}
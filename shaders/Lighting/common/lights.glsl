@@ -0,0 +1,102 @@
+// Material and lighting functions shared by the lighting shaders, pulled in with #include "common/lights.glsl".
+// Also declares the material uniform and the texture coordinates the lighting functions sample with.
+
+#include "lightTypes.glsl"
+
+struct Material {
+    sampler2D diffuse;
+    sampler2D specular;
+    float shininess;
+};
+
+in vec2 TexCoords;
+
+uniform Material material;
+
+// how much light is left after travelling from lightPos to fragPos
+float CalcAttenuation(vec3 lightPos, vec3 fragPos, float constant, float linear, float quadratic)
+{
+    float distance = length(lightPos - fragPos);
+    return 1.0 / (constant + linear * distance + quadratic * (distance * distance));
+}
+
+// 1 inside the inner cone, 0 outside the outer cone and a smooth falloff in between
+float CalcSpotIntensity(SpotLight light, vec3 lightDir)
+{
+    float theta = dot(lightDir, normalize(-light.direction));
+    float epsilon = light.cutOff - light.outerCutOff;
+    return clamp((theta - light.outerCutOff) / epsilon, 0.0, 1.0);
+}
+
+// phong specular factor for the light coming from lightDir
+float CalcSpecular(vec3 lightDir, vec3 normal, vec3 viewDir)
+{
+    vec3 reflectDir = reflect(-lightDir, normal);
+    return pow(max(dot(viewDir, reflectDir), 0.0), material.shininess);
+}
+
+// calculates the color when using a directional light, only the ambient light reaches the parts in shadow.
+vec3 CalcDirLightShadow(DirLight light, vec3 normal, vec3 viewDir, float shadow)
+{
+    vec3 lightDir = normalize(-light.direction);
+    // diffuse shading
+    float diff = max(dot(normal, lightDir), 0.0);
+    // specular shading
+    float spec = CalcSpecular(lightDir, normal, viewDir);
+    // combine results
+    vec3 ambient = light.ambient * vec3(texture(material.diffuse, TexCoords));
+    vec3 diffuse = light.diffuse * diff * vec3(texture(material.diffuse, TexCoords));
+    vec3 specular = light.specular * spec * vec3(texture(material.specular, TexCoords));
+    return (ambient + (1.0 - shadow) * (diffuse + specular));
+}
+
+// calculates the color when using a directional light.
+vec3 CalcDirLight(DirLight light, vec3 normal, vec3 viewDir)
+{
+    return CalcDirLightShadow(light, normal, viewDir, 0.0);
+}
+
+// calculates the color when using a point light, only the ambient light reaches the parts in shadow.
+vec3 CalcPointLightShadow(PointLight light, vec3 normal, vec3 fragPos, vec3 viewDir, float shadow)
+{
+    vec3 lightDir = normalize(light.position - fragPos);
+    // diffuse shading
+    float diff = max(dot(normal, lightDir), 0.0);
+    // specular shading
+    float spec = CalcSpecular(lightDir, normal, viewDir);
+    // attenuation
+    float attenuation = CalcAttenuation(light.position, fragPos, light.constant, light.linear, light.quadratic);
+    // combine results
+    vec3 ambient = light.ambient * vec3(texture(material.diffuse, TexCoords));
+    vec3 diffuse = light.diffuse * diff * vec3(texture(material.diffuse, TexCoords));
+    vec3 specular = light.specular * spec * vec3(texture(material.specular, TexCoords));
+    return (ambient + (1.0 - shadow) * (diffuse + specular)) * attenuation;
+}
+
+// calculates the color when using a point light.
+vec3 CalcPointLight(PointLight light, vec3 normal, vec3 fragPos, vec3 viewDir)
+{
+    return CalcPointLightShadow(light, normal, fragPos, viewDir, 0.0);
+}
+
+// calculates the color when using a spot light.
+vec3 CalcSpotLight(SpotLight light, vec3 normal, vec3 fragPos, vec3 viewDir)
+{
+    vec3 lightDir = normalize(light.position - fragPos);
+    // diffuse shading
+    float diff = max(dot(normal, lightDir), 0.0);
+    // specular shading
+    float spec = CalcSpecular(lightDir, normal, viewDir);
+    // attenuation
+    float attenuation = CalcAttenuation(light.position, fragPos, light.constant, light.linear, light.quadratic);
+    // spotlight intensity
+    float intensity = CalcSpotIntensity(light, lightDir);
+    // combine results
+    vec3 ambient = light.ambient * vec3(texture(material.diffuse, TexCoords));
+    vec3 diffuse = light.diffuse * diff * vec3(texture(material.diffuse, TexCoords));
+    vec3 specular = light.specular * spec * vec3(texture(material.specular, TexCoords));
+    ambient *= attenuation * intensity;
+    diffuse *= attenuation * intensity;
+    specular *= attenuation * intensity;
+    return (ambient + diffuse + specular);
+}
@@ -0,0 +1,14 @@
+// The lights of the scene, filled from Go with a utils.UniformBuffer bound to utils.LightsBinding.
+
+#include "lightTypes.glsl"
+
+// the number of point lights is injected from Go when there is more than one
+#ifndef NR_POINT_LIGHTS
+#define NR_POINT_LIGHTS 1
+#endif
+
+layout (std140) uniform Lights {
+    DirLight dirLight;
+    PointLight pointLights[NR_POINT_LIGHTS];
+    SpotLight spotLight;
+};
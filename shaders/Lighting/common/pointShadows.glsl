@@ -0,0 +1,65 @@
+// Shadows of point lights from utils.PointShadowMap, pulled in with #include "common/pointShadows.glsl".
+// The first NR_SHADOW_CASTERS point lights cast shadows, at most 4.
+
+#ifndef NR_SHADOW_CASTERS
+#define NR_SHADOW_CASTERS 0
+#endif
+
+struct PointShadow {
+    samplerCube depthMap;
+    float farPlane;
+    float bias;
+    float softness;
+};
+
+#if NR_SHADOW_CASTERS > 0
+uniform PointShadow pointShadows[NR_SHADOW_CASTERS];
+#endif
+
+// directions spread around the lookup, the soft lookups average the map along them
+const vec3 pointShadowDisk[20] = vec3[](
+    vec3(1, 1, 1), vec3(1, -1, 1), vec3(-1, -1, 1), vec3(-1, 1, 1),
+    vec3(1, 1, -1), vec3(1, -1, -1), vec3(-1, -1, -1), vec3(-1, 1, -1),
+    vec3(1, 1, 0), vec3(1, -1, 0), vec3(-1, -1, 0), vec3(-1, 1, 0),
+    vec3(1, 0, 1), vec3(-1, 0, 1), vec3(1, 0, -1), vec3(-1, 0, -1),
+    vec3(0, 1, 1), vec3(0, -1, 1), vec3(0, -1, -1), vec3(0, 1, -1)
+);
+
+// 1 where the light at lightPos doesn't reach fragPos, 0 where it does and in between along soft edges
+float SamplePointShadow(PointShadow shadow, vec3 lightPos, vec3 fragPos, vec3 viewPos)
+{
+    vec3 fragToLight = fragPos - lightPos;
+    float currentDepth = length(fragToLight);
+    // the map stops at the far plane, nothing beyond it is shadowed
+    if (currentDepth > shadow.farPlane)
+        return 0.0;
+
+    // wider disk further away from the camera, where a texel covers more of the screen anyway
+    float viewDistance = length(viewPos - fragPos);
+    float diskRadius = shadow.softness * (1.0 + viewDistance / shadow.farPlane);
+    float occluded = 0.0;
+    for (int i = 0; i < 20; i++) {
+        float closestDepth = texture(shadow.depthMap, fragToLight + pointShadowDisk[i] * diskRadius).r * shadow.farPlane;
+        occluded += currentDepth - shadow.bias > closestDepth ? 1.0 : 0.0;
+    }
+    return occluded / 20.0;
+}
+
+// Shadow of point light i, 0 for the lights from NR_SHADOW_CASTERS on.
+float CalcPointShadow(int i, vec3 lightPos, vec3 fragPos, vec3 viewPos)
+{
+    // GLSL 330 only indexes arrays of samplers with constants, every caster gets its own branch
+#if NR_SHADOW_CASTERS > 0
+    if (i == 0) return SamplePointShadow(pointShadows[0], lightPos, fragPos, viewPos);
+#endif
+#if NR_SHADOW_CASTERS > 1
+    if (i == 1) return SamplePointShadow(pointShadows[1], lightPos, fragPos, viewPos);
+#endif
+#if NR_SHADOW_CASTERS > 2
+    if (i == 2) return SamplePointShadow(pointShadows[2], lightPos, fragPos, viewPos);
+#endif
+#if NR_SHADOW_CASTERS > 3
+    if (i == 3) return SamplePointShadow(pointShadows[3], lightPos, fragPos, viewPos);
+#endif
+    return 0.0;
+}
@@ -0,0 +1,27 @@
+// Shadows from a utils.ShadowMap, pulled in with #include "common/shadows.glsl".
+// The vertex shader passes the fragment position in light space, lightSpaceMatrix * world position.
+
+uniform sampler2D shadowMap;
+// PCF averages a (2 * pcfRadius + 1)^2 block of texels, 0 for hard shadows
+uniform int pcfRadius;
+
+// 1 where the light doesn't reach the fragment, 0 where it does and in between along the filtered edges
+float CalcShadow(vec4 fragPosLightSpace)
+{
+    // perspective divide, then from [-1, 1] to the [0, 1] of texture coordinates and depth
+    vec3 projCoords = fragPosLightSpace.xyz / fragPosLightSpace.w * 0.5 + 0.5;
+    // past the far plane of the light nothing was drawn into the map
+    if (projCoords.z > 1.0)
+        return 0.0;
+
+    vec2 texelSize = 1.0 / vec2(textureSize(shadowMap, 0));
+    float shadow = 0.0;
+    for (int x = -pcfRadius; x <= pcfRadius; x++) {
+        for (int y = -pcfRadius; y <= pcfRadius; y++) {
+            float closestDepth = texture(shadowMap, projCoords.xy + vec2(x, y) * texelSize).r;
+            shadow += projCoords.z > closestDepth ? 1.0 : 0.0;
+        }
+    }
+    float samples = float((2 * pcfRadius + 1) * (2 * pcfRadius + 1));
+    return shadow / samples;
+}
@@ -3,9 +3,13 @@ out vec4 FragColor;
 
 in vec2 TexCoords;
 
-uniform sampler2D texture_diffuse1;
+// the samplers utils.Mesh.Draw sets, material.<type><n>
+struct Material {
+    sampler2D texture_diffuse1;
+};
+uniform Material material;
 
 void main()
 {    
-    FragColor = texture(texture_diffuse1, TexCoords);
-}
\ No newline at end of file
+    FragColor = texture(material.texture_diffuse1, TexCoords);
+}
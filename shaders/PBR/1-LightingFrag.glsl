@@ -0,0 +1,46 @@
+#version 330 core
+out vec4 FragColor;
+
+#include "common/pbr.glsl"
+#include "common/ibl.glsl"
+
+// the number of lights is normally injected from Go
+#ifndef NR_LIGHTS
+#define NR_LIGHTS 4
+#endif
+
+in vec3 FragPos;
+in vec2 TexCoords;
+in mat3 TBN;
+
+#include "../common/camera.glsl"
+
+#if NR_LIGHTS > 0
+uniform vec3 lightPositions[NR_LIGHTS];
+uniform vec3 lightColors[NR_LIGHTS];
+#endif
+// Light from everywhere else. Without image based lighting the same from every direction, scaled by
+// the albedo and occlusion.
+uniform bool useIBL;
+uniform vec3 ambient;
+
+void main()
+{
+    Surface s = SampleMaterial(TexCoords, TBN);
+    vec3 V = normalize(viewPos - FragPos);
+
+    vec3 color = useIBL ? CalcIBL(s, V) : ambient * s.albedo * s.ao;
+#if NR_LIGHTS > 0
+    for (int i = 0; i < NR_LIGHTS; i++)
+        color += CalcPBRPointLight(s, lightPositions[i], lightColors[i], FragPos, V);
+#endif
+
+    // without HDR the shader displays the linear result itself
+#ifdef TONE_MAP
+    color = color / (color + vec3(1.0));
+#endif
+#ifdef GAMMA_CORRECT
+    color = pow(color, vec3(1.0 / 2.2));
+#endif
+    FragColor = vec4(color, 1.0);
+}
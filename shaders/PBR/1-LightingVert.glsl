@@ -0,0 +1,34 @@
+#version 330 core
+layout (location = 0) in vec3 aPos;
+layout (location = 1) in vec3 aNormal;
+layout (location = 2) in vec2 aTexCoords;
+layout (location = 3) in vec3 aTangent;
+layout (location = 4) in vec3 aBitangent;
+
+#include "../common/camera.glsl"
+
+out vec3 FragPos;
+out vec2 TexCoords;
+out mat3 TBN;
+
+uniform mat4 model;
+
+void main()
+{
+    FragPos = vec3(model * vec4(aPos, 1.0));
+    TexCoords = aTexCoords;
+
+    mat3 normalMatrix = transpose(inverse(mat3(model)));
+    vec3 N = normalize(normalMatrix * aNormal);
+    // Gram-Schmidt the tangent back onto the surface, meshes without tangents only use N
+    vec3 T = mat3(model) * aTangent;
+    T = T - dot(T, N) * N;
+    T = dot(T, T) > 0.0 ? normalize(T) : vec3(0.0);
+    // the bitangent only decides the handedness, mirrored UVs flip it
+    vec3 B = cross(N, T);
+    if (dot(B, mat3(model) * aBitangent) < 0.0)
+        B = -B;
+    TBN = mat3(T, B, N);
+
+    gl_Position = projection * view * vec4(FragPos, 1.0);
+}
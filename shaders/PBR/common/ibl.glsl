@@ -0,0 +1,31 @@
+// Ambient light from the environment, computed by utils.IBL. Pulled in with #include "common/ibl.glsl"
+// after common/pbr.glsl.
+
+uniform samplerCube irradianceMap;
+uniform samplerCube prefilterMap;
+uniform sampler2D brdfLUT;
+// the level of prefilterMap holding the reflections of roughness 1
+uniform float prefilterMaxLod;
+
+// Fresnel for light from every direction of the hemisphere, rough surfaces reflect less of it at grazing angles
+vec3 FresnelSchlickRoughness(float cosTheta, vec3 F0, float roughness)
+{
+    return F0 + (max(vec3(1.0 - roughness), F0) - F0) * pow(clamp(1.0 - cosTheta, 0.0, 1.0), 5.0);
+}
+
+// The environment's light reflected towards the viewer along V, diffuse and specular
+vec3 CalcIBL(Surface s, vec3 V)
+{
+    float NdotV = max(dot(s.normal, V), 0.0);
+    vec3 F = FresnelSchlickRoughness(NdotV, BaseReflectivity(s), s.roughness);
+    vec3 kD = (vec3(1.0) - F) * (1.0 - s.metallic);
+    vec3 diffuse = texture(irradianceMap, s.normal).rgb * s.albedo;
+
+    // split sum: the environment prefiltered for the roughness, times the BRDF's scale and bias to F0
+    vec3 R = reflect(-V, s.normal);
+    vec3 prefiltered = textureLod(prefilterMap, R, s.roughness * prefilterMaxLod).rgb;
+    vec2 brdf = texture(brdfLUT, vec2(NdotV, s.roughness)).rg;
+    vec3 specular = prefiltered * (F * brdf.x + brdf.y);
+
+    return (kD * diffuse + specular) * s.ao;
+}
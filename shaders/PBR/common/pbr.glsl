@@ -0,0 +1,123 @@
+// Metallic-roughness materials lit with the Cook-Torrance BRDF, pulled in with #include "common/pbr.glsl".
+// The material mirrors utils.PBRMaterial, its maps are named the way utils.Mesh binds them.
+
+struct PBRMaterial {
+    sampler2D texture_albedo1;
+    sampler2D texture_metallic1;
+    sampler2D texture_roughness1;
+    sampler2D texture_ao1;
+    sampler2D texture_normal1;
+    // which of the maps the mesh has, without one the factor below is used alone
+    bool hasAlbedoMap;
+    bool hasMetallicMap;
+    bool hasRoughnessMap;
+    bool hasAOMap;
+    bool hasNormalMap;
+
+    vec3 albedo;
+    float metallic;
+    float roughness;
+    float ao;
+};
+
+uniform PBRMaterial material;
+
+// The material at one fragment, with the maps applied.
+struct Surface {
+    vec3 albedo;
+    float metallic;
+    float roughness;
+    float ao;
+    vec3 normal;
+};
+
+const float PI = 3.14159265359;
+
+// TBN has the world space tangent, bitangent and normal of the fragment as columns.
+Surface SampleMaterial(vec2 texCoords, mat3 TBN)
+{
+    Surface s;
+    s.albedo = material.albedo;
+    s.metallic = material.metallic;
+    s.roughness = material.roughness;
+    s.ao = material.ao;
+    if (material.hasAlbedoMap)
+        s.albedo *= texture(material.texture_albedo1, texCoords).rgb;
+    // glTF packs both into one texture, metallic in blue and roughness in green, grayscale maps work either way
+    if (material.hasMetallicMap)
+        s.metallic *= texture(material.texture_metallic1, texCoords).b;
+    if (material.hasRoughnessMap)
+        s.roughness *= texture(material.texture_roughness1, texCoords).g;
+    if (material.hasAOMap)
+        s.ao *= texture(material.texture_ao1, texCoords).r;
+
+    s.normal = normalize(TBN[2]);
+    if (material.hasNormalMap) {
+        vec3 tangentNormal = texture(material.texture_normal1, texCoords).rgb * 2.0 - 1.0;
+        s.normal = normalize(TBN * tangentNormal);
+    }
+    return s;
+}
+
+// GGX / Trowbridge-Reitz: the share of microfacets lined up with the halfway vector H
+float DistributionGGX(vec3 N, vec3 H, float roughness)
+{
+    float a = roughness * roughness;
+    float a2 = a * a;
+    float NdotH = max(dot(N, H), 0.0);
+    float denom = NdotH * NdotH * (a2 - 1.0) + 1.0;
+    return a2 / (PI * denom * denom);
+}
+
+// Schlick-GGX: the share of microfacets not hidden by others when seen at cosTheta, k remapped for direct light
+float GeometrySchlickGGX(float cosTheta, float roughness)
+{
+    float r = roughness + 1.0;
+    float k = (r * r) / 8.0;
+    return cosTheta / (cosTheta * (1.0 - k) + k);
+}
+
+// Smith: microfacets shadowed from the light and masked from the viewer
+float GeometrySmith(vec3 N, vec3 V, vec3 L, float roughness)
+{
+    float NdotV = max(dot(N, V), 0.0);
+    float NdotL = max(dot(N, L), 0.0);
+    return GeometrySchlickGGX(NdotV, roughness) * GeometrySchlickGGX(NdotL, roughness);
+}
+
+// Schlick's approximation of how much light is reflected rather than refracted, F0 straight on
+vec3 FresnelSchlick(float cosTheta, vec3 F0)
+{
+    return F0 + (1.0 - F0) * pow(clamp(1.0 - cosTheta, 0.0, 1.0), 5.0);
+}
+
+// Reflectance straight on: 4% for dielectrics, the albedo tints it for metals
+vec3 BaseReflectivity(Surface s)
+{
+    return mix(vec3(0.04), s.albedo, s.metallic);
+}
+
+// Light arriving along L with radiance, reflected towards the viewer along V
+vec3 CalcPBRLight(Surface s, vec3 V, vec3 L, vec3 radiance)
+{
+    vec3 H = normalize(V + L);
+    float NdotV = max(dot(s.normal, V), 0.0);
+    float NdotL = max(dot(s.normal, L), 0.0);
+
+    float NDF = DistributionGGX(s.normal, H, s.roughness);
+    float G = GeometrySmith(s.normal, V, L, s.roughness);
+    vec3 F = FresnelSchlick(max(dot(H, V), 0.0), BaseReflectivity(s));
+    vec3 specular = NDF * G * F / (4.0 * NdotV * NdotL + 0.0001);
+
+    // what isn't reflected is refracted and scattered back out diffusely, metals absorb it
+    vec3 kD = (vec3(1.0) - F) * (1.0 - s.metallic);
+    return (kD * s.albedo / PI + specular) * radiance * NdotL;
+}
+
+// A point light of color at lightPos, falling off with the inverse square of the distance
+vec3 CalcPBRPointLight(Surface s, vec3 lightPos, vec3 color, vec3 fragPos, vec3 V)
+{
+    vec3 L = lightPos - fragPos;
+    float distance = length(L);
+    return CalcPBRLight(s, V, L / distance, color / (distance * distance));
+}
@@ -0,0 +1,8 @@
+// Camera matrices shared by every program in a scene, filled from Go with a utils.UniformBuffer
+// bound to utils.CameraBinding.
+
+layout (std140) uniform Camera {
+    mat4 projection;
+    mat4 view;
+    vec3 viewPos;
+};
@@ -0,0 +1,205 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Keeps what is brighter than threshold, with a soft knee so the cut off doesn't show. Four bilinear
+// taps average the 4x4 block of the full size image that ends up in one pixel of the first mip.
+const bloomPrefilterShader = postFragmentHeader + `
+uniform float threshold;
+uniform float knee;
+
+void main()
+{
+    vec3 color = texture(screenTexture, TexCoords + vec2(-1.0, -1.0) * texelSize).rgb;
+    color += texture(screenTexture, TexCoords + vec2(1.0, -1.0) * texelSize).rgb;
+    color += texture(screenTexture, TexCoords + vec2(-1.0, 1.0) * texelSize).rgb;
+    color += texture(screenTexture, TexCoords + vec2(1.0, 1.0) * texelSize).rgb;
+    color *= 0.25;
+
+    float brightness = max(color.r, max(color.g, color.b));
+    float soft = clamp(brightness - threshold + knee, 0.0, 2.0 * knee);
+    soft = soft * soft / (4.0 * knee + 1e-4);
+    float contribution = max(soft, brightness - threshold) / max(brightness, 1e-4);
+    FragColor = vec4(color * contribution, 1.0);
+}
+`
+
+// Dual Kawase downsample: the centre and four diagonal taps.
+const bloomDownsampleShader = postFragmentHeader + `
+void main()
+{
+    vec2 o = texelSize * 0.5;
+    vec3 sum = texture(screenTexture, TexCoords).rgb * 4.0;
+    sum += texture(screenTexture, TexCoords + vec2(-o.x, -o.y)).rgb;
+    sum += texture(screenTexture, TexCoords + vec2(o.x, -o.y)).rgb;
+    sum += texture(screenTexture, TexCoords + vec2(-o.x, o.y)).rgb;
+    sum += texture(screenTexture, TexCoords + vec2(o.x, o.y)).rgb;
+    FragColor = vec4(sum / 8.0, 1.0);
+}
+`
+
+// Dual Kawase upsample: a ring of eight taps, radius spreads them further apart.
+const bloomUpsampleShader = postFragmentHeader + `
+uniform float radius;
+
+void main()
+{
+    vec2 o = texelSize * 0.5 * radius;
+    vec3 sum = texture(screenTexture, TexCoords + vec2(-o.x * 2.0, 0.0)).rgb;
+    sum += texture(screenTexture, TexCoords + vec2(o.x * 2.0, 0.0)).rgb;
+    sum += texture(screenTexture, TexCoords + vec2(0.0, -o.y * 2.0)).rgb;
+    sum += texture(screenTexture, TexCoords + vec2(0.0, o.y * 2.0)).rgb;
+    sum += texture(screenTexture, TexCoords + vec2(-o.x, -o.y)).rgb * 2.0;
+    sum += texture(screenTexture, TexCoords + vec2(o.x, -o.y)).rgb * 2.0;
+    sum += texture(screenTexture, TexCoords + vec2(-o.x, o.y)).rgb * 2.0;
+    sum += texture(screenTexture, TexCoords + vec2(o.x, o.y)).rgb * 2.0;
+    FragColor = vec4(sum / 12.0, 1.0);
+}
+`
+
+const bloomCompositeShader = postFragmentHeader + `
+uniform sampler2D bloomTexture;
+uniform float intensity;
+
+void main()
+{
+    vec3 color = texture(screenTexture, TexCoords).rgb;
+    FragColor = vec4(color + texture(bloomTexture, TexCoords).rgb * intensity, 1.0);
+}
+`
+
+// Number of mips in the blur pyramid, the first is half the size of the image.
+const bloomLevels = 6
+
+// Makes bright parts of the image glow. The pixels above Threshold are blurred through a pyramid of
+// ever smaller framebuffers (dual Kawase filter) and added back onto the image. Put Effect before tone
+// mapping in a PostProcessor, so it sees the HDR colours.
+type Bloom struct {
+	// Brightness a pixel needs to start glowing, and how soft the transition is.
+	Threshold, Knee float32
+	// Strength of the glow, 1 spreads about as much light as the bright pixels have.
+	Intensity float32
+	// Spread of the blur, 1 is the natural size of the filter.
+	Radius float32
+
+	Effect *PostEffect
+
+	prefilter, downsample, upsample *PostEffect
+	mips                            [bloomLevels]*Framebuffer
+}
+
+// Create the bloom passes, the effect starts out disabled.
+func NewBloom() (*Bloom, error) {
+	b := &Bloom{Threshold: 1, Knee: 0.5, Intensity: 1, Radius: 1}
+
+	var err error
+	shaders := []struct {
+		effect **PostEffect
+		name   string
+		source string
+	}{
+		{&b.Effect, "bloom", bloomCompositeShader},
+		{&b.prefilter, "bloom-prefilter", bloomPrefilterShader},
+		{&b.downsample, "bloom-downsample", bloomDownsampleShader},
+		{&b.upsample, "bloom-upsample", bloomUpsampleShader},
+	}
+	for _, s := range shaders {
+		if *s.effect, err = NewPostEffect(s.name, s.source, nil); err != nil {
+			b.deleteAll()
+			return nil, err
+		}
+	}
+	for i := range b.mips {
+		// sized to the image on every frame, see resize
+		b.mips[i], err = NewFramebuffer(FramebufferOptions{Width: 1, Height: 1, Color: []uint32{gl.R11F_G11F_B10F}})
+		if err != nil {
+			b.deleteAll()
+			return nil, err
+		}
+	}
+
+	b.Effect.Before = b.blur
+	return b, nil
+}
+
+// Release the GL objects used for blurring. Effect is deleted with the PostProcessor it was added to.
+func (b *Bloom) Delete() {
+	for _, e := range []*PostEffect{b.prefilter, b.downsample, b.upsample} {
+		if e != nil {
+			e.Delete()
+		}
+	}
+	for _, mip := range b.mips {
+		if mip != nil {
+			mip.Delete()
+		}
+	}
+}
+
+// Clean up after NewBloom failed half way.
+func (b *Bloom) deleteAll() {
+	if b.Effect != nil {
+		b.Effect.Delete()
+	}
+	b.Delete()
+}
+
+// Build the blurred image of the bright parts of scene in the first mip, and bind it for the composite pass.
+func (b *Bloom) blur(scene *Framebuffer) {
+	b.resize(scene.Width, scene.Height)
+
+	var blendSrc, blendDst int32
+	blend := gl.IsEnabled(gl.BLEND)
+	gl.GetIntegerv(gl.BLEND_SRC_RGB, &blendSrc)
+	gl.GetIntegerv(gl.BLEND_DST_RGB, &blendDst)
+
+	b.prefilter.Uniforms["threshold"] = b.Threshold
+	b.prefilter.Uniforms["knee"] = max(b.Knee, 1e-4)
+	b.pass(b.prefilter, scene, b.mips[0])
+	for i := 1; i < len(b.mips); i++ {
+		b.pass(b.downsample, b.mips[i-1], b.mips[i])
+	}
+
+	// back up the pyramid, every level is blurred and added onto the one above it
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.ONE, gl.ONE)
+	b.upsample.Uniforms["radius"] = b.Radius
+	for i := len(b.mips) - 1; i > 0; i-- {
+		b.pass(b.upsample, b.mips[i], b.mips[i-1])
+	}
+	if !blend {
+		gl.Disable(gl.BLEND)
+	}
+	gl.BlendFunc(uint32(blendSrc), uint32(blendDst))
+
+	// the first mip now holds every level added up
+	b.Effect.Uniforms["bloomTexture"] = int32(1)
+	b.Effect.Uniforms["intensity"] = b.Intensity / bloomLevels
+	gl.ActiveTexture(gl.TEXTURE1)
+	gl.BindTexture(gl.TEXTURE_2D, b.mips[0].Color[0])
+	gl.ActiveTexture(gl.TEXTURE0)
+}
+
+// Draw the full-screen quad with effect, reading input and writing output.
+func (b *Bloom) pass(effect *PostEffect, input, output *Framebuffer) {
+	output.Bind()
+	effect.shader.Use()
+	effect.setUniforms(mgl32.Vec2{1 / float32(input.Width), 1 / float32(input.Height)})
+	gl.BindTexture(gl.TEXTURE_2D, input.Color[0])
+	gl.DrawArrays(gl.TRIANGLES, 0, 6)
+}
+
+// Halve the size for every level of the pyramid, down to a single pixel.
+func (b *Bloom) resize(width, height int) {
+	for _, mip := range b.mips {
+		width, height = max(width/2, 1), max(height/2, 1)
+		if err := mip.Resize(width, height); err != nil {
+			fmt.Println(err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"testing"
+
+	"opgl-learn/headless"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+func TestBloom(t *testing.T) {
+	withGLContext(t)
+
+	target, err := headless.NewTarget(256, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Delete()
+	screen := *Screen
+	t.Cleanup(func() { *Screen = screen })
+	SetScreen(target.Framebuffer(), target.Width, target.Height)
+
+	bloom, err := NewBloom()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bloom.Delete()
+	p, err := NewPostProcessor([]*PostEffect{bloom.Effect})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Delete()
+	bloom.Effect.Enabled = true
+
+	// a dim background with a small, very bright square in the middle
+	drawScene := func() (centre, near, far uint8) {
+		p.Begin()
+		gl.ClearColor(0.25, 0.25, 0.25, 1)
+		gl.Clear(gl.COLOR_BUFFER_BIT)
+		gl.Enable(gl.SCISSOR_TEST)
+		gl.Scissor(124, 124, 8, 8)
+		gl.ClearColor(8, 8, 8, 1)
+		gl.Clear(gl.COLOR_BUFFER_BIT)
+		gl.Disable(gl.SCISSOR_TEST)
+		p.End()
+
+		img := target.ReadImage()
+		return img.NRGBAAt(128, 128).R, img.NRGBAAt(128, 136).R, img.NRGBAAt(2, 2).R
+	}
+
+	centre, near, far := drawScene()
+	if centre != 255 {
+		t.Errorf("bright square: got %d, want 255", centre)
+	}
+	// 0.25 is 64, the glow adds to it next to the square but not in the corner
+	if near < 80 {
+		t.Errorf("next to the square: got %d, want a glow above 80", near)
+	}
+	if far < 63 || far > 66 {
+		t.Errorf("corner: got %d, want 64", far)
+	}
+
+	bloom.Threshold = 100
+	if _, near, _ := drawScene(); near < 63 || near > 66 {
+		t.Errorf("above the threshold: got %d next to the square, want 64", near)
+	}
+	if glErr := gl.GetError(); glErr != gl.NO_ERROR {
+		t.Errorf("OpenGL error 0x%x", glErr)
+	}
+}
@@ -0,0 +1,229 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Most cascades a CascadedShadowMap can have, the size of the arrays in cascadedShadows.glsl.
+const MaxCascades = 8
+
+// Shadows of a directional light over a large view. The camera frustum is cut into cascades along
+// its view direction, each with a shadow map of its own, so the cascades near the camera cover
+// a small area in fine detail and the far ones a large area coarsely. The maps are the layers of
+// one texture array.
+//
+// Call Update every frame after the camera moved, then Render, and sample the maps in the lighting
+// shader through shaders/Lighting/common/cascadedShadows.glsl, see SetUniforms.
+type CascadedShadowMap struct {
+	// Distance along the camera's view direction where each cascade ends, set by Update.
+	Splits []float32
+	// Projection * view of the light for each cascade, set by Update.
+	LightSpaces []mgl32.Mat4
+	// How the frustum is split: 0 cuts it into equally long cascades, 1 grows them logarithmically
+	// with the distance, the practical split scheme blends between the two.
+	Lambda float32
+	// Fraction of each cascade, at its far end, that fades into the next one so the switch doesn't show.
+	Blend float32
+	// Depth offset while drawing the maps, like ShadowMap.SlopeBias and ShadowMap.ConstantBias.
+	SlopeBias, ConstantBias float32
+	// Percentage closer filtering radius, like ShadowMap.PCFRadius.
+	PCFRadius int32
+	// Tint every cascade with its own colour in the lighting shader.
+	Debug bool
+
+	texture, framebuffer uint32
+	size                 int
+	shader               *Shader
+}
+
+// Create cascades shadow maps of size x size texels, from 1 to MaxCascades of them.
+func NewCascadedShadowMap(size, cascades int) (*CascadedShadowMap, error) {
+	if cascades < 1 || cascades > MaxCascades {
+		return nil, fmt.Errorf("cascaded shadow map: %d cascades, use 1 to %d", cascades, MaxCascades)
+	}
+	program, err := BuildShaderProgram(shadowDepthVertexShader, shadowDepthFragmentShader)
+	if err != nil {
+		return nil, err
+	}
+	s := &CascadedShadowMap{
+		Splits:       make([]float32, cascades),
+		LightSpaces:  make([]mgl32.Mat4, cascades),
+		Lambda:       0.75,
+		Blend:        0.1,
+		SlopeBias:    2,
+		ConstantBias: 4,
+		PCFRadius:    1,
+		size:         size,
+		shader:       NewShaderObject(program),
+	}
+
+	gl.GenTextures(1, &s.texture)
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, s.texture)
+	gl.TexImage3D(gl.TEXTURE_2D_ARRAY, 0, gl.DEPTH_COMPONENT24, int32(size), int32(size), int32(cascades), 0, gl.DEPTH_COMPONENT, gl.FLOAT, nil)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	// outside a map nothing is in shadow
+	border := [4]float32{1, 1, 1, 1}
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_BORDER)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_BORDER)
+	gl.TexParameterfv(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_BORDER_COLOR, &border[0])
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, 0)
+
+	var previous int32
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &previous)
+	defer gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(previous))
+
+	gl.GenFramebuffers(1, &s.framebuffer)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, s.framebuffer)
+	gl.FramebufferTextureLayer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, s.texture, 0, 0)
+	gl.DrawBuffer(gl.NONE)
+	gl.ReadBuffer(gl.NONE)
+	if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+		s.Delete()
+		return nil, fmt.Errorf("cascaded shadow map %dx%d is not complete: %s", size, size, framebufferStatusName(status))
+	}
+	return s, nil
+}
+
+// The depth texture array, layer i is the map of cascade i.
+func (s *CascadedShadowMap) Texture() uint32 {
+	return s.texture
+}
+
+// Fit the cascades to the part of camera's frustum between near and far, for a light shining along
+// direction. aspect is the width / height of the camera's projection, its field of view is camera.Zoom.
+func (s *CascadedShadowMap) Update(camera *Camera, aspect, near, far float32, direction mgl32.Vec3) {
+	view := camera.GetViewMatrix()
+	fovy := mgl32.DegToRad(float32(camera.Zoom))
+	s.Splits = cascadeSplits(near, far, len(s.Splits), s.Lambda)
+
+	// the light never turns with the camera, only the orthographic box around each cascade moves
+	direction = direction.Normalize()
+	up := mgl32.Vec3{0, 1, 0}
+	if math.Abs(float64(direction.Y())) > 0.99 {
+		up = mgl32.Vec3{0, 0, 1}
+	}
+	lightView := mgl32.LookAtV(mgl32.Vec3{}, direction, up)
+
+	start := near
+	for i, end := range s.Splits {
+		corners := frustumCorners(view, fovy, aspect, start, end)
+		start = end
+
+		// A sphere around the slice of the frustum keeps the same size however the camera turns,
+		// so the texels do too. Rounding the radius up keeps float noise from changing it.
+		var centre mgl32.Vec3
+		for _, c := range corners {
+			centre = centre.Add(c.Mul(1.0 / 8))
+		}
+		var radius float32
+		for _, c := range corners {
+			radius = max(radius, c.Sub(centre).Len())
+		}
+		radius = float32(math.Ceil(float64(radius)*16)) / 16
+
+		// Move the box in whole texels, so a still scene lands on the same texels while the camera
+		// moves and the shadow edges don't shimmer.
+		c := lightView.Mul4x1(centre.Vec4(1)).Vec3()
+		texel := 2 * radius / float32(s.size)
+		x := float32(math.Floor(float64(c.X()/texel))) * texel
+		y := float32(math.Floor(float64(c.Y()/texel))) * texel
+
+		// the light looks down -z, casters in front of the near plane are clamped onto it by Render
+		projection := mgl32.Ortho(x-radius, x+radius, y-radius, y+radius, -c.Z()-radius, -c.Z()+radius)
+		s.LightSpaces[i] = projection.Mul4(lightView)
+	}
+}
+
+// Draw the map of every cascade. draw is called once per cascade with the depth shader in use, it draws
+// the shadow casters after setting "model" on the shader. The framebuffer and viewport in use are left
+// as they were.
+func (s *CascadedShadowMap) Render(draw func(depth *Shader)) {
+	var previousFramebuffer int32
+	var previousViewport [4]int32
+	gl.GetIntegerv(gl.DRAW_FRAMEBUFFER_BINDING, &previousFramebuffer)
+	gl.GetIntegerv(gl.VIEWPORT, &previousViewport[0])
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, s.framebuffer)
+	gl.Viewport(0, 0, int32(s.size), int32(s.size))
+	gl.Enable(gl.POLYGON_OFFSET_FILL)
+	gl.PolygonOffset(s.SlopeBias, s.ConstantBias)
+	// casters between the light and a cascade are outside its box but still throw shadows into it
+	gl.Enable(gl.DEPTH_CLAMP)
+
+	s.shader.Use()
+	for i := range s.LightSpaces {
+		gl.FramebufferTextureLayer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, s.texture, 0, int32(i))
+		gl.Clear(gl.DEPTH_BUFFER_BIT)
+		s.shader.SetMat4("lightSpaceMatrix", &s.LightSpaces[i])
+		draw(s.shader)
+	}
+
+	gl.Disable(gl.DEPTH_CLAMP)
+	gl.Disable(gl.POLYGON_OFFSET_FILL)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(previousFramebuffer))
+	gl.Viewport(previousViewport[0], previousViewport[1], previousViewport[2], previousViewport[3])
+}
+
+// Bind the maps to texture unit and set the uniforms of cascadedShadows.glsl on program, which has to be in use.
+func (s *CascadedShadowMap) SetUniforms(program Program, unit uint32) {
+	gl.ActiveTexture(gl.TEXTURE0 + unit)
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, s.texture)
+	gl.ActiveTexture(gl.TEXTURE0)
+
+	SetInt(program, "cascadeShadowMap", int32(unit))
+	SetInt(program, "cascadeCount", int32(len(s.Splits)))
+	for i := range s.Splits {
+		SetFloat(program, fmt.Sprintf("cascadeSplits[%d]", i), s.Splits[i])
+		SetMat4(program, fmt.Sprintf("cascadeLightSpaces[%d]", i), &s.LightSpaces[i])
+	}
+	SetFloat(program, "cascadeBlend", s.Blend)
+	SetInt(program, "cascadePCFRadius", s.PCFRadius)
+	debug := int32(0)
+	if s.Debug {
+		debug = 1
+	}
+	SetBool(program, "cascadeDebug", debug)
+}
+
+// Release the texture array, its framebuffer and the depth shader.
+func (s *CascadedShadowMap) Delete() {
+	gl.DeleteFramebuffers(1, &s.framebuffer)
+	gl.DeleteTextures(1, &s.texture)
+	gl.DeleteProgram(s.shader.ID)
+}
+
+// Where each of count cascades between near and far ends. The practical split scheme: lambda blends
+// logarithmic splits, which match how perspective shrinks things, with uniform ones, which keep the
+// first cascades from getting tiny.
+func cascadeSplits(near, far float32, count int, lambda float32) []float32 {
+	splits := make([]float32, count)
+	for i := range splits {
+		p := float64(i+1) / float64(count)
+		logarithmic := float64(near) * math.Pow(float64(far/near), p)
+		uniform := float64(near) + float64(far-near)*p
+		splits[i] = float32(float64(lambda)*logarithmic + float64(1-lambda)*uniform)
+	}
+	return splits
+}
+
+// World space corners of the part of a camera frustum between near and far.
+func frustumCorners(view mgl32.Mat4, fovy, aspect, near, far float32) [8]mgl32.Vec3 {
+	inverse := mgl32.Perspective(fovy, aspect, near, far).Mul4(view).Inv()
+	var corners [8]mgl32.Vec3
+	for i := range corners {
+		ndc := mgl32.Vec4{-1, -1, -1, 1}
+		for axis := range 3 {
+			if i&(1<<axis) != 0 {
+				ndc[axis] = 1
+			}
+		}
+		p := inverse.Mul4x1(ndc)
+		corners[i] = p.Vec3().Mul(1 / p.W())
+	}
+	return corners
+}
@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestCascadeSplits(t *testing.T) {
+	uniform := cascadeSplits(1, 101, 4, 0)
+	for i, want := range []float32{26, 51, 76, 101} {
+		if math.Abs(float64(uniform[i]-want)) > 1e-3 {
+			t.Errorf("uniform split %d: got %v, want %v", i, uniform[i], want)
+		}
+	}
+	logarithmic := cascadeSplits(1, 1000, 3, 1)
+	for i, want := range []float32{10, 100, 1000} {
+		if math.Abs(float64(logarithmic[i]-want)) > 1e-2 {
+			t.Errorf("logarithmic split %d: got %v, want %v", i, logarithmic[i], want)
+		}
+	}
+	practical := cascadeSplits(0.1, 150, 4, 0.75)
+	for i := range practical {
+		if i > 0 && practical[i] <= practical[i-1] {
+			t.Errorf("splits don't grow: %v", practical)
+		}
+	}
+	if practical[3] != 150 {
+		t.Errorf("last split: got %v, want the far plane", practical[3])
+	}
+}
+
+func TestCascadedShadowMapUpdate(t *testing.T) {
+	const size = 1024
+	s := &CascadedShadowMap{Splits: make([]float32, 4), LightSpaces: make([]mgl32.Mat4, 4), Lambda: 0.75, size: size}
+	sun := mgl32.Vec3{-0.6, -0.6, -0.4}
+	camera := NewCamera(mgl32.Vec3{0, 1, 8}, mgl32.Vec3{0, 1, 0}, YAW, -10)
+	s.Update(&camera, 4.0/3, 0.1, 150, sun)
+
+	// every cascade holds its slice of the frustum
+	fovy := mgl32.DegToRad(float32(camera.Zoom))
+	start := float32(0.1)
+	for i, end := range s.Splits {
+		for _, corner := range frustumCorners(camera.GetViewMatrix(), fovy, 4.0/3, start, end) {
+			p := s.LightSpaces[i].Mul4x1(corner.Vec4(1))
+			if math.Abs(float64(p.X())) > 1.0001 || math.Abs(float64(p.Y())) > 1.0001 || math.Abs(float64(p.Z())) > 1.0001 {
+				t.Errorf("cascade %d: corner %v lands outside at %v", i, corner, p)
+			}
+		}
+		start = end
+	}
+
+	// Turning the camera keeps the size of the texels, moving it shifts the maps by whole texels.
+	before := s.LightSpaces[0]
+	camera.Position = camera.Position.Add(mgl32.Vec3{0.37, 0.11, -0.23})
+	camera.ProcessMouseMovement(123, 17, true)
+	s.Update(&camera, 4.0/3, 0.1, 150, sun)
+	after := s.LightSpaces[0]
+	if math.Abs(float64(before[0]-after[0])) > 1e-6 || math.Abs(float64(before[5]-after[5])) > 1e-6 {
+		t.Errorf("texel size changed when the camera turned: %v, %v to %v, %v", before[0], before[5], after[0], after[5])
+	}
+	for _, axis := range []int{12, 13} {
+		texels := float64(after[axis]-before[axis]) * size / 2
+		if math.Abs(texels-math.Round(texels)) > 0.01 {
+			t.Errorf("the map moved by %v texels", texels)
+		}
+	}
+}
+
+func TestCascadedShadowMap(t *testing.T) {
+	withGLContext(t)
+
+	if _, err := NewCascadedShadowMap(16, MaxCascades+1); err == nil {
+		t.Error("too many cascades: no error")
+	}
+	s, err := NewCascadedShadowMap(16, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Delete()
+
+	// a big floor under the camera, every cascade sees some of it
+	quad := []float32{-100, -1, -100, 100, -1, -100, 100, -1, 100, -100, -1, -100, 100, -1, 100, -100, -1, 100}
+	var vao, vbo uint32
+	gl.GenVertexArrays(1, &vao)
+	gl.GenBuffers(1, &vbo)
+	defer gl.DeleteVertexArrays(1, &vao)
+	defer gl.DeleteBuffers(1, &vbo)
+	gl.BindVertexArray(vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*len(quad), gl.Ptr(quad), gl.STATIC_DRAW)
+	gl.VertexAttribPointerWithOffset(0, 3, gl.FLOAT, false, 3*4, 0)
+	gl.EnableVertexAttribArray(0)
+
+	camera := NewCamera(mgl32.Vec3{0, 1, 0}, mgl32.Vec3{0, 1, 0}, YAW, -20)
+	s.Update(&camera, 1, 0.1, 50, mgl32.Vec3{0, -1, 0.1})
+	gl.Viewport(1, 2, 3, 4)
+	gl.Enable(gl.DEPTH_TEST)
+	defer gl.Disable(gl.DEPTH_TEST)
+	cascades := 0
+	s.Render(func(depth *Shader) {
+		cascades++
+		model := mgl32.Ident4()
+		depth.SetMat4("model", &model)
+		gl.DrawArrays(gl.TRIANGLES, 0, 6)
+	})
+	if cascades != 3 {
+		t.Errorf("draw called for %d cascades, want 3", cascades)
+	}
+	var viewport [4]int32
+	gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+	if viewport != [4]int32{1, 2, 3, 4} {
+		t.Errorf("viewport after Render: got %v", viewport)
+	}
+
+	texels := make([]float32, 16*16*3)
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, s.Texture())
+	gl.GetTexImage(gl.TEXTURE_2D_ARRAY, 0, gl.DEPTH_COMPONENT, gl.FLOAT, gl.Ptr(texels))
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, 0)
+	for layer := range 3 {
+		drawn := 0
+		for _, d := range texels[layer*256 : (layer+1)*256] {
+			if d < 1 {
+				drawn++
+			}
+		}
+		if drawn == 0 {
+			t.Errorf("cascade %d: the floor isn't in its map", layer)
+		}
+	}
+	if glErr := gl.GetError(); glErr != gl.NO_ERROR {
+		t.Errorf("OpenGL error 0x%x", glErr)
+	}
+}
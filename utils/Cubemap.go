@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"os"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Cubemap faces in the order OpenGL numbers them, face i is uploaded to GL_TEXTURE_CUBE_MAP_POSITIVE_X + i.
+const (
+	FaceRight  = iota // +X
+	FaceLeft          // -X
+	FaceTop           // +Y
+	FaceBottom        // -Y
+	FaceFront         // +Z
+	FaceBack          // -Z
+)
+
+// Load six images into a cubemap, faces are ordered right, left, top, bottom, front, back (see FaceRight).
+// Exits the program if anything goes wrong, use LoadCubemap to handle the error instead.
+func NewCubemap(faces [6]string, opts TextureOptions) uint32 {
+	cubemap, err := LoadCubemap(faces, opts)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	return cubemap
+}
+
+// Same as NewCubemap but returns the error. Wrapping defaults to gl.CLAMP_TO_EDGE so the seams don't show.
+func LoadCubemap(faces [6]string, opts TextureOptions) (uint32, error) {
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, texture)
+	cubemapOptions(opts).apply(gl.TEXTURE_CUBE_MAP)
+
+	for i, face := range faces {
+		img, err := LoadImage(face)
+		if err != nil {
+			gl.DeleteTextures(1, &texture)
+			return 0, err
+		}
+		// unlike 2D textures cubemap faces start at the top left, no flipping
+		target := uint32(gl.TEXTURE_CUBE_MAP_POSITIVE_X + i)
+		if hdr, ok := img.(*HDRImage); ok {
+			uploadHDR(target, hdr, orDefault(opts.InternalFormat, gl.RGB16F), false)
+		} else {
+			uploadImage(target, img, opts, false)
+		}
+	}
+
+	if !opts.NoMipmaps {
+		gl.GenerateMipmap(gl.TEXTURE_CUBE_MAP)
+	}
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, 0)
+	return texture, nil
+}
+
+// Load a Radiance .hdr image in equirectangular (longitude/latitude) projection and resample it
+// into a cubemap with faces of size x size pixels. Faces are RGB16F unless opts says otherwise.
+func LoadEquirectangularCubemap(path string, size int, opts TextureOptions) (uint32, error) {
+	img, err := LoadImage(path)
+	if err != nil {
+		return 0, err
+	}
+	hdr, ok := img.(*HDRImage)
+	if !ok {
+		return 0, fmt.Errorf("%s: equirectangular maps have to be Radiance HDR images", path)
+	}
+	faces := EquirectangularToCubemap(hdr, size)
+
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, texture)
+	cubemapOptions(opts).apply(gl.TEXTURE_CUBE_MAP)
+	for i, face := range faces {
+		uploadHDR(uint32(gl.TEXTURE_CUBE_MAP_POSITIVE_X+i), face, orDefault(opts.InternalFormat, gl.RGB16F), false)
+	}
+	if !opts.NoMipmaps {
+		gl.GenerateMipmap(gl.TEXTURE_CUBE_MAP)
+	}
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, 0)
+	return texture, nil
+}
+
+func cubemapOptions(opts TextureOptions) TextureOptions {
+	opts.WrapS = orDefault(opts.WrapS, gl.CLAMP_TO_EDGE)
+	opts.WrapT = orDefault(opts.WrapT, gl.CLAMP_TO_EDGE)
+	opts.WrapR = orDefault(opts.WrapR, gl.CLAMP_TO_EDGE)
+	return opts
+}
+
+// Resample an equirectangular image into six cubemap faces, ordered like FaceRight.
+// The centre column of the image looks down +X and the top row straight up.
+func EquirectangularToCubemap(img *HDRImage, size int) [6]*HDRImage {
+	var faces [6]*HDRImage
+	for face := range faces {
+		faces[face] = NewHDRImage(image.Rect(0, 0, size, size))
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				dx, dy, dz := CubemapDirection(face, (float64(x)+0.5)/float64(size), (float64(y)+0.5)/float64(size))
+				faces[face].SetRGB(x, y, sampleEquirectangular(img, dx, dy, dz))
+			}
+		}
+	}
+	return faces
+}
+
+// The (unnormalised) direction through the point (s, t) of a cubemap face, s and t go from 0 to 1
+// left to right and top to bottom of the face image. Follows the face orientation table of the GL spec.
+func CubemapDirection(face int, s, t float64) (x, y, z float64) {
+	a, b := 2*s-1, 2*t-1
+	switch face {
+	case FaceRight:
+		return 1, -b, -a
+	case FaceLeft:
+		return -1, -b, a
+	case FaceTop:
+		return a, 1, b
+	case FaceBottom:
+		return a, -1, -b
+	case FaceFront:
+		return a, -b, 1
+	default:
+		return -a, -b, -1
+	}
+}
+
+// Bilinearly sample the equirectangular image in direction (x, y, z), wrapping around horizontally.
+func sampleEquirectangular(img *HDRImage, x, y, z float64) [3]float32 {
+	length := math.Sqrt(x*x + y*y + z*z)
+	u := math.Atan2(z, x)/(2*math.Pi) + 0.5
+	v := 0.5 - math.Asin(y/length)/math.Pi
+
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	fx := u*float64(width) - 0.5
+	fy := min(max(v*float64(height)-0.5, 0), float64(height-1))
+	x0, y0 := int(math.Floor(fx)), int(math.Floor(fy))
+	tx, ty := float32(fx-float64(x0)), float32(fy-float64(y0))
+	y1 := min(y0+1, height-1)
+
+	at := func(x, y int) [3]float32 {
+		x = ((x % width) + width) % width
+		return img.RGBAt(img.Rect.Min.X+x, img.Rect.Min.Y+y)
+	}
+	c00, c10, c01, c11 := at(x0, y0), at(x0+1, y0), at(x0, y1), at(x0+1, y1)
+
+	var c [3]float32
+	for i := range c {
+		top := c00[i]*(1-tx) + c10[i]*tx
+		bottom := c01[i]*(1-tx) + c11[i]*tx
+		c[i] = top*(1-ty) + bottom*ty
+	}
+	return c
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"opgl-learn/headless"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestEquirectangularToCubemap(t *testing.T) {
+	// red holds the horizontal and green the vertical texture coordinate of each pixel
+	equirect := NewHDRImage(image.Rect(0, 0, 64, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 64; x++ {
+			equirect.SetRGB(x, y, [3]float32{(float32(x) + 0.5) / 64, (float32(y) + 0.5) / 32, 0})
+		}
+	}
+	faces := EquirectangularToCubemap(equirect, 16)
+
+	tests := []struct {
+		face int
+		u, v float32
+	}{
+		{FaceRight, 0.5, 0.5},
+		{FaceFront, 0.75, 0.5},
+		{FaceBack, 0.25, 0.5},
+		{FaceTop, -1, 0.5 / 32}, // the pole, any u
+		{FaceBottom, -1, 31.5 / 32},
+	}
+	for _, tt := range tests {
+		// the centre of a 16 pixel face falls between pixels 7 and 8
+		c := faces[tt.face].RGBAt(8, 8)
+		if tt.u >= 0 && math.Abs(float64(c[0]-tt.u)) > 0.05 {
+			t.Errorf("face %d: got u %v, want %v", tt.face, c[0], tt.u)
+		}
+		if math.Abs(float64(c[1]-tt.v)) > 0.05 {
+			t.Errorf("face %d: got v %v, want %v", tt.face, c[1], tt.v)
+		}
+	}
+}
+
+// A different solid colour for every face of the test cubemaps.
+var faceColors = [6]color.NRGBA{
+	{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255},
+	{255, 255, 0, 255}, {0, 255, 255, 255}, {255, 0, 255, 255},
+}
+
+// A cubemap with every face filled with its colour from faceColors.
+func solidCubemap(t *testing.T) uint32 {
+	t.Helper()
+	dir := t.TempDir()
+	var faces [6]string
+	for i, c := range faceColors {
+		img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+		for p := 0; p < len(img.Pix); p += 4 {
+			copy(img.Pix[p:], []uint8{c.R, c.G, c.B, c.A})
+		}
+		faces[i] = filepath.Join(dir, fmt.Sprintf("face%d.png", i))
+		file, err := os.Create(faces[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		png.Encode(file, img)
+		file.Close()
+	}
+
+	cubemap, err := LoadCubemap(faces, TextureOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cubemap
+}
+
+func TestSkybox(t *testing.T) {
+	withGLContext(t)
+
+	cubemap := solidCubemap(t)
+	defer gl.DeleteTextures(1, &cubemap)
+	skybox, err := NewSkybox(cubemap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer skybox.Delete()
+
+	target, err := headless.NewTarget(32, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Delete()
+	gl.Enable(gl.DEPTH_TEST)
+	gl.DepthFunc(gl.LESS)
+
+	projection := mgl32.Perspective(mgl32.DegToRad(45), 1, 0.1, 100)
+	// the camera is far from the origin, the skybox must not care
+	eye := mgl32.Vec3{50, 50, 50}
+	looks := map[int]mgl32.Vec3{
+		FaceBack:  {0, 0, -1},
+		FaceFront: {0, 0, 1},
+		FaceRight: {1, 0, 0},
+		FaceTop:   {0, 1, 0.001},
+	}
+	for face, look := range looks {
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+		skybox.Draw(mgl32.LookAtV(eye, eye.Add(look), mgl32.Vec3{0, 1, 0}), projection)
+
+		if got := target.ReadImage().NRGBAAt(16, 16); got != faceColors[face] {
+			t.Errorf("looking at face %d: got %v, want %v", face, got, faceColors[face])
+		}
+	}
+
+	var depthFunc int32
+	gl.GetIntegerv(gl.DEPTH_FUNC, &depthFunc)
+	if depthFunc != gl.LESS {
+		t.Errorf("depth func not restored, got %#x", depthFunc)
+	}
+}
@@ -0,0 +1,50 @@
+package utils
+
+import "github.com/go-gl/gl/v3.3-core/gl"
+
+// How a mesh uses the environment cubemap of its Model.
+type EnvironmentMode int32
+
+const (
+	// Only the mesh's own diffuse texture, the environment is ignored.
+	EnvironmentDiffuse EnvironmentMode = iota
+	// A perfect mirror, like chrome.
+	EnvironmentReflect
+	// Light bends through the surface, like glass or water.
+	EnvironmentRefract
+)
+
+// The texture unit the environment cubemap is bound to, out of the way of the mesh textures.
+const EnvironmentTextureUnit = 8
+
+// Environment mapping settings of a mesh, mirrors the Environment struct in
+// shaders/AdvancedOpenGL/6-EnvironmentFrag.glsl.
+type EnvironmentMaterial struct {
+	Mode EnvironmentMode
+	// Index of refraction of the material, 1.52 (glass) when left at 0. Also drives the Fresnel term.
+	IOR float32
+	// How much of the Fresnel reflection is blended over the refraction, 0 for none and 1 for all of it.
+	// Surfaces seen at grazing angles reflect more, which is what makes glass look like glass.
+	Fresnel float32
+}
+
+func (e EnvironmentMaterial) ior() float32 {
+	if e.IOR == 0 {
+		return 1.52
+	}
+	return e.IOR
+}
+
+func (e EnvironmentMaterial) apply(shader uint32) {
+	SetInt(shader, "environment.mode", int32(e.Mode))
+	SetFloat(shader, "environment.ior", e.ior())
+	SetFloat(shader, "environment.fresnel", e.Fresnel)
+}
+
+// Bind cubemap for Draw, shader has to be in use and declare a samplerCube environmentMap.
+func bindEnvironmentMap(shader uint32, cubemap uint32) {
+	SetInt(shader, "environmentMap", EnvironmentTextureUnit)
+	gl.ActiveTexture(gl.TEXTURE0 + EnvironmentTextureUnit)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, cubemap)
+	gl.ActiveTexture(gl.TEXTURE0)
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"image/color"
+	"testing"
+
+	"opgl-learn/headless"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// A quad facing +Z at z = -1, from x0 to x1.
+func quadMesh(x0, x1 float32) Mesh {
+	vertices := []Vertex{}
+	for _, p := range [][2]float32{{x0, -1}, {x1, -1}, {x1, 1}, {x0, 1}} {
+		vertices = append(vertices, Vertex{Position: mgl32.Vec3{p[0], p[1], -1}, Normal: mgl32.Vec3{0, 0, 1}})
+	}
+	return NewMesh(vertices, []uint32{0, 1, 2, 2, 3, 0}, nil)
+}
+
+func TestEnvironmentMaterial(t *testing.T) {
+	withGLContext(t)
+
+	shader, err := LoadShader("../shaders/AdvancedOpenGL/6-EnvironmentVert.glsl", "../shaders/AdvancedOpenGL/6-EnvironmentFrag.glsl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gl.DeleteProgram(shader)
+
+	target, err := headless.NewTarget(64, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Delete()
+
+	// looking straight at two quads from the origin: the left one mirrors what is behind the camera,
+	// the right one is glass with no bending so the sky in front shows through
+	model := Model{
+		Meshes:          []Mesh{quadMesh(-1, 0), quadMesh(0, 1)},
+		EnvironmentMap:  solidCubemap(t),
+		Environment:     EnvironmentMaterial{Mode: EnvironmentReflect},
+		MeshEnvironment: map[int]EnvironmentMaterial{1: {Mode: EnvironmentRefract, IOR: 1}},
+	}
+
+	gl.UseProgram(shader)
+	projection := mgl32.Ortho(-1, 1, -1, 1, 0.1, 10)
+	view := mgl32.Ident4()
+	identity := mgl32.Ident4()
+	SetMat4(shader, "projection", &projection)
+	SetMat4(shader, "view", &view)
+	SetMat4(shader, "model", &identity)
+	SetVec3(shader, "cameraPos", &mgl32.Vec3{0, 0, 0})
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+	model.Draw(shader)
+
+	img := target.ReadImage()
+	tests := []struct {
+		name string
+		x    int
+		want color.NRGBA
+	}{
+		{"reflect", 16, faceColors[FaceFront]},
+		{"refract", 48, faceColors[FaceBack]},
+	}
+	for _, tt := range tests {
+		if got := img.NRGBAAt(tt.x, 16); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
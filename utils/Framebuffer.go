@@ -0,0 +1,278 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// How a framebuffer and its attachments are created.
+type FramebufferOptions struct {
+	// Size in pixels. Left at 0 the framebuffer follows the size of Screen, it is reallocated
+	// the next time it is used after the window has been resized.
+	Width, Height int
+	// Internal format of each colour attachment, like gl.RGBA8 or gl.RGBA16F. Fragment shader
+	// output location i is written to attachment i.
+	Color []uint32
+	// Internal format of the depth (and stencil) attachment, like gl.DEPTH24_STENCIL8 or
+	// gl.DEPTH_COMPONENT32F, 0 for none.
+	Depth uint32
+	// Store depth in a texture that can be sampled (shadow maps) instead of a renderbuffer.
+	DepthTexture bool
+	// Min and mag filter of the attachment textures, gl.LINEAR when left at 0.
+	Filter int32
+}
+
+// A framebuffer object with texture colour attachments and an optional depth/stencil attachment.
+// Attachments keep their IDs when the framebuffer is resized, only their storage is reallocated.
+type Framebuffer struct {
+	ID            uint32
+	Width, Height int
+	// One texture per colour attachment, in attachment order.
+	Color []uint32
+	// The depth texture when FramebufferOptions.DepthTexture is set, 0 otherwise.
+	Depth uint32
+
+	opts         FramebufferOptions
+	renderbuffer uint32
+	followScreen bool
+}
+
+// The framebuffer that ends up on screen: the window's default framebuffer, or the offscreen target
+// in headless runs. Bind it to draw the final image after rendering into framebuffers of your own.
+var Screen = &Framebuffer{}
+
+// Point Screen at framebuffer id with the given size, main calls it on start up and from the
+// framebuffer size callback.
+func SetScreen(id uint32, width, height int) {
+	Screen.ID, Screen.Width, Screen.Height = id, width, height
+}
+
+// Create a framebuffer, the previous framebuffer binding is left alone.
+func NewFramebuffer(opts FramebufferOptions) (*Framebuffer, error) {
+	var maxDrawBuffers int32
+	gl.GetIntegerv(gl.MAX_DRAW_BUFFERS, &maxDrawBuffers)
+	if len(opts.Color) > int(maxDrawBuffers) {
+		return nil, fmt.Errorf("framebuffer: %d colour attachments, the driver supports %d", len(opts.Color), maxDrawBuffers)
+	}
+
+	f := &Framebuffer{opts: opts, followScreen: opts.Width == 0 && opts.Height == 0}
+	gl.GenFramebuffers(1, &f.ID)
+	f.Color = make([]uint32, len(opts.Color))
+	if len(f.Color) > 0 {
+		gl.GenTextures(int32(len(f.Color)), &f.Color[0])
+	}
+	if opts.Depth != 0 {
+		if opts.DepthTexture {
+			gl.GenTextures(1, &f.Depth)
+		} else {
+			gl.GenRenderbuffers(1, &f.renderbuffer)
+		}
+	}
+
+	width, height := opts.Width, opts.Height
+	if f.followScreen {
+		width, height = Screen.Width, Screen.Height
+	}
+	if err := f.allocate(width, height); err != nil {
+		f.Delete()
+		return nil, err
+	}
+	return f, nil
+}
+
+// Reallocate the attachments for a new size, nothing happens if the size is the same or 0
+// (a minimised window). Framebuffers following Screen do this by themselves.
+func (f *Framebuffer) Resize(width, height int) error {
+	if (width == f.Width && height == f.Height) || width <= 0 || height <= 0 {
+		return nil
+	}
+	return f.allocate(width, height)
+}
+
+// Bind the framebuffer for drawing and reading, with the viewport covering it.
+func (f *Framebuffer) Bind() {
+	f.fit()
+	gl.BindFramebuffer(gl.FRAMEBUFFER, f.ID)
+	gl.Viewport(0, 0, int32(f.Width), int32(f.Height))
+}
+
+// Copy the buffers picked by mask (gl.COLOR_BUFFER_BIT, gl.DEPTH_BUFFER_BIT, gl.STENCIL_BUFFER_BIT)
+// to dst, stretching them to its size. Colour is read from attachment 0 and written to every draw buffer
+// of dst, filter is gl.NEAREST or gl.LINEAR (depth and stencil only blit with gl.NEAREST).
+// Leaves dst bound like dst.Bind does, ready to draw on top.
+func (f *Framebuffer) BlitTo(dst *Framebuffer, mask, filter uint32) {
+	f.blit(dst, mask, filter, func() {})
+}
+
+// Same as BlitTo for the colour of attachment i.
+func (f *Framebuffer) BlitColorTo(i int, dst *Framebuffer, filter uint32) {
+	f.blit(dst, gl.COLOR_BUFFER_BIT, filter, func() {
+		gl.ReadBuffer(gl.COLOR_ATTACHMENT0 + uint32(i))
+	})
+	// the read buffer is framebuffer state, put it back for the next BlitTo
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, f.ID)
+	gl.ReadBuffer(gl.COLOR_ATTACHMENT0)
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, dst.ID)
+}
+
+func (f *Framebuffer) blit(dst *Framebuffer, mask, filter uint32, setReadBuffer func()) {
+	f.fit()
+	dst.fit()
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, f.ID)
+	setReadBuffer()
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, dst.ID)
+	gl.BlitFramebuffer(0, 0, int32(f.Width), int32(f.Height), 0, 0, int32(dst.Width), int32(dst.Height), mask, filter)
+	dst.Bind()
+}
+
+// Release the framebuffer and its attachments.
+func (f *Framebuffer) Delete() {
+	gl.DeleteFramebuffers(1, &f.ID)
+	if len(f.Color) > 0 {
+		gl.DeleteTextures(int32(len(f.Color)), &f.Color[0])
+	}
+	gl.DeleteTextures(1, &f.Depth)
+	gl.DeleteRenderbuffers(1, &f.renderbuffer)
+}
+
+// Catch up with the size of Screen.
+func (f *Framebuffer) fit() {
+	if !f.followScreen {
+		return
+	}
+	if err := f.Resize(Screen.Width, Screen.Height); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// (Re)allocate the storage of every attachment and check the framebuffer is complete.
+func (f *Framebuffer) allocate(width, height int) error {
+	var drawBinding, readBinding int32
+	gl.GetIntegerv(gl.DRAW_FRAMEBUFFER_BINDING, &drawBinding)
+	gl.GetIntegerv(gl.READ_FRAMEBUFFER_BINDING, &readBinding)
+	defer func() {
+		gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, uint32(drawBinding))
+		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, uint32(readBinding))
+	}()
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, f.ID)
+	filter := orDefault(f.opts.Filter, gl.LINEAR)
+
+	drawBuffers := []uint32{}
+	for i, texture := range f.Color {
+		if err := allocateTexture(texture, f.opts.Color[i], width, height, filter); err != nil {
+			return err
+		}
+		attachment := gl.COLOR_ATTACHMENT0 + uint32(i)
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, attachment, gl.TEXTURE_2D, texture, 0)
+		drawBuffers = append(drawBuffers, attachment)
+	}
+	if len(drawBuffers) > 0 {
+		gl.DrawBuffers(int32(len(drawBuffers)), &drawBuffers[0])
+	} else {
+		// depth only, like a shadow map
+		gl.DrawBuffer(gl.NONE)
+		gl.ReadBuffer(gl.NONE)
+	}
+
+	if f.opts.Depth != 0 {
+		attachment := depthAttachment(f.opts.Depth)
+		if f.opts.DepthTexture {
+			if err := allocateTexture(f.Depth, f.opts.Depth, width, height, filter); err != nil {
+				return err
+			}
+			gl.FramebufferTexture2D(gl.FRAMEBUFFER, attachment, gl.TEXTURE_2D, f.Depth, 0)
+		} else {
+			gl.BindRenderbuffer(gl.RENDERBUFFER, f.renderbuffer)
+			gl.RenderbufferStorage(gl.RENDERBUFFER, f.opts.Depth, int32(width), int32(height))
+			gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+			gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, attachment, gl.RENDERBUFFER, f.renderbuffer)
+		}
+	}
+
+	f.Width, f.Height = width, height
+	if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+		return fmt.Errorf("framebuffer %dx%d is not complete: %s", width, height, framebufferStatusName(status))
+	}
+	return nil
+}
+
+// Allocate the storage of an attachment texture, it is sampled with filter and clamped to the edge.
+func allocateTexture(texture, internalFormat uint32, width, height int, filter int32) error {
+	format, xtype, ok := pixelFormat(internalFormat)
+	if !ok {
+		return fmt.Errorf("framebuffer: unsupported attachment format 0x%x", internalFormat)
+	}
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, int32(internalFormat), int32(width), int32(height), 0, format, xtype, nil)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, filter)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, filter)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return nil
+}
+
+// The pixel format and type TexImage2D wants alongside an internal format. No data is uploaded,
+// but they still have to be compatible with it.
+func pixelFormat(internalFormat uint32) (format, xtype uint32, ok bool) {
+	switch internalFormat {
+	case gl.RGBA8, gl.SRGB8_ALPHA8, gl.RGBA:
+		return gl.RGBA, gl.UNSIGNED_BYTE, true
+	case gl.RGB8, gl.SRGB8, gl.RGB:
+		return gl.RGB, gl.UNSIGNED_BYTE, true
+	case gl.RG8:
+		return gl.RG, gl.UNSIGNED_BYTE, true
+	case gl.R8:
+		return gl.RED, gl.UNSIGNED_BYTE, true
+	case gl.RGBA16F, gl.RGBA32F:
+		return gl.RGBA, gl.FLOAT, true
+	case gl.RGB16F, gl.RGB32F, gl.R11F_G11F_B10F:
+		return gl.RGB, gl.FLOAT, true
+	case gl.RG16F, gl.RG32F:
+		return gl.RG, gl.FLOAT, true
+	case gl.R16F, gl.R32F:
+		return gl.RED, gl.FLOAT, true
+	case gl.DEPTH_COMPONENT16, gl.DEPTH_COMPONENT24, gl.DEPTH_COMPONENT32F:
+		return gl.DEPTH_COMPONENT, gl.FLOAT, true
+	case gl.DEPTH24_STENCIL8:
+		return gl.DEPTH_STENCIL, gl.UNSIGNED_INT_24_8, true
+	case gl.DEPTH32F_STENCIL8:
+		return gl.DEPTH_STENCIL, gl.FLOAT_32_UNSIGNED_INT_24_8_REV, true
+	}
+	return 0, 0, false
+}
+
+func depthAttachment(internalFormat uint32) uint32 {
+	switch internalFormat {
+	case gl.DEPTH24_STENCIL8, gl.DEPTH32F_STENCIL8:
+		return gl.DEPTH_STENCIL_ATTACHMENT
+	case gl.STENCIL_INDEX8:
+		return gl.STENCIL_ATTACHMENT
+	}
+	return gl.DEPTH_ATTACHMENT
+}
+
+// Name of a glCheckFramebufferStatus result, the spec explains what each one means.
+func framebufferStatusName(status uint32) string {
+	switch status {
+	case gl.FRAMEBUFFER_UNDEFINED:
+		return "GL_FRAMEBUFFER_UNDEFINED"
+	case gl.FRAMEBUFFER_INCOMPLETE_ATTACHMENT:
+		return "GL_FRAMEBUFFER_INCOMPLETE_ATTACHMENT (an attachment has no storage or a format that can't be rendered to)"
+	case gl.FRAMEBUFFER_INCOMPLETE_MISSING_ATTACHMENT:
+		return "GL_FRAMEBUFFER_INCOMPLETE_MISSING_ATTACHMENT (nothing is attached)"
+	case gl.FRAMEBUFFER_INCOMPLETE_DRAW_BUFFER:
+		return "GL_FRAMEBUFFER_INCOMPLETE_DRAW_BUFFER"
+	case gl.FRAMEBUFFER_INCOMPLETE_READ_BUFFER:
+		return "GL_FRAMEBUFFER_INCOMPLETE_READ_BUFFER"
+	case gl.FRAMEBUFFER_UNSUPPORTED:
+		return "GL_FRAMEBUFFER_UNSUPPORTED (this combination of formats is not supported by the driver)"
+	case gl.FRAMEBUFFER_INCOMPLETE_MULTISAMPLE:
+		return "GL_FRAMEBUFFER_INCOMPLETE_MULTISAMPLE"
+	case gl.FRAMEBUFFER_INCOMPLETE_LAYER_TARGETS:
+		return "GL_FRAMEBUFFER_INCOMPLETE_LAYER_TARGETS"
+	}
+	return fmt.Sprintf("status 0x%x", status)
+}
@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Read pixel (x, y) of colour attachment i as floats.
+func readAttachment(f *Framebuffer, i int, x, y int32) [4]float32 {
+	var pixel [4]float32
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, f.ID)
+	gl.ReadBuffer(gl.COLOR_ATTACHMENT0 + uint32(i))
+	gl.ReadPixels(x, y, 1, 1, gl.RGBA, gl.FLOAT, gl.Ptr(&pixel[0]))
+	gl.ReadBuffer(gl.COLOR_ATTACHMENT0)
+	return pixel
+}
+
+func TestFramebufferAttachments(t *testing.T) {
+	withGLContext(t)
+
+	f, err := NewFramebuffer(FramebufferOptions{
+		Width: 8, Height: 4,
+		Color: []uint32{gl.RGBA8, gl.RGBA16F},
+		Depth: gl.DEPTH24_STENCIL8,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Delete()
+	if len(f.Color) != 2 || f.Color[0] == 0 || f.Color[1] == 0 || f.Depth != 0 {
+		t.Fatalf("attachments: got colour %v and depth texture %d", f.Color, f.Depth)
+	}
+
+	f.Bind()
+	var viewport [4]int32
+	gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+	if viewport != [4]int32{0, 0, 8, 4} {
+		t.Errorf("viewport: got %v", viewport)
+	}
+
+	// every draw buffer is cleared on its own, the float attachment keeps values above 1
+	red, bright := [4]float32{1, 0, 0, 1}, [4]float32{2.5, 0.5, 0, 1}
+	gl.ClearBufferfv(gl.COLOR, 0, &red[0])
+	gl.ClearBufferfv(gl.COLOR, 1, &bright[0])
+	if got := readAttachment(f, 0, 3, 2); got != red {
+		t.Errorf("attachment 0: got %v, want %v", got, red)
+	}
+	if got := readAttachment(f, 1, 3, 2); got != bright {
+		t.Errorf("attachment 1: got %v, want %v", got, bright)
+	}
+
+	dst, err := NewFramebuffer(FramebufferOptions{Width: 4, Height: 2, Color: []uint32{gl.RGBA16F}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Delete()
+	f.BlitColorTo(1, dst, gl.NEAREST)
+	if got := readAttachment(dst, 0, 1, 1); got != bright {
+		t.Errorf("blitting attachment 1: got %v, want %v", got, bright)
+	}
+	f.BlitTo(dst, gl.COLOR_BUFFER_BIT, gl.NEAREST)
+	if got := readAttachment(dst, 0, 1, 1); got != red {
+		t.Errorf("blitting: got %v, want %v", got, red)
+	}
+	if glErr := gl.GetError(); glErr != gl.NO_ERROR {
+		t.Errorf("OpenGL error 0x%x", glErr)
+	}
+}
+
+func TestFramebufferDepthTexture(t *testing.T) {
+	withGLContext(t)
+
+	f, err := NewFramebuffer(FramebufferOptions{Width: 16, Height: 16, Depth: gl.DEPTH_COMPONENT24, DepthTexture: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Delete()
+	if f.Depth == 0 || len(f.Color) != 0 {
+		t.Fatalf("attachments: got colour %v and depth texture %d", f.Color, f.Depth)
+	}
+
+	f.Bind()
+	gl.ClearDepth(0.25)
+	gl.Clear(gl.DEPTH_BUFFER_BIT)
+	gl.ClearDepth(1)
+	var depth float32
+	gl.ReadPixels(5, 5, 1, 1, gl.DEPTH_COMPONENT, gl.FLOAT, gl.Ptr(&depth))
+	if depth < 0.249 || depth > 0.251 {
+		t.Errorf("depth: got %v, want 0.25", depth)
+	}
+}
+
+func TestFramebufferFollowsScreen(t *testing.T) {
+	withGLContext(t)
+	screen := *Screen
+	t.Cleanup(func() { *Screen = screen })
+
+	SetScreen(0, 16, 8)
+	f, err := NewFramebuffer(FramebufferOptions{Color: []uint32{gl.RGBA8}, Depth: gl.DEPTH24_STENCIL8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Delete()
+	if f.Width != 16 || f.Height != 8 {
+		t.Fatalf("size: got %dx%d, want 16x8", f.Width, f.Height)
+	}
+
+	texture := f.Color[0]
+	SetScreen(0, 32, 20)
+	f.Bind()
+	if f.Width != 32 || f.Height != 20 || f.Color[0] != texture {
+		t.Fatalf("after resizing the screen: got %dx%d with texture %d, want 32x20 with texture %d", f.Width, f.Height, f.Color[0], texture)
+	}
+	var width, height int32
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+	gl.GetTexLevelParameteriv(gl.TEXTURE_2D, 0, gl.TEXTURE_WIDTH, &width)
+	gl.GetTexLevelParameteriv(gl.TEXTURE_2D, 0, gl.TEXTURE_HEIGHT, &height)
+	if width != 32 || height != 20 {
+		t.Errorf("texture size: got %dx%d, want 32x20", width, height)
+	}
+
+	// a minimised window has no size, the framebuffer keeps the old one
+	SetScreen(0, 0, 0)
+	f.Bind()
+	if f.Width != 32 || f.Height != 20 {
+		t.Errorf("minimised: got %dx%d, want 32x20", f.Width, f.Height)
+	}
+}
+
+func TestFramebufferErrors(t *testing.T) {
+	withGLContext(t)
+
+	tests := []struct {
+		name string
+		opts FramebufferOptions
+		want string
+	}{
+		{"no attachments", FramebufferOptions{Width: 4, Height: 4}, "GL_FRAMEBUFFER_INCOMPLETE_MISSING_ATTACHMENT"},
+		{"unknown format", FramebufferOptions{Width: 4, Height: 4, Color: []uint32{gl.COMPRESSED_RGBA}}, "unsupported attachment format"},
+		{"too many attachments", FramebufferOptions{Width: 4, Height: 4, Color: make([]uint32, 64)}, "colour attachments"},
+	}
+	for _, tt := range tests {
+		f, err := NewFramebuffer(tt.opts)
+		if err == nil {
+			f.Delete()
+			t.Errorf("%s: no error", tt.name)
+		} else if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %q, want it to mention %q", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,617 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// The image based lighting shaders are small and fixed, they are kept here so IBL works from any directory.
+// Every cubemap pass draws the inside of a cube around the origin, one face at a time.
+const iblCubeVertexShader = `#version 330 core
+layout (location = 0) in vec3 aPos;
+
+out vec3 LocalPos;
+
+// projection * view of the face drawn to
+uniform mat4 transform;
+
+void main()
+{
+    LocalPos = aPos;
+    gl_Position = transform * vec4(aPos, 1.0);
+}
+`
+
+// Same mapping as sampleEquirectangular, the texture's rows are flipped so the top of the image is at t = 1.
+const equirectangularFragmentShader = `#version 330 core
+out vec4 FragColor;
+
+in vec3 LocalPos;
+
+uniform sampler2D equirectangularMap;
+
+const float PI = 3.14159265359;
+
+void main()
+{
+    vec3 v = normalize(LocalPos);
+    vec2 uv = vec2(atan(v.z, v.x) / (2.0 * PI) + 0.5, asin(v.y) / PI + 0.5);
+    FragColor = vec4(texture(equirectangularMap, uv).rgb, 1.0);
+}
+`
+
+// Integrates the light arriving at a surface facing LocalPos over the hemisphere around it, weighted by
+// the cosine of the angle. Divided by π like a Lambertian surface does, so the shader multiplies it by the albedo.
+const irradianceFragmentShader = `#version 330 core
+out vec4 FragColor;
+
+in vec3 LocalPos;
+
+uniform samplerCube environmentMap;
+// mip level of the environment sampled, the sum only needs its low frequencies
+uniform float sourceLod;
+
+const float PI = 3.14159265359;
+const float sampleDelta = 0.05;
+
+void main()
+{
+    vec3 N = normalize(LocalPos);
+    vec3 up = abs(N.y) < 0.999 ? vec3(0.0, 1.0, 0.0) : vec3(0.0, 0.0, 1.0);
+    vec3 right = normalize(cross(up, N));
+    up = cross(N, right);
+
+    vec3 irradiance = vec3(0.0);
+    float samples = 0.0;
+    for (float phi = 0.0; phi < 2.0 * PI; phi += sampleDelta) {
+        for (float theta = 0.0; theta < 0.5 * PI; theta += sampleDelta) {
+            vec3 tangentSample = vec3(sin(theta) * cos(phi), sin(theta) * sin(phi), cos(theta));
+            vec3 direction = tangentSample.x * right + tangentSample.y * up + tangentSample.z * N;
+            irradiance += textureLod(environmentMap, direction, sourceLod).rgb * cos(theta) * sin(theta);
+            samples++;
+        }
+    }
+    FragColor = vec4(PI * irradiance / samples, 1.0);
+}
+`
+
+// Quasi random directions around N, picked with the probability of the GGX distribution.
+const iblSamplingFunctions = `
+const float PI = 3.14159265359;
+const uint SAMPLE_COUNT = 512u;
+
+// the van der Corput sequence mirrored around the binary point
+float RadicalInverse(uint bits)
+{
+    bits = (bits << 16u) | (bits >> 16u);
+    bits = ((bits & 0x55555555u) << 1u) | ((bits & 0xAAAAAAAAu) >> 1u);
+    bits = ((bits & 0x33333333u) << 2u) | ((bits & 0xCCCCCCCCu) >> 2u);
+    bits = ((bits & 0x0F0F0F0Fu) << 4u) | ((bits & 0xF0F0F0F0u) >> 4u);
+    bits = ((bits & 0x00FF00FFu) << 8u) | ((bits & 0xFF00FF00u) >> 8u);
+    return float(bits) * 2.3283064365386963e-10;
+}
+
+vec2 Hammersley(uint i, uint n)
+{
+    return vec2(float(i) / float(n), RadicalInverse(i));
+}
+
+// a halfway vector around N, more of them close to N the smoother the surface
+vec3 ImportanceSampleGGX(vec2 Xi, vec3 N, float roughness)
+{
+    float a = roughness * roughness;
+    float phi = 2.0 * PI * Xi.x;
+    float cosTheta = sqrt((1.0 - Xi.y) / (1.0 + (a * a - 1.0) * Xi.y));
+    float sinTheta = sqrt(1.0 - cosTheta * cosTheta);
+    vec3 H = vec3(cos(phi) * sinTheta, sin(phi) * sinTheta, cosTheta);
+
+    vec3 up = abs(N.z) < 0.999 ? vec3(0.0, 0.0, 1.0) : vec3(1.0, 0.0, 0.0);
+    vec3 tangent = normalize(cross(up, N));
+    vec3 bitangent = cross(N, tangent);
+    return normalize(tangent * H.x + bitangent * H.y + N * H.z);
+}
+`
+
+// The environment reflected by a surface of roughness, assuming it is seen straight on (N = V = R).
+const prefilterFragmentShader = `#version 330 core
+out vec4 FragColor;
+
+in vec3 LocalPos;
+
+uniform samplerCube environmentMap;
+uniform float roughness;
+// size of a face of the environment
+uniform float resolution;
+` + iblSamplingFunctions + `
+float DistributionGGX(float NdotH, float roughness)
+{
+    float a = roughness * roughness;
+    float a2 = a * a;
+    float denom = NdotH * NdotH * (a2 - 1.0) + 1.0;
+    return a2 / (PI * denom * denom);
+}
+
+void main()
+{
+    vec3 N = normalize(LocalPos);
+    vec3 V = N;
+
+    vec3 color = vec3(0.0);
+    float totalWeight = 0.0;
+    for (uint i = 0u; i < SAMPLE_COUNT; i++) {
+        vec3 H = ImportanceSampleGGX(Hammersley(i, SAMPLE_COUNT), N, roughness);
+        vec3 L = normalize(2.0 * dot(V, H) * H - V);
+        float NdotL = dot(N, L);
+        if (NdotL > 0.0) {
+            // Where the samples are sparse read a blurrier level of the environment, covering the solid
+            // angle of the sample, so small bright spots don't turn into dots.
+            float NdotH = max(dot(N, H), 0.0);
+            float HdotV = max(dot(H, V), 0.0);
+            float pdf = DistributionGGX(NdotH, roughness) * NdotH / (4.0 * HdotV) + 0.0001;
+            float texelAngle = 4.0 * PI / (6.0 * resolution * resolution);
+            float sampleAngle = 1.0 / (float(SAMPLE_COUNT) * pdf + 0.0001);
+            float lod = roughness == 0.0 ? 0.0 : 0.5 * log2(sampleAngle / texelAngle);
+
+            color += textureLod(environmentMap, L, lod).rgb * NdotL;
+            totalWeight += NdotL;
+        }
+    }
+    FragColor = vec4(color / totalWeight, 1.0);
+}
+`
+
+// Scale (red) and bias (green) to F0 of the specular reflection, by n·v along x and roughness along y.
+const brdfFragmentShader = `#version 330 core
+out vec2 FragColor;
+
+in vec2 TexCoords;
+` + iblSamplingFunctions + `
+// k is remapped differently for image based lighting than for direct light
+float GeometrySchlickGGX(float cosTheta, float roughness)
+{
+    float k = (roughness * roughness) / 2.0;
+    return cosTheta / (cosTheta * (1.0 - k) + k);
+}
+
+void main()
+{
+    float NdotV = max(TexCoords.x, 1e-3);
+    float roughness = TexCoords.y;
+    vec3 V = vec3(sqrt(1.0 - NdotV * NdotV), 0.0, NdotV);
+    vec3 N = vec3(0.0, 0.0, 1.0);
+
+    float A = 0.0;
+    float B = 0.0;
+    for (uint i = 0u; i < SAMPLE_COUNT; i++) {
+        vec3 H = ImportanceSampleGGX(Hammersley(i, SAMPLE_COUNT), N, roughness);
+        vec3 L = normalize(2.0 * dot(V, H) * H - V);
+        float NdotL = max(L.z, 0.0);
+        float NdotH = max(H.z, 0.0);
+        float VdotH = max(dot(V, H), 0.0);
+        if (NdotL > 0.0) {
+            float G = GeometrySchlickGGX(NdotV, roughness) * GeometrySchlickGGX(NdotL, roughness);
+            float G_Vis = (G * VdotH) / (NdotH * NdotV);
+            float Fc = pow(1.0 - VdotH, 5.0);
+            A += (1.0 - Fc) * G_Vis;
+            B += Fc * G_Vis;
+        }
+    }
+    FragColor = vec2(A, B) / float(SAMPLE_COUNT);
+}
+`
+
+// Image based lighting: an environment image lights the scene from every direction, sampled by
+// shaders/PBR/common/ibl.glsl. The environment becomes a cubemap, its diffuse light is convolved into
+// an irradiance map and its reflections are prefiltered for increasing roughness down the mip chain of
+// another. A lookup table holds the part of the specular reflection that only depends on the BRDF,
+// the split sum approximation. All of it is computed on the GPU when the IBL is created.
+type IBL struct {
+	// The environment itself, mipmapped, for a Skybox.
+	Environment uint32
+	// Diffuse light reaching a surface facing each direction.
+	Irradiance uint32
+	// The environment reflected by surfaces of roughness 0 in level 0 up to roughness 1 in the last level.
+	Prefiltered       uint32
+	PrefilteredLevels int
+	// Scale and bias to the base reflectivity of the specular reflection.
+	BRDFLUT uint32
+}
+
+// Sizes of the IBL maps, 0 picks the default of DefaultIBLOptions.
+type IBLOptions struct {
+	// Faces of the environment cubemap.
+	EnvironmentSize int
+	// Faces of the irradiance map, it has no detail so it can be small.
+	IrradianceSize int
+	// Faces of the first level of the prefiltered map, the levels stop at 8x8.
+	PrefilteredSize int
+	// Width and height of the BRDF lookup table.
+	BRDFSize int
+	// Directory the irradiance, prefiltered and BRDF maps are cached in, so they are only computed
+	// once per environment image. Nothing is cached when empty.
+	CacheDir string
+}
+
+var DefaultIBLOptions = IBLOptions{EnvironmentSize: 512, IrradianceSize: 32, PrefilteredSize: 128, BRDFSize: 512}
+
+func (o IBLOptions) withDefaults() IBLOptions {
+	o.EnvironmentSize = int(orDefault(int32(o.EnvironmentSize), int32(DefaultIBLOptions.EnvironmentSize)))
+	o.IrradianceSize = int(orDefault(int32(o.IrradianceSize), int32(DefaultIBLOptions.IrradianceSize)))
+	o.PrefilteredSize = int(orDefault(int32(o.PrefilteredSize), int32(DefaultIBLOptions.PrefilteredSize)))
+	o.BRDFSize = int(orDefault(int32(o.BRDFSize), int32(DefaultIBLOptions.BRDFSize)))
+	return o
+}
+
+// Load a Radiance .hdr image in equirectangular projection and compute its IBL maps, see NewIBL.
+func LoadIBL(path string, opts IBLOptions) (*IBL, error) {
+	img, err := LoadImage(path)
+	if err != nil {
+		return nil, err
+	}
+	hdr, ok := img.(*HDRImage)
+	if !ok {
+		return nil, fmt.Errorf("%s: image based lighting needs a Radiance HDR image", path)
+	}
+	return NewIBL(hdr, opts)
+}
+
+// Compute the IBL maps of an equirectangular environment, or read them from opts.CacheDir when they
+// were computed before. Turns on seamless cubemap filtering, the blurry levels show the seams otherwise.
+// The framebuffer and viewport in use are left as they were.
+func NewIBL(equirectangular *HDRImage, opts IBLOptions) (*IBL, error) {
+	opts = opts.withDefaults()
+	passes, err := newIBLPasses()
+	if err != nil {
+		return nil, err
+	}
+	defer passes.delete()
+
+	gl.Enable(gl.TEXTURE_CUBE_MAP_SEAMLESS)
+	ibl := &IBL{PrefilteredLevels: prefilteredLevels(opts.PrefilteredSize)}
+
+	source := NewTextureFromImage(equirectangular, TextureOptions{WrapT: gl.CLAMP_TO_EDGE, NoMipmaps: true})
+	defer gl.DeleteTextures(1, &source)
+	environmentLevels := mipLevels(opts.EnvironmentSize)
+	ibl.Environment = newIBLCubemap(opts.EnvironmentSize, environmentLevels, nil)
+	passes.equirectangular.Use()
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, source)
+	if err := passes.renderCubemap(passes.equirectangular, ibl.Environment, opts.EnvironmentSize, 0); err != nil {
+		ibl.Delete()
+		return nil, err
+	}
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, ibl.Environment)
+	gl.GenerateMipmap(gl.TEXTURE_CUBE_MAP)
+
+	// the irradiance and prefiltered maps only depend on the environment, the lookup table only on its size
+	irradianceLength := cubemapLength(opts.IrradianceSize, 1)
+	mapsLength := irradianceLength + cubemapLength(opts.PrefilteredSize, ibl.PrefilteredLevels)
+	var mapsCache, brdfCache string
+	if opts.CacheDir != "" {
+		mapsCache = filepath.Join(opts.CacheDir, iblCacheKey(equirectangular, opts)+".ibl")
+		brdfCache = filepath.Join(opts.CacheDir, fmt.Sprintf("brdf-%d.lut", opts.BRDFSize))
+	}
+	// the maps work without the cache, failing to write it is only reported
+	var cacheErr error
+
+	if maps, err := readIBLCache(mapsCache, mapsLength); err == nil {
+		ibl.Irradiance = newIBLCubemap(opts.IrradianceSize, 1, maps[:irradianceLength])
+		ibl.Prefiltered = newIBLCubemap(opts.PrefilteredSize, ibl.PrefilteredLevels, maps[irradianceLength:])
+	} else {
+		if err := passes.convolve(ibl, opts, environmentLevels); err != nil {
+			ibl.Delete()
+			return nil, err
+		}
+		if mapsCache != "" {
+			maps := append(readIBLCubemap(ibl.Irradiance, opts.IrradianceSize, 1), readIBLCubemap(ibl.Prefiltered, opts.PrefilteredSize, ibl.PrefilteredLevels)...)
+			cacheErr = errors.Join(cacheErr, writeIBLCache(mapsCache, maps))
+		}
+	}
+
+	if lut, err := readIBLCache(brdfCache, opts.BRDFSize*opts.BRDFSize*2); err == nil {
+		ibl.BRDFLUT = newBRDFLUT(opts.BRDFSize, lut)
+	} else {
+		ibl.BRDFLUT = newBRDFLUT(opts.BRDFSize, nil)
+		if err := passes.renderBRDF(ibl.BRDFLUT, opts.BRDFSize); err != nil {
+			ibl.Delete()
+			return nil, err
+		}
+		if brdfCache != "" {
+			cacheErr = errors.Join(cacheErr, writeIBLCache(brdfCache, readBRDFLUT(ibl.BRDFLUT, opts.BRDFSize)))
+		}
+	}
+	if cacheErr != nil {
+		fmt.Println("image based lighting not cached:", cacheErr)
+	}
+	return ibl, nil
+}
+
+// Bind the irradiance map, the prefiltered map and the lookup table to texture unit, unit+1 and unit+2
+// and set the uniforms of ibl.glsl on program, which has to be in use, turning useIBL on.
+func (ibl *IBL) SetUniforms(program Program, unit uint32) {
+	gl.ActiveTexture(gl.TEXTURE0 + unit)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, ibl.Irradiance)
+	gl.ActiveTexture(gl.TEXTURE0 + unit + 1)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, ibl.Prefiltered)
+	gl.ActiveTexture(gl.TEXTURE0 + unit + 2)
+	gl.BindTexture(gl.TEXTURE_2D, ibl.BRDFLUT)
+	gl.ActiveTexture(gl.TEXTURE0)
+
+	setIBLSamplers(program, unit)
+	SetFloat(program, "prefilterMaxLod", float32(ibl.PrefilteredLevels-1))
+	SetBool(program, "useIBL", 1)
+}
+
+// Turn useIBL off on program, which has to be in use, so it falls back to its constant ambient light.
+// The IBL samplers still move to unit, unit+1 and unit+2: left on unit 0 the cubemaps would clash with
+// the 2D texture there and nothing would be drawn.
+func DisableIBL(program Program, unit uint32) {
+	setIBLSamplers(program, unit)
+	SetBool(program, "useIBL", 0)
+}
+
+func setIBLSamplers(program Program, unit uint32) {
+	SetInt(program, "irradianceMap", int32(unit))
+	SetInt(program, "prefilterMap", int32(unit+1))
+	SetInt(program, "brdfLUT", int32(unit+2))
+}
+
+// Release the cubemaps and the lookup table, the Environment included.
+func (ibl *IBL) Delete() {
+	textures := []uint32{ibl.Environment, ibl.Irradiance, ibl.Prefiltered, ibl.BRDFLUT}
+	gl.DeleteTextures(int32(len(textures)), &textures[0])
+}
+
+// Levels of a prefiltered map with size x size faces, halving down to 8x8 where the roughest reflections fit.
+func prefilteredLevels(size int) int {
+	return max(mipLevels(size)-3, 1)
+}
+
+// Number of mip levels of a size x size texture.
+func mipLevels(size int) int {
+	return int(math.Floor(math.Log2(float64(size)))) + 1
+}
+
+// Half floats in levels mip levels of the six RGB faces of a size x size cubemap.
+func cubemapLength(size, levels int) int {
+	n := 0
+	for level := range levels {
+		n += 6 * 3 * max(size>>level, 1) * max(size>>level, 1)
+	}
+	return n
+}
+
+// A half float cubemap with levels mip levels, filled from data (every face of every level in turn as
+// RGB half floats) or left empty when data is nil.
+func newIBLCubemap(size, levels int, data []uint16) uint32 {
+	var cubemap uint32
+	gl.GenTextures(1, &cubemap)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, cubemap)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	offset := 0
+	for level := range levels {
+		s := max(size>>level, 1)
+		for face := range 6 {
+			var pixels unsafe.Pointer
+			if data != nil {
+				pixels = gl.Ptr(&data[offset])
+				offset += s * s * 3
+			}
+			gl.TexImage2D(gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(face), int32(level), gl.RGBA16F, int32(s), int32(s), 0, gl.RGB, gl.HALF_FLOAT, pixels)
+		}
+	}
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+
+	minFilter := int32(gl.LINEAR)
+	if levels > 1 {
+		minFilter = gl.LINEAR_MIPMAP_LINEAR
+	}
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, minFilter)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAX_LEVEL, int32(levels-1))
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, 0)
+	return cubemap
+}
+
+// The contents of a cubemap made by newIBLCubemap, in the same order.
+func readIBLCubemap(cubemap uint32, size, levels int) []uint16 {
+	data := make([]uint16, cubemapLength(size, levels))
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, cubemap)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	offset := 0
+	for level := range levels {
+		s := max(size>>level, 1)
+		for face := range 6 {
+			gl.GetTexImage(gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(face), int32(level), gl.RGB, gl.HALF_FLOAT, gl.Ptr(&data[offset]))
+			offset += s * s * 3
+		}
+	}
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 4)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, 0)
+	return data
+}
+
+// An RG16F lookup table, filled from data or left empty when data is nil.
+func newBRDFLUT(size int, data []uint16) uint32 {
+	var pixels unsafe.Pointer
+	if data != nil {
+		pixels = gl.Ptr(data)
+	}
+	var lut uint32
+	gl.GenTextures(1, &lut)
+	gl.BindTexture(gl.TEXTURE_2D, lut)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RG16F, int32(size), int32(size), 0, gl.RG, gl.HALF_FLOAT, pixels)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return lut
+}
+
+func readBRDFLUT(lut uint32, size int) []uint16 {
+	data := make([]uint16, size*size*2)
+	gl.BindTexture(gl.TEXTURE_2D, lut)
+	gl.GetTexImage(gl.TEXTURE_2D, 0, gl.RG, gl.HALF_FLOAT, gl.Ptr(data))
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return data
+}
+
+// The programs and geometry computing the maps of an IBL, only needed while creating it.
+type iblPasses struct {
+	equirectangular, irradiance, prefilter, brdf *Shader
+	framebuffer                                  uint32
+	cubeVAO, cubeVBO, quadVAO, quadVBO           uint32
+
+	previousFramebuffer int32
+	previousViewport    [4]int32
+	depthTest, cullFace bool
+}
+
+func newIBLPasses() (*iblPasses, error) {
+	p := &iblPasses{}
+	sources := []struct {
+		shader           **Shader
+		vertex, fragment string
+	}{
+		{&p.equirectangular, iblCubeVertexShader, equirectangularFragmentShader},
+		{&p.irradiance, iblCubeVertexShader, irradianceFragmentShader},
+		{&p.prefilter, iblCubeVertexShader, prefilterFragmentShader},
+		{&p.brdf, postVertexShader, brdfFragmentShader},
+	}
+	for _, s := range sources {
+		program, err := BuildShaderProgram(s.vertex, s.fragment)
+		if err != nil {
+			p.delete()
+			return nil, err
+		}
+		*s.shader = NewShaderObject(program)
+	}
+
+	gl.GenVertexArrays(1, &p.cubeVAO)
+	gl.GenBuffers(1, &p.cubeVBO)
+	gl.BindVertexArray(p.cubeVAO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, p.cubeVBO)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*len(skyboxVertices), gl.Ptr(skyboxVertices), gl.STATIC_DRAW)
+	gl.VertexAttribPointerWithOffset(0, 3, gl.FLOAT, false, 3*4, 0)
+	gl.EnableVertexAttribArray(0)
+
+	gl.GenVertexArrays(1, &p.quadVAO)
+	gl.GenBuffers(1, &p.quadVBO)
+	gl.BindVertexArray(p.quadVAO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, p.quadVBO)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*len(postQuadVertices), gl.Ptr(postQuadVertices), gl.STATIC_DRAW)
+	gl.VertexAttribPointerWithOffset(0, 2, gl.FLOAT, false, 4*4, 0)
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointerWithOffset(1, 2, gl.FLOAT, false, 4*4, 2*4)
+	gl.EnableVertexAttribArray(1)
+	gl.BindVertexArray(0)
+
+	// every pass covers its whole target, the cube is seen from inside
+	gl.GetIntegerv(gl.DRAW_FRAMEBUFFER_BINDING, &p.previousFramebuffer)
+	gl.GetIntegerv(gl.VIEWPORT, &p.previousViewport[0])
+	p.depthTest, p.cullFace = gl.IsEnabled(gl.DEPTH_TEST), gl.IsEnabled(gl.CULL_FACE)
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Disable(gl.CULL_FACE)
+	gl.GenFramebuffers(1, &p.framebuffer)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, p.framebuffer)
+	return p, nil
+}
+
+// Draw shader into level of every face of cubemap, whose first level is size x size.
+func (p *iblPasses) renderCubemap(shader *Shader, cubemap uint32, size, level int) error {
+	s := int32(max(size>>level, 1))
+	gl.Viewport(0, 0, s, s)
+	shader.Use()
+	gl.BindVertexArray(p.cubeVAO)
+	for face, transform := range CubemapFaceTransforms(mgl32.Vec3{}, 0.1, 10) {
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(face), cubemap, int32(level))
+		if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+			return fmt.Errorf("IBL cubemap level %d is not complete: %s", level, framebufferStatusName(status))
+		}
+		shader.SetMat4("transform", &transform)
+		gl.DrawArrays(gl.TRIANGLES, 0, 36)
+	}
+	gl.BindVertexArray(0)
+	return nil
+}
+
+// Compute the irradiance and prefiltered maps of ibl.Environment, which has environmentLevels levels.
+func (p *iblPasses) convolve(ibl *IBL, opts IBLOptions, environmentLevels int) error {
+	ibl.Irradiance = newIBLCubemap(opts.IrradianceSize, 1, nil)
+	ibl.Prefiltered = newIBLCubemap(opts.PrefilteredSize, ibl.PrefilteredLevels, nil)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, ibl.Environment)
+
+	// a 32x32 version of the environment holds all the detail the irradiance needs
+	p.irradiance.Use()
+	p.irradiance.SetInt("environmentMap", 0)
+	p.irradiance.SetFloat("sourceLod", float32(min(max(mipLevels(opts.EnvironmentSize)-6, 0), environmentLevels-1)))
+	if err := p.renderCubemap(p.irradiance, ibl.Irradiance, opts.IrradianceSize, 0); err != nil {
+		return err
+	}
+
+	p.prefilter.Use()
+	p.prefilter.SetInt("environmentMap", 0)
+	p.prefilter.SetFloat("resolution", float32(opts.EnvironmentSize))
+	for level := range ibl.PrefilteredLevels {
+		roughness := float32(0)
+		if ibl.PrefilteredLevels > 1 {
+			roughness = float32(level) / float32(ibl.PrefilteredLevels-1)
+		}
+		p.prefilter.SetFloat("roughness", roughness)
+		if err := p.renderCubemap(p.prefilter, ibl.Prefiltered, opts.PrefilteredSize, level); err != nil {
+			return err
+		}
+	}
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, 0)
+	return nil
+}
+
+func (p *iblPasses) renderBRDF(lut uint32, size int) error {
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, lut, 0)
+	if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+		return fmt.Errorf("BRDF lookup table is not complete: %s", framebufferStatusName(status))
+	}
+	gl.Viewport(0, 0, int32(size), int32(size))
+	p.brdf.Use()
+	gl.BindVertexArray(p.quadVAO)
+	gl.DrawArrays(gl.TRIANGLES, 0, 6)
+	gl.BindVertexArray(0)
+	return nil
+}
+
+// Release everything and put back the framebuffer, viewport and state newIBLPasses changed.
+func (p *iblPasses) delete() {
+	for _, s := range []*Shader{p.equirectangular, p.irradiance, p.prefilter, p.brdf} {
+		if s != nil {
+			gl.DeleteProgram(s.ID)
+		}
+	}
+	if p.framebuffer == 0 {
+		return
+	}
+	gl.DeleteVertexArrays(1, &p.cubeVAO)
+	gl.DeleteBuffers(1, &p.cubeVBO)
+	gl.DeleteVertexArrays(1, &p.quadVAO)
+	gl.DeleteBuffers(1, &p.quadVBO)
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(p.previousFramebuffer))
+	gl.DeleteFramebuffers(1, &p.framebuffer)
+	gl.Viewport(p.previousViewport[0], p.previousViewport[1], p.previousViewport[2], p.previousViewport[3])
+	if p.depthTest {
+		gl.Enable(gl.DEPTH_TEST)
+	}
+	if p.cullFace {
+		gl.Enable(gl.CULL_FACE)
+	}
+}
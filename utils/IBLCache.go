@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// IBL cache files start with this, followed by the texels as little endian half floats.
+// Bump the version when the maps are computed differently so old caches are not used.
+const iblCacheMagic = "OPGLIBL1"
+
+// Name of the cached maps of an environment, a hash of its pixels and the sizes of the maps.
+func iblCacheKey(img *HDRImage, opts IBLOptions) string {
+	h := sha256.New()
+	size := img.Rect.Size()
+	binary.Write(h, binary.LittleEndian, []int64{int64(size.X), int64(size.Y), int64(opts.EnvironmentSize), int64(opts.IrradianceSize), int64(opts.PrefilteredSize)})
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		row := img.Pix[(y-img.Rect.Min.Y)*img.Stride:][:size.X*3]
+		binary.Write(h, binary.LittleEndian, row)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// Read length half floats from a cache file written by writeIBLCache. Fails when path is empty,
+// the file doesn't exist or doesn't hold exactly that many.
+func readIBLCache(path string, length int) ([]uint16, error) {
+	if path == "" {
+		return nil, fmt.Errorf("no IBL cache")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	magic := make([]byte, len(iblCacheMagic))
+	if _, err := io.ReadFull(f, magic); err != nil || string(magic) != iblCacheMagic {
+		return nil, fmt.Errorf("%s: not an IBL cache", path)
+	}
+	data := make([]uint16, length)
+	if err := binary.Read(f, binary.LittleEndian, data); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if n, _ := f.Read(make([]byte, 1)); n != 0 {
+		return nil, fmt.Errorf("%s: more data than expected", path)
+	}
+	return data, nil
+}
+
+// Write data to a cache file. It is written next to path and renamed, so a half written file is never read.
+func writeIBLCache(path string, data []uint16) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(iblCacheMagic)
+	if err == nil {
+		err = binary.Write(f, binary.LittleEndian, data)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("writing IBL cache %s: %w", path, err)
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,236 @@
+package utils
+
+import (
+	"image"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"opgl-learn/headless"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// An equirectangular image whose colour says which way each pixel looks: red for +X, green for +Y, blue for +Z.
+func directionImage(width, height int) *HDRImage {
+	img := NewHDRImage(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			// the inverse of sampleEquirectangular
+			longitude := ((float64(x)+0.5)/float64(width) - 0.5) * 2 * math.Pi
+			latitude := (0.5 - (float64(y)+0.5)/float64(height)) * math.Pi
+			dx, dy, dz := math.Cos(latitude)*math.Cos(longitude), math.Sin(latitude), math.Cos(latitude)*math.Sin(longitude)
+			var c [3]float32
+			for i, d := range []float64{dx, dy, dz} {
+				if d > 0 {
+					c[i] = 1
+				}
+			}
+			img.SetRGB(x, y, c)
+		}
+	}
+	return img
+}
+
+func uniformImage(c [3]float32) *HDRImage {
+	img := NewHDRImage(image.Rect(0, 0, 16, 8))
+	for y := range 8 {
+		for x := range 16 {
+			img.SetRGB(x, y, c)
+		}
+	}
+	return img
+}
+
+// Texel (x, y) of face at level of a cubemap, as RGB floats.
+func cubemapTexel(cubemap uint32, face, level, size, x, y int) [3]float32 {
+	s := max(size>>level, 1)
+	texels := make([]float32, s*s*3)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, cubemap)
+	gl.GetTexImage(gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(face), int32(level), gl.RGB, gl.FLOAT, gl.Ptr(texels))
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, 0)
+	i := (y*s + x) * 3
+	return [3]float32{texels[i], texels[i+1], texels[i+2]}
+}
+
+var smallIBL = IBLOptions{EnvironmentSize: 32, IrradianceSize: 8, PrefilteredSize: 16, BRDFSize: 16}
+
+func TestIBLEnvironment(t *testing.T) {
+	withGLContext(t)
+
+	ibl, err := NewIBL(directionImage(128, 64), smallIBL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ibl.Delete()
+
+	// a quarter of the way into each face, away from where the colours change
+	for face := range 6 {
+		x, y := 8, 8
+		dx, dy, dz := CubemapDirection(face, (float64(x)+0.5)/32, (float64(y)+0.5)/32)
+		var want [3]float32
+		for i, d := range []float64{dx, dy, dz} {
+			if d > 0 {
+				want[i] = 1
+			}
+		}
+		if got := cubemapTexel(ibl.Environment, face, 0, 32, x, y); got != want {
+			t.Errorf("face %d: got %v, want %v", face, got, want)
+		}
+	}
+	if glErr := gl.GetError(); glErr != gl.NO_ERROR {
+		t.Errorf("OpenGL error 0x%x", glErr)
+	}
+}
+
+func TestIBLUniformEnvironment(t *testing.T) {
+	withGLContext(t)
+
+	// light of the same colour from everywhere: every surface receives that colour, however rough
+	color := [3]float32{1, 0.5, 0.25}
+	ibl, err := NewIBL(uniformImage(color), smallIBL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ibl.Delete()
+
+	near := func(got [3]float32) bool {
+		for i := range got {
+			if math.Abs(float64(got[i]-color[i])) > 0.03*float64(color[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	for face := range 6 {
+		if got := cubemapTexel(ibl.Irradiance, face, 0, 8, 3, 4); !near(got) {
+			t.Errorf("irradiance of face %d: got %v, want %v", face, got, color)
+		}
+		for level := range ibl.PrefilteredLevels {
+			if got := cubemapTexel(ibl.Prefiltered, face, level, 16, 0, 0); !near(got) {
+				t.Errorf("prefiltered level %d of face %d: got %v, want %v", level, face, got, color)
+			}
+		}
+	}
+
+	// seen straight on a smooth surface reflects all of F0, scale 1 and no bias
+	lut := make([]float32, 16*16*2)
+	gl.BindTexture(gl.TEXTURE_2D, ibl.BRDFLUT)
+	gl.GetTexImage(gl.TEXTURE_2D, 0, gl.RG, gl.FLOAT, gl.Ptr(lut))
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	if scale, bias := lut[15*2], lut[15*2+1]; math.Abs(float64(scale-1)) > 0.05 || bias > 0.05 {
+		t.Errorf("BRDF at n·v = 1, roughness 0: scale %v, bias %v", scale, bias)
+	}
+	for i, v := range lut {
+		if v < 0 || v > 1 {
+			t.Fatalf("BRDF value %d out of range: %v", i, v)
+		}
+	}
+}
+
+func TestIBLCache(t *testing.T) {
+	withGLContext(t)
+
+	opts := smallIBL
+	opts.CacheDir = t.TempDir()
+	img := uniformImage([3]float32{0.5, 0.5, 0.5})
+	ibl, err := NewIBL(img, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ibl.Delete()
+
+	maps := filepath.Join(opts.CacheDir, iblCacheKey(img, opts.withDefaults())+".ibl")
+	if _, err := os.Stat(filepath.Join(opts.CacheDir, "brdf-16.lut")); err != nil {
+		t.Fatal(err)
+	}
+	// replace the cached maps with 2s (0x4000 as a half float), the next IBL has to show them
+	length := cubemapLength(8, 1) + cubemapLength(16, ibl.PrefilteredLevels)
+	twos := make([]uint16, length)
+	for i := range twos {
+		twos[i] = 0x4000
+	}
+	if err := writeIBLCache(maps, twos); err != nil {
+		t.Fatal(err)
+	}
+
+	cached, err := NewIBL(img, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cached.Delete()
+	if got := cubemapTexel(cached.Irradiance, FaceTop, 0, 8, 0, 0); got != [3]float32{2, 2, 2} {
+		t.Errorf("irradiance: got %v, want the cached 2s", got)
+	}
+	if got := cubemapTexel(cached.Prefiltered, FaceBack, cached.PrefilteredLevels-1, 16, 1, 1); got != [3]float32{2, 2, 2} {
+		t.Errorf("prefiltered: got %v, want the cached 2s", got)
+	}
+
+	// a cache of the wrong size is ignored
+	if err := writeIBLCache(maps, twos[:10]); err != nil {
+		t.Fatal(err)
+	}
+	recomputed, err := NewIBL(img, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recomputed.Delete()
+	if got := cubemapTexel(recomputed.Irradiance, FaceTop, 0, 8, 0, 0); math.Abs(float64(got[0]-0.5)) > 0.02 {
+		t.Errorf("irradiance after a broken cache: got %v, want 0.5", got)
+	}
+}
+
+func TestIBLShading(t *testing.T) {
+	withGLContext(t)
+
+	shader, err := LoadShaderWithDefines("../shaders/PBR/1-LightingVert.glsl", "../shaders/PBR/1-LightingFrag.glsl", Defines{"NR_LIGHTS": "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gl.DeleteProgram(shader)
+
+	target, err := headless.NewTarget(64, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Delete()
+
+	color := [3]float32{0.8, 0.4, 0.2}
+	ibl, err := NewIBL(uniformImage(color), smallIBL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ibl.Delete()
+
+	// Under light of the same colour from everywhere a white mirror and a white rough dielectric both
+	// show that colour, one all reflection and the other nearly all diffuse.
+	mirror := PBRMaterial{Albedo: mgl32.Vec3{1, 1, 1}, Metallic: 1, Roughness: 0, AO: 1}
+	rough := PBRMaterial{Albedo: mgl32.Vec3{1, 1, 1}, Metallic: 0, Roughness: 1, AO: 1}
+	left := Model{Meshes: []Mesh{quadMesh(-1, 0)}, PBR: &mirror}
+	right := Model{Meshes: []Mesh{quadMesh(0, 1)}, PBR: &rough}
+
+	camera := NewUniformBuffer(CameraBinding)
+	defer camera.Delete()
+	camera.Update(&CameraUniforms{Projection: mgl32.Ortho(-1, 1, -1, 1, 0.1, 10), View: mgl32.Ident4()})
+
+	gl.UseProgram(shader)
+	identity := mgl32.Ident4()
+	SetMat4(shader, "model", &identity)
+	ibl.SetUniforms(shader, 4)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+	left.Draw(shader)
+	right.Draw(shader)
+
+	img := target.ReadImage()
+	for _, x := range []int{16, 48} {
+		got := img.NRGBAAt(x, 16)
+		for i, v := range []uint8{got.R, got.G, got.B} {
+			if want := 255 * color[i]; math.Abs(float64(v)-float64(want)) > 0.05*255 {
+				t.Errorf("pixel at x %d: got %v, want %v", x, got, color)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/bmp"
+)
+
+// Image file formats LoadImage understands.
+type ImageFormat string
+
+const (
+	FormatPNG  ImageFormat = "png"
+	FormatJPEG ImageFormat = "jpeg"
+	FormatGIF  ImageFormat = "gif"
+	FormatBMP  ImageFormat = "bmp"
+	FormatTGA  ImageFormat = "tga"
+	FormatHDR  ImageFormat = "hdr"
+)
+
+// Open and decode an image file. The format comes from the magic bytes at the start of the file,
+// except for TGA which has none and is recognised by its extension.
+// Radiance .hdr files decode to an *HDRImage, everything else to one of the standard image types.
+func LoadImage(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open image: %w", err)
+	}
+	defer file.Close()
+
+	img, _, err := DecodeImage(file, path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return img, nil
+}
+
+// Decode an image from r, name is only used for formats without magic bytes.
+func DecodeImage(r io.Reader, name string) (image.Image, ImageFormat, error) {
+	br := bufio.NewReader(r)
+	// errors here are left for the decoder, short files just won't match anything
+	header, _ := br.Peek(16)
+
+	format, ok := DetectImageFormat(header, name)
+	if !ok {
+		return nil, "", fmt.Errorf("unknown image format")
+	}
+
+	var img image.Image
+	var err error
+	switch format {
+	case FormatPNG:
+		img, err = png.Decode(br)
+	case FormatJPEG:
+		img, err = jpeg.Decode(br)
+	case FormatGIF:
+		img, err = gif.Decode(br) // first frame only
+	case FormatBMP:
+		img, err = bmp.Decode(br)
+	case FormatTGA:
+		img, err = decodeTGA(br)
+	case FormatHDR:
+		img, err = decodeHDR(br)
+	}
+	if err != nil {
+		return nil, format, fmt.Errorf("decoding %s: %w", format, err)
+	}
+	return img, format, nil
+}
+
+// Work out the format from the first bytes of a file, falling back to the extension of name.
+func DetectImageFormat(header []byte, name string) (ImageFormat, bool) {
+	switch {
+	case bytes.HasPrefix(header, []byte("\x89PNG\r\n\x1a\n")):
+		return FormatPNG, true
+	case bytes.HasPrefix(header, []byte{0xFF, 0xD8, 0xFF}):
+		return FormatJPEG, true
+	case bytes.HasPrefix(header, []byte("GIF87a")), bytes.HasPrefix(header, []byte("GIF89a")):
+		return FormatGIF, true
+	case bytes.HasPrefix(header, []byte("BM")):
+		return FormatBMP, true
+	case bytes.HasPrefix(header, []byte("#?RADIANCE")), bytes.HasPrefix(header, []byte("#?RGBE")):
+		return FormatHDR, true
+	}
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".tga", ".targa":
+		return FormatTGA, true
+	case ".hdr":
+		return FormatHDR, true
+	}
+	return "", false
+}
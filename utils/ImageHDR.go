@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"strings"
+)
+
+// A floating point RGB image, what Radiance .hdr files decode to.
+// Pixel (x, y) starts at Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X)*3].
+type HDRImage struct {
+	Pix    []float32
+	Stride int
+	Rect   image.Rectangle
+}
+
+func NewHDRImage(r image.Rectangle) *HDRImage {
+	return &HDRImage{Pix: make([]float32, r.Dx()*r.Dy()*3), Stride: r.Dx() * 3, Rect: r}
+}
+
+func (p *HDRImage) ColorModel() color.Model { return color.RGBA64Model }
+
+func (p *HDRImage) Bounds() image.Rectangle { return p.Rect }
+
+// The linear colour at (x, y) clamped to [0, 1], use RGBAt for the real values.
+func (p *HDRImage) At(x, y int) color.Color {
+	c := p.RGBAt(x, y)
+	to16 := func(v float32) uint16 { return uint16(math.Round(float64(min(max(v, 0), 1)) * 0xFFFF)) }
+	return color.RGBA64{to16(c[0]), to16(c[1]), to16(c[2]), 0xFFFF}
+}
+
+func (p *HDRImage) RGBAt(x, y int) [3]float32 {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return [3]float32{}
+	}
+	i := (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*3
+	return [3]float32{p.Pix[i], p.Pix[i+1], p.Pix[i+2]}
+}
+
+func (p *HDRImage) SetRGB(x, y int, c [3]float32) {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return
+	}
+	i := (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*3
+	copy(p.Pix[i:i+3], c[:])
+}
+
+// Decode a Radiance RGBE image, flat or run length encoded (both the old and the adaptive scheme).
+func decodeHDR(r io.Reader) (*HDRImage, error) {
+	br := bufio.NewReader(r)
+
+	magic, err := br.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(magic, "#?") {
+		return nil, errors.New("missing Radiance header")
+	}
+	// header variables end with an empty line
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if format, ok := strings.CutPrefix(line, "FORMAT="); ok && format != "32-bit_rle_rgbe" {
+			return nil, fmt.Errorf("unsupported Radiance format %q", format)
+		}
+	}
+
+	resolution, err := br.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	var yDir, xDir string
+	var width, height int
+	if _, err := fmt.Sscanf(resolution, "%2s %d %2s %d", &yDir, &height, &xDir, &width); err != nil {
+		return nil, fmt.Errorf("bad resolution line %q", strings.TrimSpace(resolution))
+	}
+	if (yDir != "-Y" && yDir != "+Y") || xDir != "+X" || width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("unsupported resolution line %q", strings.TrimSpace(resolution))
+	}
+
+	img := NewHDRImage(image.Rect(0, 0, width, height))
+	scanline := make([]byte, width*4)
+	for row := 0; row < height; row++ {
+		if err := readHDRScanline(br, scanline); err != nil {
+			return nil, err
+		}
+		// +Y means the first scanline is the bottom of the image
+		y := row
+		if yDir == "+Y" {
+			y = height - 1 - row
+		}
+		for x := 0; x < width; x++ {
+			img.SetRGB(x, y, rgbeToFloat(scanline[x*4:x*4+4]))
+		}
+	}
+	return img, nil
+}
+
+// Read one scanline of RGBE pixels into dst.
+func readHDRScanline(br *bufio.Reader, dst []byte) error {
+	width := len(dst) / 4
+	start, err := br.Peek(4)
+	if err != nil {
+		return err
+	}
+	// adaptive RLE: 2, 2, then the width, then every channel encoded on its own
+	if width < 8 || width > 0x7FFF || start[0] != 2 || start[1] != 2 || start[2]&0x80 != 0 {
+		return readFlatHDRScanline(br, dst)
+	}
+	if int(start[2])<<8|int(start[3]) != width {
+		return errors.New("Radiance scanline width mismatch")
+	}
+	br.Discard(4)
+
+	var packet [2]byte
+	for channel := 0; channel < 4; channel++ {
+		for x := 0; x < width; {
+			if _, err := io.ReadFull(br, packet[:1]); err != nil {
+				return err
+			}
+			count := int(packet[0])
+			if count > 128 {
+				// a run of the next byte
+				count -= 128
+				if x+count > width {
+					return errors.New("Radiance run goes past the end of the scanline")
+				}
+				if _, err := io.ReadFull(br, packet[1:]); err != nil {
+					return err
+				}
+				for ; count > 0; count-- {
+					dst[x*4+channel] = packet[1]
+					x++
+				}
+			} else {
+				if count == 0 || x+count > width {
+					return errors.New("bad Radiance literal run")
+				}
+				for ; count > 0; count-- {
+					b, err := br.ReadByte()
+					if err != nil {
+						return err
+					}
+					dst[x*4+channel] = b
+					x++
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Uncompressed pixels, with the old style runs where a 1, 1, 1, n pixel repeats the previous one.
+func readFlatHDRScanline(br *bufio.Reader, dst []byte) error {
+	width := len(dst) / 4
+	shift := 0
+	for x := 0; x < width; {
+		px := dst[x*4 : x*4+4]
+		if _, err := io.ReadFull(br, px); err != nil {
+			return err
+		}
+		if px[0] == 1 && px[1] == 1 && px[2] == 1 {
+			if x == 0 {
+				return errors.New("Radiance run without a previous pixel")
+			}
+			count := int(px[3]) << shift
+			if x+count > width {
+				return errors.New("Radiance run goes past the end of the scanline")
+			}
+			for ; count > 0; count-- {
+				copy(dst[x*4:x*4+4], dst[(x-1)*4:x*4])
+				x++
+			}
+			shift += 8
+			continue
+		}
+		shift = 0
+		x++
+	}
+	return nil
+}
+
+func rgbeToFloat(rgbe []byte) [3]float32 {
+	if rgbe[3] == 0 {
+		return [3]float32{}
+	}
+	f := float32(math.Ldexp(1, int(rgbe[3])-(128+8)))
+	return [3]float32{float32(rgbe[0]) * f, float32(rgbe[1]) * f, float32(rgbe[2]) * f}
+}
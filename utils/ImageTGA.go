@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+)
+
+// TGA image types, the RLE variants are the plain ones + 8.
+const (
+	tgaColorMapped = 1
+	tgaTrueColor   = 2
+	tgaGrayscale   = 3
+	tgaRLE         = 8
+)
+
+// Decode a Truevision TGA image: colour mapped, true colour or grayscale, raw or run length encoded,
+// with 8, 15/16, 24 or 32 bits per pixel.
+func decodeTGA(r io.Reader) (*image.NRGBA, error) {
+	var header [18]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	idLength := int(header[0])
+	hasColorMap := header[1] == 1
+	imageType := int(header[2])
+	mapFirst := int(binary.LittleEndian.Uint16(header[3:]))
+	mapLength := int(binary.LittleEndian.Uint16(header[5:]))
+	mapDepth := int(header[7])
+	width := int(binary.LittleEndian.Uint16(header[12:]))
+	height := int(binary.LittleEndian.Uint16(header[14:]))
+	depth := int(header[16])
+	descriptor := header[17]
+
+	rle := imageType&tgaRLE != 0
+	baseType := imageType &^ tgaRLE
+	if baseType != tgaColorMapped && baseType != tgaTrueColor && baseType != tgaGrayscale {
+		return nil, fmt.Errorf("unsupported TGA image type %d", imageType)
+	}
+	if width == 0 || height == 0 {
+		return nil, errors.New("empty TGA image")
+	}
+
+	if _, err := io.CopyN(io.Discard, r, int64(idLength)); err != nil {
+		return nil, err
+	}
+
+	// colour map entries are stored like true colour pixels
+	var palette [][4]byte
+	if hasColorMap {
+		entrySize := (mapDepth + 7) / 8
+		raw := make([]byte, mapLength*entrySize)
+		if _, err := io.ReadFull(r, raw); err != nil {
+			return nil, err
+		}
+		palette = make([][4]byte, mapLength)
+		for i := range palette {
+			c, err := tgaColor(raw[i*entrySize:(i+1)*entrySize], mapDepth, false)
+			if err != nil {
+				return nil, err
+			}
+			palette[i] = c
+		}
+	}
+	if baseType == tgaColorMapped && (palette == nil || depth != 8 && depth != 16) {
+		return nil, errors.New("bad TGA colour map")
+	}
+
+	pixelSize := (depth + 7) / 8
+	data := make([]byte, width*height*pixelSize)
+	if rle {
+		if err := readTGARLE(r, data, pixelSize); err != nil {
+			return nil, err
+		}
+	} else if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	topToBottom := descriptor&0x20 != 0
+	rightToLeft := descriptor&0x10 != 0
+	for i := 0; i < width*height; i++ {
+		px := data[i*pixelSize : (i+1)*pixelSize]
+
+		var c [4]byte
+		switch baseType {
+		case tgaColorMapped:
+			index := int(px[0])
+			if pixelSize == 2 {
+				index = int(binary.LittleEndian.Uint16(px))
+			}
+			index -= mapFirst
+			if index < 0 || index >= len(palette) {
+				return nil, fmt.Errorf("TGA colour map index %d out of range", index+mapFirst)
+			}
+			c = palette[index]
+		default:
+			var err error
+			if c, err = tgaColor(px, depth, baseType == tgaGrayscale); err != nil {
+				return nil, err
+			}
+		}
+
+		// rows are stored bottom to top unless the descriptor says otherwise
+		x, y := i%width, i/width
+		if rightToLeft {
+			x = width - 1 - x
+		}
+		if !topToBottom {
+			y = height - 1 - y
+		}
+		copy(img.Pix[y*img.Stride+x*4:], c[:])
+	}
+	return img, nil
+}
+
+// Expand run length encoded packets into data.
+func readTGARLE(r io.Reader, data []byte, pixelSize int) error {
+	var packet [1]byte
+	pixel := make([]byte, pixelSize)
+	for n := 0; n < len(data); {
+		if _, err := io.ReadFull(r, packet[:]); err != nil {
+			return err
+		}
+		count := int(packet[0]&0x7F) + 1
+		if n+count*pixelSize > len(data) {
+			return errors.New("TGA run goes past the end of the image")
+		}
+
+		if packet[0]&0x80 != 0 {
+			// one pixel repeated count times
+			if _, err := io.ReadFull(r, pixel); err != nil {
+				return err
+			}
+			for i := 0; i < count; i++ {
+				n += copy(data[n:], pixel)
+			}
+		} else {
+			if _, err := io.ReadFull(r, data[n:n+count*pixelSize]); err != nil {
+				return err
+			}
+			n += count * pixelSize
+		}
+	}
+	return nil
+}
+
+// Convert one stored pixel to non premultiplied RGBA.
+func tgaColor(px []byte, depth int, gray bool) ([4]byte, error) {
+	switch {
+	case gray && depth == 8:
+		return [4]byte{px[0], px[0], px[0], 255}, nil
+	case gray && depth == 16:
+		return [4]byte{px[0], px[0], px[0], px[1]}, nil
+	case depth == 15 || depth == 16:
+		// A1R5G5B5, the alpha bit is ignored as plenty of writers leave it at 0
+		v := binary.LittleEndian.Uint16(px)
+		expand := func(c uint16) byte { return byte(c<<3 | c>>2) }
+		return [4]byte{expand(v >> 10 & 0x1F), expand(v >> 5 & 0x1F), expand(v & 0x1F), 255}, nil
+	case depth == 24:
+		return [4]byte{px[2], px[1], px[0], 255}, nil
+	case depth == 32:
+		return [4]byte{px[2], px[1], px[0], px[3]}, nil
+	}
+	return [4]byte{}, fmt.Errorf("unsupported TGA pixel depth %d", depth)
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"golang.org/x/image/bmp"
+)
+
+func TestDecodeImageFormats(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	src.Set(1, 0, color.NRGBA{255, 0, 0, 255})
+
+	encoders := map[ImageFormat]func(*bytes.Buffer) error{
+		FormatPNG:  func(b *bytes.Buffer) error { return png.Encode(b, src) },
+		FormatJPEG: func(b *bytes.Buffer) error { return jpeg.Encode(b, src, nil) },
+		FormatGIF:  func(b *bytes.Buffer) error { return gif.Encode(b, src, nil) },
+		FormatBMP:  func(b *bytes.Buffer) error { return bmp.Encode(b, src) },
+	}
+	for want, encode := range encoders {
+		var buf bytes.Buffer
+		if err := encode(&buf); err != nil {
+			t.Fatal(err)
+		}
+		// the extension is deliberately wrong, only the contents should matter
+		img, format, err := DecodeImage(&buf, "texture.tga")
+		if err != nil || format != want {
+			t.Errorf("%s: got format %q, err %v", want, format, err)
+			continue
+		}
+		if img.Bounds() != src.Bounds() {
+			t.Errorf("%s: got bounds %v", want, img.Bounds())
+		}
+	}
+
+	if _, _, err := DecodeImage(bytes.NewReader([]byte("not an image")), "texture.dds"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestDecodeTGA(t *testing.T) {
+	header := func(imageType, depth, descriptor byte) []byte {
+		return []byte{0, 0, imageType, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 0, 2, 0, depth, descriptor}
+	}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		// 24 bit BGR, stored bottom row first
+		{"raw", append(header(2, 24, 0),
+			0, 0, 255, 0, 255, 0,
+			255, 0, 0, 255, 255, 255)},
+		// 32 bit BGRA, top row first, a run of two blue pixels then two literal pixels
+		{"rle", append(header(10, 32, 0x28),
+			0x81, 255, 0, 0, 255,
+			0x01, 0, 0, 255, 255, 0, 255, 0, 255)},
+	}
+	want := map[string][4]color.NRGBA{
+		"raw": {{0, 0, 255, 255}, {255, 255, 255, 255}, {255, 0, 0, 255}, {0, 255, 0, 255}},
+		"rle": {{0, 0, 255, 255}, {0, 0, 255, 255}, {255, 0, 0, 255}, {0, 255, 0, 255}},
+	}
+
+	for _, tt := range tests {
+		img, format, err := DecodeImage(bytes.NewReader(tt.data), "texture.TGA")
+		if err != nil || format != FormatTGA {
+			t.Errorf("%s: got format %q, err %v", tt.name, format, err)
+			continue
+		}
+		for i, w := range want[tt.name] {
+			if got := img.(*image.NRGBA).NRGBAAt(i%2, i/2); got != w {
+				t.Errorf("%s: pixel (%d, %d) got %v, want %v", tt.name, i%2, i/2, got, w)
+			}
+		}
+	}
+}
+
+// A 8x2 Radiance file, the first scanline flat and the second with adaptive RLE.
+func testHDRFile() []byte {
+	data := []byte("#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y 2 +X 8\n")
+	for x := 0; x < 8; x++ {
+		data = append(data, 128, 64, 0, 129) // (1, 0.5, 0)
+	}
+	data = append(data, 2, 2, 0, 8)
+	data = append(data, 128+8, 128) // red
+	data = append(data, 128+8, 0)   // green
+	data = append(data, 2, 32, 64, 128+6, 0)
+	data = append(data, 128+8, 131) // exponent, 4x
+	return data
+}
+
+func TestDecodeHDR(t *testing.T) {
+	img, format, err := DecodeImage(bytes.NewReader(testHDRFile()), "sky.png")
+	if err != nil || format != FormatHDR {
+		t.Fatalf("got format %q, err %v", format, err)
+	}
+	hdr := img.(*HDRImage)
+	if got := hdr.RGBAt(5, 0); got != [3]float32{1, 0.5, 0} {
+		t.Errorf("flat scanline: got %v", got)
+	}
+	if got := hdr.RGBAt(0, 1); got != [3]float32{4, 0, 1} {
+		t.Errorf("RLE scanline (0): got %v", got)
+	}
+	if got := hdr.RGBAt(1, 1); got != [3]float32{4, 0, 2} {
+		t.Errorf("RLE scanline (1): got %v", got)
+	}
+	if got := hdr.RGBAt(7, 1); got != [3]float32{4, 0, 0} {
+		t.Errorf("RLE scanline (7): got %v", got)
+	}
+}
+
+func TestLoadHDRTexture(t *testing.T) {
+	withGLContext(t)
+
+	path := filepath.Join(t.TempDir(), "sky.hdr")
+	if err := os.WriteFile(path, testHDRFile(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []int32{gl.RGB16F, gl.RGB32F} {
+		texture, err := LoadTexture(path, TextureOptions{WrapS: gl.CLAMP_TO_EDGE, WrapT: gl.CLAMP_TO_EDGE, NoMipmaps: true, InternalFormat: format})
+		if err != nil {
+			t.Fatal(err)
+		}
+		gl.BindTexture(gl.TEXTURE_2D, texture)
+		var internal int32
+		gl.GetTexLevelParameteriv(gl.TEXTURE_2D, 0, gl.TEXTURE_INTERNAL_FORMAT, &internal)
+
+		// row 0 of the texture is the bottom of the image
+		pixels := make([]float32, 8*2*3)
+		gl.GetTexImage(gl.TEXTURE_2D, 0, gl.RGB, gl.FLOAT, gl.Ptr(pixels))
+		gl.DeleteTextures(1, &texture)
+
+		if internal != format {
+			t.Errorf("internal format: got %#x, want %#x", internal, format)
+		}
+		if pixels[0] != 4 || pixels[8*3] != 1 {
+			t.Errorf("%#x: got red %v and %v, want 4 and 1", format, pixels[0], pixels[8*3])
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Loads textures and models in the background. Files are decoded and imported on worker goroutines,
+// the OpenGL uploads are queued and run by Process on the thread that owns the context, a bit per frame.
+// Until its upload has run a texture is a 1x1 placeholder, so renders can draw straight away.
+//
+// NewLoader, the Load* functions, Process and Wait must all be called from the GL thread.
+type Loader struct {
+	// How long Process may spend uploading per call, at least one upload runs every call.
+	Budget time.Duration
+	// Called from Process after every finished item, total grows as models discover their textures.
+	OnProgress func(done, total int)
+
+	workers chan struct{} // one slot per worker goroutine
+
+	mu      sync.Mutex
+	uploads []func()
+	working int // jobs that may still queue an upload
+
+	done, total  int // guarded by mu too, models add to total from the workers
+	placeholders map[string]uint32
+}
+
+// Default for Loader.Budget, about a quarter of a 60 fps frame.
+const DefaultUploadBudget = 4 * time.Millisecond
+
+// Create a loader running at most workers decodes at once, runtime.NumCPU() when workers <= 0.
+func NewLoader(workers int) *Loader {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	return &Loader{
+		Budget:       DefaultUploadBudget,
+		workers:      make(chan struct{}, workers),
+		placeholders: make(map[string]uint32),
+	}
+}
+
+// A texture that is being loaded. ID is a placeholder until Ready, it is never 0.
+type AsyncTexture struct {
+	ID    uint32
+	Ready bool
+	Err   error
+}
+
+// Start loading a texture. The returned texture is updated by Process once the image has been uploaded,
+// if loading fails Err is set and the placeholder stays.
+func (l *Loader) LoadTexture(path string, opts TextureOptions) *AsyncTexture {
+	texture := &AsyncTexture{ID: l.placeholder("")}
+	l.addTotal(1)
+
+	l.decode(path, func(img image.Image, err error) {
+		if err != nil {
+			texture.Err = err
+		} else {
+			texture.ID = NewTextureFromImage(img, opts)
+			texture.Ready = true
+		}
+		l.finished()
+	})
+	return texture
+}
+
+// Texture types a model gets from its materials, by whether it is drawn with a PBR material.
+var modelTextureTypes = map[bool][]string{
+	false: {"texture_diffuse", "texture_specular", "texture_normal", "texture_height"},
+	true:  {"texture_albedo", "texture_metallic", "texture_roughness", "texture_ao", "texture_normal"},
+}
+
+// Start loading a model. The meshes are added to the returned model by Process as they are uploaded,
+// their textures start out as placeholders matching the texture type. If the import or a texture fails
+// the model's Err is set, textures that failed keep their placeholder.
+func (l *Loader) LoadModel(path string, gamma bool) *Model {
+	return l.loadModel(&Model{GammaCorrection: gamma}, path)
+}
+
+// Same as LoadModel for a model drawn with material by a shader including shaders/PBR/common/pbr.glsl.
+// Only the metallic-roughness maps are loaded, the albedo maps are decoded from sRGB as the PBR shaders
+// light in linear space.
+func (l *Loader) LoadPBRModel(path string, material PBRMaterial) *Model {
+	return l.loadModel(&Model{GammaCorrection: true, PBR: &material}, path)
+}
+
+func (l *Loader) loadModel(model *Model, path string) *Model {
+	model.LoadedTextures = make(map[string]Texture)
+	load := &modelLoad{
+		model:   model,
+		waiting: make(map[string][]textureSlot),
+	}
+	// make sure the placeholders exist before any mesh needs them, they can only be created here
+	gamma, pbr := model.GammaCorrection, model.PBR != nil
+	for _, typeName := range modelTextureTypes[pbr] {
+		l.placeholder(typeName)
+	}
+	l.addTotal(1)
+
+	l.run(func() {
+		dir, meshes, err := importModel(path, gamma, pbr)
+		if err != nil {
+			l.upload(func() {
+				load.model.Err = err
+				l.finished()
+			})
+			return
+		}
+
+		// decode every texture once, in parallel with the mesh uploads
+		requested := map[string]bool{}
+		for _, data := range meshes {
+			for _, ref := range data.textures {
+				if !requested[ref.key()] {
+					requested[ref.key()] = true
+					l.addTotal(1)
+					l.loadModelTexture(load, dir+ref.filename(), ref)
+				}
+			}
+		}
+
+		for _, data := range meshes {
+			l.upload(func() { load.addMesh(l, dir, data) })
+		}
+		l.upload(l.finished)
+	})
+	return load.model
+}
+
+// State of a model being loaded, only touched on the GL thread.
+type modelLoad struct {
+	model *Model
+	// textures still showing a placeholder, by textureRef key
+	waiting map[string][]textureSlot
+}
+
+type textureSlot struct{ mesh, texture int }
+
+func (load *modelLoad) addMesh(l *Loader, dir string, data meshData) {
+	m := load.model
+	m.Directory = dir
+
+	textures := []Texture{}
+	for j, ref := range data.textures {
+		texture := Texture{id: l.placeholder(ref.Type), Type: ref.Type, Path: ref.Path}
+		if loaded, ok := m.LoadedTextures[ref.key()]; ok {
+			texture.id = loaded.id
+		} else {
+			load.waiting[ref.key()] = append(load.waiting[ref.key()], textureSlot{len(m.Meshes), j})
+		}
+		textures = append(textures, texture)
+	}
+	m.Meshes = append(m.Meshes, NewMesh(data.vertices, data.indices, textures))
+}
+
+// Decode one texture of a model and swap it in for the placeholders.
+func (l *Loader) loadModelTexture(load *modelLoad, file string, ref textureRef) {
+	space := Linear
+	if ref.gamma {
+		space = SRGB
+	}
+	l.decode(file, func(img image.Image, err error) {
+		defer l.finished()
+		if err != nil {
+			if load.model.Err == nil {
+				load.model.Err = err
+			}
+			return
+		}
+
+		texture := Texture{id: NewTextureFromImage(img, TextureOptions{ColorSpace: space}), Type: ref.Type, Path: ref.Path}
+		load.model.LoadedTextures[ref.key()] = texture
+		for _, slot := range load.waiting[ref.key()] {
+			load.model.Meshes[slot.mesh].Textures[slot.texture].id = texture.id
+		}
+		delete(load.waiting, ref.key())
+	})
+}
+
+// Run uploads until the budget is used up. Call it once per frame, before drawing.
+func (l *Loader) Process() {
+	start := time.Now()
+	for {
+		upload, ok := l.next()
+		if !ok {
+			return
+		}
+		upload()
+		if time.Since(start) >= l.Budget {
+			return
+		}
+	}
+}
+
+// Block until everything requested so far has been uploaded, ignoring the budget.
+func (l *Loader) Wait() {
+	for {
+		upload, ok := l.next()
+		if ok {
+			upload()
+			continue
+		}
+		l.mu.Lock()
+		working := l.working
+		l.mu.Unlock()
+		if working == 0 && l.Pending() == 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Number of uploads waiting for Process.
+func (l *Loader) Pending() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.uploads)
+}
+
+// Whether everything requested so far has been loaded (or failed to).
+func (l *Loader) Done() bool {
+	done, total := l.Progress()
+	return done == total
+}
+
+// Items finished and requested so far.
+func (l *Loader) Progress() (done, total int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.done, l.total
+}
+
+// Decode path on a worker, then call upload with the result on the GL thread.
+func (l *Loader) decode(path string, upload func(image.Image, error)) {
+	l.run(func() {
+		img, err := LoadImage(path)
+		l.upload(func() { upload(img, err) })
+	})
+}
+
+// Run job on a worker goroutine, the job has queued all its uploads when it returns.
+func (l *Loader) run(job func()) {
+	l.mu.Lock()
+	l.working++
+	l.mu.Unlock()
+	go func() {
+		l.workers <- struct{}{}
+		job()
+		<-l.workers
+
+		l.mu.Lock()
+		l.working--
+		l.mu.Unlock()
+	}()
+}
+
+func (l *Loader) upload(upload func()) {
+	l.mu.Lock()
+	l.uploads = append(l.uploads, upload)
+	l.mu.Unlock()
+}
+
+func (l *Loader) next() (func(), bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.uploads) == 0 {
+		return nil, false
+	}
+	upload := l.uploads[0]
+	l.uploads = l.uploads[1:]
+	return upload, true
+}
+
+func (l *Loader) addTotal(n int) {
+	l.mu.Lock()
+	l.total += n
+	l.mu.Unlock()
+}
+
+// Count an item as done, only called from uploads.
+func (l *Loader) finished() {
+	l.mu.Lock()
+	l.done++
+	done, total := l.done, l.total
+	l.mu.Unlock()
+	if l.OnProgress != nil {
+		l.OnProgress(done, total)
+	}
+}
+
+// A 1x1 texture standing in for one that is still loading. Colour and roughness maps are gray, specular
+// and metallic maps black so nothing shines, occlusion maps white, normal maps point straight out of the surface.
+func (l *Loader) placeholder(typeName string) uint32 {
+	if id, ok := l.placeholders[typeName]; ok {
+		return id
+	}
+	c := color.NRGBA{128, 128, 128, 255}
+	switch typeName {
+	case "texture_specular", "texture_height", "texture_metallic":
+		c = color.NRGBA{0, 0, 0, 255}
+	case "texture_ao":
+		c = color.NRGBA{255, 255, 255, 255}
+	case "texture_normal":
+		c = color.NRGBA{128, 128, 255, 255}
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	img.SetNRGBA(0, 0, c)
+
+	id := NewTextureFromImage(img, TextureOptions{MinFilter: gl.NEAREST, MagFilter: gl.NEAREST, NoMipmaps: true})
+	l.placeholders[typeName] = id
+	return id
+}
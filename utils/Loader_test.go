@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+func writeTestPNGs(t *testing.T, n int) []string {
+	t.Helper()
+	dir := t.TempDir()
+	paths := []string{}
+	for i := 0; i < n; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("%d.png", i))
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		png.Encode(file, testImage(16, 16))
+		file.Close()
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestLoaderTextures(t *testing.T) {
+	withGLContext(t)
+
+	paths := writeTestPNGs(t, 4)
+	loader := NewLoader(2)
+	var progress [][2]int
+	loader.OnProgress = func(done, total int) { progress = append(progress, [2]int{done, total}) }
+
+	textures := []*AsyncTexture{}
+	for _, path := range paths {
+		textures = append(textures, loader.LoadTexture(path, TextureOptions{}))
+	}
+	missing := loader.LoadTexture(filepath.Join(t.TempDir(), "missing.png"), TextureOptions{})
+
+	placeholder := textures[0].ID
+	if placeholder == 0 || textures[0].Ready {
+		t.Fatalf("expected a placeholder before Process, got %+v", textures[0])
+	}
+
+	// with no time to spare Process still makes progress, one upload at a time
+	deadline := time.Now().Add(5 * time.Second)
+	for loader.Pending() < 5 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	loader.Budget = 0
+	loader.Process()
+	if got := loader.Pending(); got != 4 {
+		t.Errorf("after one Process with no budget: %d pending uploads, want 4", got)
+	}
+
+	loader.Wait()
+	if !loader.Done() {
+		t.Errorf("not done after Wait: %v", progress)
+	}
+	for i, texture := range textures {
+		if !texture.Ready || texture.ID == placeholder || !gl.IsTexture(texture.ID) {
+			t.Errorf("texture %d: got %+v", i, texture)
+		}
+	}
+	if missing.Ready || missing.Err == nil || missing.ID != placeholder {
+		t.Errorf("missing texture: got %+v", missing)
+	}
+	if len(progress) != 5 || progress[4] != [2]int{5, 5} {
+		t.Errorf("progress: got %v", progress)
+	}
+}
+
+func TestLoaderMissingModel(t *testing.T) {
+	withGLContext(t)
+
+	loader := NewLoader(1)
+	model := loader.LoadModel(filepath.Join(t.TempDir(), "missing.obj"), false)
+	loader.Wait()
+
+	if done, total := loader.Progress(); done != 1 || total != 1 {
+		t.Errorf("progress: got %d/%d, want 1/1", done, total)
+	}
+	if len(model.Meshes) != 0 || model.Err == nil {
+		t.Errorf("got %d meshes and error %v from a missing file", len(model.Meshes), model.Err)
+	}
+}
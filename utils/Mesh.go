@@ -21,6 +21,11 @@ type Texture struct {
 	Path string
 }
 
+// A texture of typeName (texture_diffuse, texture_albedo, ...) for meshes built in code rather than loaded.
+func NewMeshTexture(id uint32, typeName string) Texture {
+	return Texture{id: id, Type: typeName}
+}
+
 type Mesh struct {
 	Vertices []Vertex
 	Indices  []uint32
@@ -42,28 +47,12 @@ func NewMesh(vertices []Vertex, indices []uint32, textures []Texture) Mesh {
 }
 
 func (m *Mesh) Draw(shader uint32) {
-	var diffuseNr uint64 = 1
-	var specularNr uint64 = 1
-	var normalNr uint64 = 1
-	var heightNr uint64 = 1
+	// samplers are named material.<type><n>, numbered from 1 for each type
+	numbers := map[string]int{}
 	for i := 0; i < len(m.Textures); i++ {
-		number := ""
+		numbers[m.Textures[i].Type]++
 		gl.ActiveTexture(gl.TEXTURE0 + uint32(i))
-		switch m.Textures[i].Type {
-		case "texture_diffuse":
-			number += strconv.FormatUint(diffuseNr, 10)
-			diffuseNr++
-		case "texture_specular":
-			number += strconv.FormatUint(specularNr, 10)
-			specularNr++
-		case "texture_normal":
-			number += strconv.FormatUint(normalNr, 10)
-			normalNr++
-		case "texture_height":
-			number += strconv.FormatUint(heightNr, 10)
-			heightNr++
-		}
-		SetInt(shader, "material."+m.Textures[i].Type+number, int32(i))
+		SetInt(shader, "material."+m.Textures[i].Type+strconv.Itoa(numbers[m.Textures[i].Type]), int32(i))
 		gl.BindTexture(gl.TEXTURE_2D, m.Textures[i].id)
 	}
 
@@ -75,6 +64,18 @@ func (m *Mesh) Draw(shader uint32) {
 	gl.ActiveTexture(gl.TEXTURE0)
 }
 
+// The vertex attributes every Mesh VAO provides, shaders drawing meshes can be checked against it
+// with ShaderReflection.ValidateVertexLayout.
+var MeshVertexLayout = []VertexAttribute{
+	{Name: "Position", Location: 0, Type: gl.FLOAT_VEC3},
+	{Name: "Normal", Location: 1, Type: gl.FLOAT_VEC3},
+	{Name: "TexCoords", Location: 2, Type: gl.FLOAT_VEC2},
+	{Name: "Tangent", Location: 3, Type: gl.FLOAT_VEC3},
+	{Name: "Bitangent", Location: 4, Type: gl.FLOAT_VEC3},
+	{Name: "BoneIDs", Location: 5, Type: gl.INT_VEC4},
+	{Name: "Weights", Location: 6, Type: gl.FLOAT_VEC4},
+}
+
 func (m *Mesh) setupMesh() {
 	// size of the Vertex struct
 	var dummy Vertex
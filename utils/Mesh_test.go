@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"opgl-learn/headless"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func solidTexture(c color.NRGBA) uint32 {
+	img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	img.SetNRGBA(0, 0, c)
+	return NewTextureFromImage(img, TextureOptions{NoMipmaps: true})
+}
+
+func TestMeshDrawSamplers(t *testing.T) {
+	withGLContext(t)
+
+	shader, err := LoadShader("../shaders/ModelLoading/1-ModelVert.glsl", "../shaders/ModelLoading/1-ModelFrag.glsl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gl.DeleteProgram(shader)
+
+	target, err := headless.NewTarget(32, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Delete()
+
+	// The diffuse map comes second so it isn't on unit 0, where a sampler the shader names
+	// differently from Mesh.Draw would read from.
+	specular := solidTexture(color.NRGBA{0, 0, 255, 255})
+	diffuse := solidTexture(color.NRGBA{255, 0, 0, 255})
+	defer gl.DeleteTextures(1, &specular)
+	defer gl.DeleteTextures(1, &diffuse)
+	mesh := quadMesh(-1, 1)
+	mesh.Textures = []Texture{NewMeshTexture(specular, "texture_specular"), NewMeshTexture(diffuse, "texture_diffuse")}
+
+	gl.UseProgram(shader)
+	projection := mgl32.Ortho(-1, 1, -1, 1, 0.1, 10)
+	identity := mgl32.Ident4()
+	SetMat4(shader, "projection", &projection)
+	SetMat4(shader, "view", &identity)
+	SetMat4(shader, "model", &identity)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+	mesh.Draw(shader)
+
+	if got, want := target.ReadImage().NRGBAAt(16, 16), (color.NRGBA{255, 0, 0, 255}); got != want {
+		t.Errorf("got %v, want the diffuse map's %v", got, want)
+	}
+}
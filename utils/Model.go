@@ -5,7 +5,6 @@ import (
 	"path"
 
 	"github.com/bloeys/assimp-go/asig"
-	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/mathgl/mgl32"
 )
 
@@ -13,51 +12,152 @@ type Model struct {
 	Meshes         []Mesh
 	Directory      string
 	LoadedTextures map[string]Texture
+	// Upload colour textures (diffuse maps) as sRGB, turn it on when drawing into an sRGB framebuffer.
+	GammaCorrection bool
+
+	// Cubemap reflected and refracted by the meshes, Draw leaves the environment alone while it is 0.
+	EnvironmentMap uint32
+	// Environment mapping of every mesh without one of its own in MeshEnvironment.
+	Environment EnvironmentMaterial
+	// Per mesh overrides of Environment, by index into Meshes.
+	MeshEnvironment map[int]EnvironmentMaterial
+
+	// Factors of the metallic-roughness material, Draw sets them and which maps each mesh has for
+	// shaders including shaders/PBR/common/pbr.glsl. Set by Loader.LoadPBRModel, which imports the
+	// metallic-roughness maps instead of the diffuse and specular ones. Leave it nil for other shaders.
+	PBR *PBRMaterial
+
+	// Set by Loader.LoadModel when the import or one of the textures failed, the first such error.
+	Err error
 }
 
 func NewModel(path string) Model {
-	model := Model{}
+	return NewModelWithGamma(path, false)
+}
+
+// Same as NewModel, gamma sets GammaCorrection.
+func NewModelWithGamma(path string, gamma bool) Model {
+	model := Model{GammaCorrection: gamma}
 	model.LoadedTextures = make(map[string]Texture)
 	model.loadModel(path)
 	return model
 }
 
 func (m *Model) Draw(shader uint32) {
+	if m.EnvironmentMap != 0 {
+		bindEnvironmentMap(shader, m.EnvironmentMap)
+	}
 	for i := 0; i < len(m.Meshes); i++ {
+		if m.EnvironmentMap != 0 {
+			m.MeshEnvironmentMaterial(i).apply(shader)
+		}
+		if m.PBR != nil {
+			m.PBR.Apply(shader, m.Meshes[i].Textures)
+		}
 		m.Meshes[i].Draw(shader)
 	}
 }
 
-func (m *Model) loadModel(filepath string) {
-	scene, _, err := asig.ImportFile(filepath, asig.PostProcessTriangulate|asig.PostProcessFlipUVs)
+// The environment mapping mesh i is drawn with.
+func (m *Model) MeshEnvironmentMaterial(i int) EnvironmentMaterial {
+	if env, ok := m.MeshEnvironment[i]; ok {
+		return env
+	}
+	return m.Environment
+}
 
-	if err != nil || scene.Flags&asig.SceneFlagIncomplete != 0 {
-		fmt.Printf("ERROR::ASSIMP:: %d\n", scene.Flags)
+func (m *Model) loadModel(filepath string) {
+	dir, meshes, err := importModel(filepath, m.GammaCorrection, m.PBR != nil)
+	if err != nil {
+		fmt.Println(err)
 		return
 	}
 
-	dir, _ := path.Split(filepath)
 	m.Directory = dir
-	m.processNode(scene.RootNode, scene)
+	for _, data := range meshes {
+		textures := []Texture{}
+		for _, ref := range data.textures {
+			textures = append(textures, m.loadTexture(ref))
+		}
+		m.Meshes = append(m.Meshes, NewMesh(data.vertices, data.indices, textures))
+	}
 }
 
-func (m *Model) processNode(node *asig.Node, scene *asig.Scene) {
+// Get the texture for ref, loading it the first time it is used.
+func (m *Model) loadTexture(ref textureRef) Texture {
+	if val, ok := m.LoadedTextures[ref.key()]; ok {
+		return Texture{id: val.id, Type: ref.Type, Path: ref.Path}
+	}
+	texture := Texture{
+		id:   TextureFromFile(ref.filename(), m.Directory, ref.gamma),
+		Type: ref.Type,
+		Path: ref.Path,
+	}
+	m.LoadedTextures[ref.key()] = texture
+	return texture
+}
+
+// A mesh as imported by assimp, before anything is uploaded to OpenGL.
+type meshData struct {
+	vertices []Vertex
+	indices  []uint32
+	textures []textureRef
+}
+
+// A texture used by a material, Path is as written in the model file.
+type textureRef struct {
+	Type, Path string
+	gamma      bool
+}
+
+// the same file may be used as a colour and a data map, those need different textures
+func (ref textureRef) key() string {
+	if ref.gamma {
+		return ref.Path + " (sRGB)"
+	}
+	return ref.Path
+}
+
+// textures are looked up next to the model whatever directory the model file says
+func (ref textureRef) filename() string {
+	_, filename := path.Split(ref.Path)
+	return filename
+}
+
+// Run assimp and collect the meshes of the scene without touching OpenGL, so it can run on any goroutine.
+// pbr picks the metallic-roughness maps of the materials instead of the phong ones.
+func importModel(filepath string, gamma, pbr bool) (dir string, meshes []meshData, err error) {
+	scene, release, err := asig.ImportFile(filepath, asig.PostProcessTriangulate|asig.PostProcessFlipUVs|asig.PostProcessCalcTangentSpace)
+	if err != nil {
+		return "", nil, fmt.Errorf("ERROR::ASSIMP:: %s: %w", filepath, err)
+	}
+	defer release()
+	if scene.Flags&asig.SceneFlagIncomplete != 0 {
+		return "", nil, fmt.Errorf("ERROR::ASSIMP:: %s: incomplete scene (flags %d)", filepath, scene.Flags)
+	}
+
+	dir, _ = path.Split(filepath)
+	return dir, processNode(scene.RootNode, scene, gamma, pbr, nil), nil
+}
+
+func processNode(node *asig.Node, scene *asig.Scene, gamma, pbr bool, meshes []meshData) []meshData {
 
 	for i := 0; i < len(node.MeshIndicies); i++ {
 		mesh := scene.Meshes[node.MeshIndicies[i]]
-		m.Meshes = append(m.Meshes, m.processMesh(mesh, scene))
+		meshes = append(meshes, processMesh(mesh, scene, gamma, pbr))
 	}
 
 	for i := 0; i < len(node.Children); i++ {
-		m.processNode(node.Children[i], scene)
+		meshes = processNode(node.Children[i], scene, gamma, pbr, meshes)
 	}
+	return meshes
 }
 
-func (m *Model) processMesh(mesh *asig.Mesh, scene *asig.Scene) Mesh {
+func processMesh(mesh *asig.Mesh, scene *asig.Scene, gamma, pbr bool) meshData {
 
 	vertices := []Vertex{}
 	indices := []uint32{}
-	textures := []Texture{}
+	textures := []textureRef{}
 
 	for i := 0; i < len(mesh.Vertices); i++ {
 
@@ -93,45 +193,52 @@ func (m *Model) processMesh(mesh *asig.Mesh, scene *asig.Scene) Mesh {
 
 	// process material
 	material := scene.Materials[mesh.MaterialIndex]
-	diffuseMaps := m.loadMaterialTextures(material, asig.TextureTypeDiffuse, "texture_diffuse")
-	textures = append(textures, diffuseMaps...)
-
-	specularMaps := m.loadMaterialTextures(material, asig.TextureTypeSpecular, "texture_specular")
-	textures = append(textures, specularMaps...)
-
-	normalMaps := m.loadMaterialTextures(material, asig.TextureTypeNormal, "texture_normal")
-	textures = append(textures, normalMaps...)
-
-	heightMaps := m.loadMaterialTextures(material, asig.TextureTypeHeight, "texture_height")
-	textures = append(textures, heightMaps...)
+	normals := firstMaterialTextures(material, "texture_normal", false, asig.TextureTypeNormal, asig.TextureTypeNormalCamera)
+	if pbr {
+		// The metallic-roughness maps. Materials without a base colour use their diffuse map as albedo,
+		// glTF's occlusion ends up as a lightmap. glTF also packs metallic and roughness into one file,
+		// which assimp lists under both types, it is loaded only once.
+		textures = append(textures, firstMaterialTextures(material, "texture_albedo", gamma, asig.TextureTypeBaseColor, asig.TextureTypeDiffuse)...)
+		textures = append(textures, materialTextures(material, asig.TextureTypeMetalness, "texture_metallic", false)...)
+		textures = append(textures, materialTextures(material, asig.TextureTypeDiffuseRoughness, "texture_roughness", false)...)
+		textures = append(textures, firstMaterialTextures(material, "texture_ao", false, asig.TextureTypeAmbientOcclusion, asig.TextureTypeLightmap)...)
+		textures = append(textures, normals...)
+	} else {
+		// only colours are gamma corrected, specular, normal and height maps hold data
+		textures = append(textures, materialTextures(material, asig.TextureTypeDiffuse, "texture_diffuse", gamma)...)
+		textures = append(textures, materialTextures(material, asig.TextureTypeSpecular, "texture_specular", false)...)
+		textures = append(textures, normals...)
+		textures = append(textures, materialTextures(material, asig.TextureTypeHeight, "texture_height", false)...)
+	}
 
-	return NewMesh(vertices, indices, textures)
+	return meshData{vertices: vertices, indices: indices, textures: textures}
 }
 
-func (m *Model) loadMaterialTextures(mat *asig.Material, matType asig.TextureType, typeName string) []Texture {
-	textures := []Texture{}
-
+func materialTextures(mat *asig.Material, matType asig.TextureType, typeName string, gamma bool) []textureRef {
+	textures := []textureRef{}
 	for i := 0; i < asig.GetMaterialTextureCount(mat, matType); i++ {
-
 		info, _ := asig.GetMaterialTexture(mat, matType, uint(i))
-		if val, ok := m.LoadedTextures[info.Path]; ok {
-			textures = append(textures, val)
-		} else {
-			_, filename := path.Split(info.Path)
-			texture := Texture{
-				id:   TextureFromFile(filename, m.Directory, true),
-				Type: typeName,
-				Path: info.Path,
-			}
-			textures = append(textures, texture)
-			m.LoadedTextures[info.Path] = texture
-		}
+		textures = append(textures, textureRef{Type: typeName, Path: info.Path, gamma: gamma})
 	}
-
 	return textures
 }
 
+// The textures of the first of matTypes the material has any of, as typeName.
+func firstMaterialTextures(mat *asig.Material, typeName string, gamma bool, matTypes ...asig.TextureType) []textureRef {
+	for _, matType := range matTypes {
+		if textures := materialTextures(mat, matType, typeName, gamma); len(textures) > 0 {
+			return textures
+		}
+	}
+	return nil
+}
+
+// Load directory+path as a texture, gamma uploads it as sRGB.
 func TextureFromFile(path string, directory string, gamma bool) uint32 {
 	filename := directory + path
-	return New2DTexture(gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR, filename)
+	space := Linear
+	if gamma {
+		space = SRGB
+	}
+	return NewTexture(filename, TextureOptions{ColorSpace: space})
 }
@@ -0,0 +1,49 @@
+package utils
+
+import "github.com/go-gl/mathgl/mgl32"
+
+// Metallic-roughness material of a mesh, mirrors the PBRMaterial struct in shaders/PBR/common/pbr.glsl.
+// Each factor is multiplied with the matching map of the mesh, meshes without that map use the factor alone.
+type PBRMaterial struct {
+	// Base colour, linear RGB. Diffuse colour of dielectrics and reflection colour of metals.
+	Albedo mgl32.Vec3
+	// 0 for dielectrics like plastic or wood, 1 for metals.
+	Metallic float32
+	// 0 is a perfect mirror, 1 fully rough.
+	Roughness float32
+	// Ambient occlusion, how much of the ambient light reaches the surface.
+	AO float32
+}
+
+// White, half rough plastic. The maps of a mesh show as they are with it.
+var DefaultPBRMaterial = PBRMaterial{Albedo: mgl32.Vec3{1, 1, 1}, Metallic: 0, Roughness: 0.5, AO: 1}
+
+// The map of each texture type pbr.glsl samples, and the uniform telling it the mesh has one.
+// Metallic is read from the blue and roughness from the green channel, so glTF's packed
+// metallic-roughness textures work as well as grayscale maps.
+var pbrMaps = []struct{ Type, flag string }{
+	{"texture_albedo", "material.hasAlbedoMap"},
+	{"texture_metallic", "material.hasMetallicMap"},
+	{"texture_roughness", "material.hasRoughnessMap"},
+	{"texture_ao", "material.hasAOMap"},
+	{"texture_normal", "material.hasNormalMap"},
+}
+
+// Set the factors on program, which has to be in use, and which maps textures has. Bind textures
+// with Mesh.Draw, or set the flags for geometry drawn without a mesh by passing textures of those types.
+func (p PBRMaterial) Apply(program Program, textures []Texture) {
+	SetVec3(program, "material.albedo", &p.Albedo)
+	SetFloat(program, "material.metallic", p.Metallic)
+	SetFloat(program, "material.roughness", p.Roughness)
+	SetFloat(program, "material.ao", p.AO)
+	for _, m := range pbrMaps {
+		has := int32(0)
+		for _, texture := range textures {
+			if texture.Type == m.Type {
+				has = 1
+				break
+			}
+		}
+		SetBool(program, m.flag, has)
+	}
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+
+	"opgl-learn/headless"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestPBRMaterial(t *testing.T) {
+	withGLContext(t)
+
+	shader, err := LoadShader("../shaders/PBR/1-LightingVert.glsl", "../shaders/PBR/1-LightingFrag.glsl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gl.DeleteProgram(shader)
+
+	target, err := headless.NewTarget(64, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Delete()
+
+	red := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	red.SetNRGBA(0, 0, color.NRGBA{255, 0, 0, 255})
+	albedoMap := NewTextureFromImage(red, TextureOptions{NoMipmaps: true})
+	defer gl.DeleteTextures(1, &albedoMap)
+
+	// Metals only reflect their albedo, so the colour of each quad shows which albedo it got. The left quad
+	// is white with a red map, the right one green without a map, though the red map is still bound.
+	metal := PBRMaterial{Albedo: mgl32.Vec3{1, 1, 1}, Metallic: 1, Roughness: 0.5, AO: 1}
+	left := Model{Meshes: []Mesh{quadMesh(-1, 0)}, PBR: &metal}
+	left.Meshes[0].Textures = []Texture{NewMeshTexture(albedoMap, "texture_albedo")}
+	green := metal
+	green.Albedo = mgl32.Vec3{0, 1, 0}
+	right := Model{Meshes: []Mesh{quadMesh(0, 1)}, PBR: &green}
+
+	camera := NewUniformBuffer(CameraBinding)
+	defer camera.Delete()
+	camera.Update(&CameraUniforms{Projection: mgl32.Ortho(-1, 1, -1, 1, 0.1, 10), View: mgl32.Ident4()})
+
+	gl.UseProgram(shader)
+	identity := mgl32.Ident4()
+	SetMat4(shader, "model", &identity)
+	DisableIBL(shader, 4)
+	for i := range 4 {
+		SetVec3(shader, fmt.Sprintf("lightPositions[%d]", i), &mgl32.Vec3{0, 0, 0})
+		SetVec3(shader, fmt.Sprintf("lightColors[%d]", i), &mgl32.Vec3{1, 1, 1})
+	}
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+	left.Draw(shader)
+	right.Draw(shader)
+
+	img := target.ReadImage()
+	if got := img.NRGBAAt(16, 16); got.R < 32 || got.G != 0 || got.B != 0 {
+		t.Errorf("albedo map: got %v, want red", got)
+	}
+	if got := img.NRGBAAt(48, 16); got.G < 32 || got.R != 0 || got.B != 0 {
+		t.Errorf("albedo factor: got %v, want green", got)
+	}
+}
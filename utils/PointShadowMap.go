@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Stores the distance to the light instead of the projected depth, so the lighting shader can compare
+// distances along any direction without knowing which face it samples.
+const pointShadowDepthVertexShader = `#version 330 core
+layout (location = 0) in vec3 aPos;
+
+out vec3 FragPos;
+
+uniform mat4 lightSpaceMatrix;
+uniform mat4 model;
+
+void main()
+{
+    FragPos = vec3(model * vec4(aPos, 1.0));
+    gl_Position = lightSpaceMatrix * vec4(FragPos, 1.0);
+}
+`
+
+const pointShadowDepthFragmentShader = `#version 330 core
+in vec3 FragPos;
+
+uniform vec3 lightPos;
+uniform float farPlane;
+
+void main()
+{
+    // linear distance mapped to [0, 1]
+    gl_FragDepth = length(FragPos - lightPos) / farPlane;
+}
+`
+
+// Where each face of a cubemap looks, and which way is up in it, in the order of
+// gl.TEXTURE_CUBE_MAP_POSITIVE_X + i. Cubemap faces are upside down, hence the up vectors.
+var cubemapFaceViews = [6]struct{ front, up mgl32.Vec3 }{
+	{mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, -1, 0}},
+	{mgl32.Vec3{-1, 0, 0}, mgl32.Vec3{0, -1, 0}},
+	{mgl32.Vec3{0, 1, 0}, mgl32.Vec3{0, 0, 1}},
+	{mgl32.Vec3{0, -1, 0}, mgl32.Vec3{0, 0, -1}},
+	{mgl32.Vec3{0, 0, 1}, mgl32.Vec3{0, -1, 0}},
+	{mgl32.Vec3{0, 0, -1}, mgl32.Vec3{0, -1, 0}},
+}
+
+// Projection * view of every face of a cubemap centred on position, see cubemapFaceViews.
+func CubemapFaceTransforms(position mgl32.Vec3, near, far float32) [6]mgl32.Mat4 {
+	projection := mgl32.Perspective(mgl32.DegToRad(90), 1, near, far)
+	var transforms [6]mgl32.Mat4
+	for i, face := range cubemapFaceViews {
+		transforms[i] = projection.Mul4(mgl32.LookAtV(position, position.Add(face.front), face.up))
+	}
+	return transforms
+}
+
+// Shadows of a point light, which shines in every direction: a depth cubemap around the light holding
+// the distance to the closest surface. Draw it with Render, then sample it in the lighting shader through
+// shaders/Lighting/common/pointShadows.glsl, see SetUniforms.
+type PointShadowMap struct {
+	// Where the light is, set by Render.
+	LightPos mgl32.Vec3
+	// Nearest distance drawn into the map and how far the light casts shadows.
+	Near, Far float32
+	// How far behind the closest surface a fragment has to be to be in shadow, in world units.
+	// Keeps lit surfaces from shadowing themselves.
+	Bias float32
+	// Radius of the disk of samples averaged around each lookup, in world units at the camera.
+	// It grows with the distance to the camera, so shadows far away are softer.
+	Softness float32
+
+	cubemap, framebuffer uint32
+	size                 int
+	shader               *Shader
+}
+
+// Create a point light shadow map with size x size faces.
+func NewPointShadowMap(size int) (*PointShadowMap, error) {
+	program, err := BuildShaderProgram(pointShadowDepthVertexShader, pointShadowDepthFragmentShader)
+	if err != nil {
+		return nil, err
+	}
+	s := &PointShadowMap{Near: 0.1, Far: 25, Bias: 0.05, Softness: 0.04, size: size, shader: NewShaderObject(program)}
+
+	gl.GenTextures(1, &s.cubemap)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, s.cubemap)
+	for face := range 6 {
+		gl.TexImage2D(gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(face), 0, gl.DEPTH_COMPONENT24, int32(size), int32(size), 0, gl.DEPTH_COMPONENT, gl.FLOAT, nil)
+	}
+	// the soft lookups do their own filtering
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, 0)
+
+	var previous int32
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &previous)
+	defer gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(previous))
+
+	gl.GenFramebuffers(1, &s.framebuffer)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, s.framebuffer)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.TEXTURE_CUBE_MAP_POSITIVE_X, s.cubemap, 0)
+	gl.DrawBuffer(gl.NONE)
+	gl.ReadBuffer(gl.NONE)
+	if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+		s.Delete()
+		return nil, fmt.Errorf("point shadow map %dx%d is not complete: %s", size, size, framebufferStatusName(status))
+	}
+	return s, nil
+}
+
+// The depth cubemap, its red channel holds the distance to the light divided by Far.
+func (s *PointShadowMap) Texture() uint32 {
+	return s.cubemap
+}
+
+// Draw the map for a light at position. draw is called once for each face of the cubemap with the depth
+// shader in use, it draws the shadow casters after setting "model" on the shader. The framebuffer and
+// viewport in use are left as they were.
+func (s *PointShadowMap) Render(position mgl32.Vec3, draw func(depth *Shader)) {
+	s.LightPos = position
+
+	var previousFramebuffer int32
+	var previousViewport [4]int32
+	gl.GetIntegerv(gl.DRAW_FRAMEBUFFER_BINDING, &previousFramebuffer)
+	gl.GetIntegerv(gl.VIEWPORT, &previousViewport[0])
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, s.framebuffer)
+	gl.Viewport(0, 0, int32(s.size), int32(s.size))
+	s.shader.Use()
+	s.shader.SetVec3("lightPos", &s.LightPos)
+	s.shader.SetFloat("farPlane", s.Far)
+	// six passes, one per face: GLSL 330 can only pick the face in a geometry shader
+	for face, transform := range CubemapFaceTransforms(position, s.Near, s.Far) {
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(face), s.cubemap, 0)
+		gl.Clear(gl.DEPTH_BUFFER_BIT)
+		s.shader.SetMat4("lightSpaceMatrix", &transform)
+		draw(s.shader)
+	}
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(previousFramebuffer))
+	gl.Viewport(previousViewport[0], previousViewport[1], previousViewport[2], previousViewport[3])
+}
+
+// Bind the map to texture unit and set pointShadows[index] of pointShadows.glsl on program,
+// which has to be in use.
+func (s *PointShadowMap) SetUniforms(program Program, index int, unit uint32) {
+	gl.ActiveTexture(gl.TEXTURE0 + unit)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, s.cubemap)
+	gl.ActiveTexture(gl.TEXTURE0)
+
+	prefix := fmt.Sprintf("pointShadows[%d].", index)
+	SetInt(program, prefix+"depthMap", int32(unit))
+	SetFloat(program, prefix+"farPlane", s.Far)
+	SetFloat(program, prefix+"bias", s.Bias)
+	SetFloat(program, prefix+"softness", s.Softness)
+}
+
+// Release the cubemap, its framebuffer and the depth shader.
+func (s *PointShadowMap) Delete() {
+	gl.DeleteFramebuffers(1, &s.framebuffer)
+	gl.DeleteTextures(1, &s.cubemap)
+	gl.DeleteProgram(s.shader.ID)
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestCubemapFaceTransforms(t *testing.T) {
+	position := mgl32.Vec3{1, 2, 3}
+	for i, m := range CubemapFaceTransforms(position, 0.1, 10) {
+		// straight ahead of each face lands in the middle of it
+		p := m.Mul4x1(position.Add(cubemapFaceViews[i].front.Mul(5)).Vec4(1))
+		ndc := p.Vec3().Mul(1 / p.W())
+		if math.Abs(float64(ndc.X())) > 1e-5 || math.Abs(float64(ndc.Y())) > 1e-5 || ndc.Z() <= -1 || ndc.Z() >= 1 {
+			t.Errorf("face %d: point ahead at %v", i, ndc)
+		}
+	}
+}
+
+func TestPointShadowMap(t *testing.T) {
+	withGLContext(t)
+
+	s, err := NewPointShadowMap(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Delete()
+
+	// a wall at x = 2 covering the whole +X face of a light at the origin
+	quad := []float32{2, -3, -3, 2, 3, -3, 2, 3, 3, 2, -3, -3, 2, 3, 3, 2, -3, 3}
+	var vao, vbo uint32
+	gl.GenVertexArrays(1, &vao)
+	gl.GenBuffers(1, &vbo)
+	defer gl.DeleteVertexArrays(1, &vao)
+	defer gl.DeleteBuffers(1, &vbo)
+	gl.BindVertexArray(vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*len(quad), gl.Ptr(quad), gl.STATIC_DRAW)
+	gl.VertexAttribPointerWithOffset(0, 3, gl.FLOAT, false, 3*4, 0)
+	gl.EnableVertexAttribArray(0)
+
+	gl.Viewport(1, 2, 3, 4)
+	gl.Enable(gl.DEPTH_TEST)
+	defer gl.Disable(gl.DEPTH_TEST)
+	faces := 0
+	s.Render(mgl32.Vec3{}, func(depth *Shader) {
+		faces++
+		model := mgl32.Ident4()
+		depth.SetMat4("model", &model)
+		gl.DrawArrays(gl.TRIANGLES, 0, 6)
+	})
+	if faces != 6 {
+		t.Errorf("draw called for %d faces, want 6", faces)
+	}
+	var viewport [4]int32
+	gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+	if viewport != [4]int32{1, 2, 3, 4} {
+		t.Errorf("viewport after Render: got %v", viewport)
+	}
+
+	readFace := func(face uint32) []float32 {
+		texels := make([]float32, 16*16)
+		gl.BindTexture(gl.TEXTURE_CUBE_MAP, s.Texture())
+		gl.GetTexImage(face, 0, gl.DEPTH_COMPONENT, gl.FLOAT, gl.Ptr(texels))
+		gl.BindTexture(gl.TEXTURE_CUBE_MAP, 0)
+		return texels
+	}
+
+	// the distance to the wall divided by the far plane, more towards the corners than in the middle
+	wall := readFace(gl.TEXTURE_CUBE_MAP_POSITIVE_X)
+	centre, corner := wall[8*16+8], wall[0]
+	if want := 2 / s.Far; math.Abs(float64(centre-want)) > 0.005 {
+		t.Errorf("+X face centre: got %v, want %v", centre, want)
+	}
+	if want := 2 * float32(math.Sqrt(3)) / s.Far; corner < centre || corner > want {
+		t.Errorf("+X face corner: got %v, want between %v and %v", corner, centre, want)
+	}
+	for _, d := range readFace(gl.TEXTURE_CUBE_MAP_NEGATIVE_X) {
+		if d != 1 {
+			t.Fatalf("-X face: got depth %v, want nothing drawn", d)
+		}
+	}
+	if glErr := gl.GetError(); glErr != gl.NO_ERROR {
+		t.Errorf("OpenGL error 0x%x", glErr)
+	}
+}
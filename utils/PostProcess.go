@@ -0,0 +1,386 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Every pass draws the same full-screen quad.
+const postVertexShader = `#version 330 core
+layout (location = 0) in vec2 aPos;
+layout (location = 1) in vec2 aTexCoords;
+
+out vec2 TexCoords;
+
+void main()
+{
+    TexCoords = aTexCoords;
+    gl_Position = vec4(aPos, 0.0, 1.0);
+}
+`
+
+// Start of the built-in effects. screenTexture is the output of the previous pass, texelSize
+// the size of one of its pixels in texture coordinates.
+const postFragmentHeader = `#version 330 core
+out vec4 FragColor;
+
+in vec2 TexCoords;
+
+uniform sampler2D screenTexture;
+uniform vec2 texelSize;
+`
+
+const invertShader = postFragmentHeader + `
+void main()
+{
+    vec3 color = clamp(texture(screenTexture, TexCoords).rgb, 0.0, 1.0);
+    FragColor = vec4(1.0 - color, 1.0);
+}
+`
+
+const grayscaleShader = postFragmentHeader + `
+void main()
+{
+    vec3 color = texture(screenTexture, TexCoords).rgb;
+    float luminance = dot(color, vec3(0.2126, 0.7152, 0.0722));
+    FragColor = vec4(vec3(luminance), 1.0);
+}
+`
+
+const kernelShader = postFragmentHeader + `
+uniform mat3 kernel;
+
+void main()
+{
+    vec3 color = vec3(0.0);
+    for (int x = -1; x <= 1; x++) {
+        for (int y = -1; y <= 1; y++) {
+            color += kernel[x + 1][1 - y] * texture(screenTexture, TexCoords + vec2(x, y) * texelSize).rgb;
+        }
+    }
+    FragColor = vec4(color, 1.0);
+}
+`
+
+const vignetteShader = postFragmentHeader + `
+uniform float radius;
+uniform float softness;
+uniform float strength;
+
+void main()
+{
+    vec3 color = texture(screenTexture, TexCoords).rgb;
+    float vignette = smoothstep(radius, radius - softness, distance(TexCoords, vec2(0.5)));
+    FragColor = vec4(color * mix(1.0, vignette, strength), 1.0);
+}
+`
+
+const chromaticAberrationShader = postFragmentHeader + `
+uniform float amount;
+
+void main()
+{
+    // red and blue are pushed apart towards the edges, like a cheap lens
+    vec2 offset = (TexCoords - vec2(0.5)) * amount;
+    float r = texture(screenTexture, TexCoords + offset).r;
+    float g = texture(screenTexture, TexCoords).g;
+    float b = texture(screenTexture, TexCoords - offset).b;
+    FragColor = vec4(r, g, b, 1.0);
+}
+`
+
+// FXAA as described by Timothy Lottes, the simple version without the edge search.
+const fxaaShader = postFragmentHeader + `
+const float SPAN_MAX = 8.0;
+const float REDUCE_MUL = 1.0 / 8.0;
+const float REDUCE_MIN = 1.0 / 128.0;
+
+vec3 sampleAt(vec2 offset)
+{
+    return texture(screenTexture, TexCoords + offset * texelSize).rgb;
+}
+
+void main()
+{
+    const vec3 toLuma = vec3(0.299, 0.587, 0.114);
+    float lumaNW = dot(sampleAt(vec2(-1.0, 1.0)), toLuma);
+    float lumaNE = dot(sampleAt(vec2(1.0, 1.0)), toLuma);
+    float lumaSW = dot(sampleAt(vec2(-1.0, -1.0)), toLuma);
+    float lumaSE = dot(sampleAt(vec2(1.0, -1.0)), toLuma);
+    vec3 colorM = sampleAt(vec2(0.0));
+    float lumaM = dot(colorM, toLuma);
+    float lumaMin = min(lumaM, min(min(lumaNW, lumaNE), min(lumaSW, lumaSE)));
+    float lumaMax = max(lumaM, max(max(lumaNW, lumaNE), max(lumaSW, lumaSE)));
+
+    // blur along the edge, across the direction the luma changes in
+    vec2 dir = vec2(-((lumaNW + lumaNE) - (lumaSW + lumaSE)), (lumaNW + lumaSW) - (lumaNE + lumaSE));
+    float dirReduce = max((lumaNW + lumaNE + lumaSW + lumaSE) * 0.25 * REDUCE_MUL, REDUCE_MIN);
+    float rcpDirMin = 1.0 / (min(abs(dir.x), abs(dir.y)) + dirReduce);
+    dir = clamp(dir * rcpDirMin, vec2(-SPAN_MAX), vec2(SPAN_MAX));
+
+    vec3 rgbA = 0.5 * (sampleAt(dir * (1.0 / 3.0 - 0.5)) + sampleAt(dir * (2.0 / 3.0 - 0.5)));
+    vec3 rgbB = rgbA * 0.5 + 0.25 * (sampleAt(dir * -0.5) + sampleAt(dir * 0.5));
+    float lumaB = dot(rgbB, toLuma);
+    // the wider blur crossed another edge if it left the local luma range, fall back to the narrow one
+    FragColor = vec4((lumaB < lumaMin || lumaB > lumaMax) ? rgbA : rgbB, 1.0);
+}
+`
+
+// 3x3 convolution kernels for NewKernelEffect, laid out like the pixels they weigh (row by row, top to bottom).
+var (
+	SharpenKernel = [9]float32{
+		-1, -1, -1,
+		-1, 9, -1,
+		-1, -1, -1,
+	}
+	BlurKernel = [9]float32{
+		1.0 / 16, 2.0 / 16, 1.0 / 16,
+		2.0 / 16, 4.0 / 16, 2.0 / 16,
+		1.0 / 16, 2.0 / 16, 1.0 / 16,
+	}
+	EdgeKernel = [9]float32{
+		1, 1, 1,
+		1, -8, 1,
+		1, 1, 1,
+	}
+)
+
+// One full-screen pass of a PostProcessor.
+type PostEffect struct {
+	Name    string
+	Enabled bool
+	// Set before every pass, values can be float32, int32, mgl32.Vec2/3/4 or mgl32.Mat3.
+	Uniforms map[string]any
+	// Called before the pass with the framebuffer it reads from, for effects that need to look at the
+	// whole image first. The full-screen quad is bound so it can draw passes of its own. May be nil.
+	Before func(input *Framebuffer)
+
+	shader *Shader
+}
+
+// Create a disabled effect from a fragment shader. The shader gets TexCoords from the vertex shader,
+// the previous image as sampler2D screenTexture and, if it declares it, the size of a pixel as vec2 texelSize.
+func NewPostEffect(name, fragmentShader string, uniforms map[string]any) (*PostEffect, error) {
+	program, err := BuildShaderProgram(postVertexShader, fragmentShader)
+	if err != nil {
+		return nil, fmt.Errorf("post effect %s: %w", name, err)
+	}
+	if uniforms == nil {
+		uniforms = map[string]any{}
+	}
+	return &PostEffect{Name: name, Uniforms: uniforms, shader: NewShaderObject(program)}, nil
+}
+
+// Create an effect convolving the image with a 3x3 kernel, see SharpenKernel.
+func NewKernelEffect(name string, kernel [9]float32) (*PostEffect, error) {
+	// mat3 is column major, kernel[column][row] in the shader is kernel[row*3+column] here
+	k := mgl32.Mat3(kernel).Transpose()
+	return NewPostEffect(name, kernelShader, map[string]any{"kernel": k})
+}
+
+// The built-in effects, all disabled, in the order they are applied: invert, grayscale, sharpen, blur,
+// edge detection, chromatic aberration, vignette and FXAA.
+func DefaultPostEffects() ([]*PostEffect, error) {
+	type effect struct {
+		name     string
+		shader   string
+		uniforms map[string]any
+		kernel   *[9]float32
+	}
+	list := []effect{
+		{name: "invert", shader: invertShader},
+		{name: "grayscale", shader: grayscaleShader},
+		{name: "sharpen", kernel: &SharpenKernel},
+		{name: "blur", kernel: &BlurKernel},
+		{name: "edge-detection", kernel: &EdgeKernel},
+		{name: "chromatic-aberration", shader: chromaticAberrationShader, uniforms: map[string]any{"amount": float32(0.02)}},
+		{name: "vignette", shader: vignetteShader, uniforms: map[string]any{"radius": float32(0.75), "softness": float32(0.45), "strength": float32(0.8)}},
+		{name: "fxaa", shader: fxaaShader},
+	}
+
+	effects := []*PostEffect{}
+	for _, e := range list {
+		var effect *PostEffect
+		var err error
+		if e.kernel != nil {
+			effect, err = NewKernelEffect(e.name, *e.kernel)
+		} else {
+			effect, err = NewPostEffect(e.name, e.shader, e.uniforms)
+		}
+		if err != nil {
+			for _, created := range effects {
+				created.Delete()
+			}
+			return nil, err
+		}
+		effects = append(effects, effect)
+	}
+	return effects, nil
+}
+
+func (e *PostEffect) Delete() {
+	gl.DeleteProgram(e.shader.ID)
+}
+
+func (e *PostEffect) setUniforms(texelSize mgl32.Vec2) {
+	e.shader.SetInt("screenTexture", 0)
+	if e.shader.HasUniform("texelSize") {
+		e.shader.SetVec2("texelSize", &texelSize)
+	}
+	for name, value := range e.Uniforms {
+		switch v := value.(type) {
+		case float32:
+			e.shader.SetFloat(name, v)
+		case int32:
+			e.shader.SetInt(name, v)
+		case mgl32.Vec2:
+			e.shader.SetVec2(name, &v)
+		case mgl32.Vec3:
+			e.shader.SetVec3(name, &v)
+		case mgl32.Vec4:
+			e.shader.SetVec4(name, &v)
+		case mgl32.Mat3:
+			e.shader.SetMat3(name, &v)
+		default:
+			fmt.Printf("post effect %s: uniform %s has unsupported type %T\n", e.Name, name, value)
+		}
+	}
+}
+
+// An ordered list of full-screen effects applied to a frame. Draw the scene between Begin and End,
+// each enabled effect reads the output of the one before it from a pair of ping-pong framebuffers
+// and the last one writes to Screen. With nothing enabled the scene is drawn straight to Screen.
+type PostProcessor struct {
+	Effects []*PostEffect
+
+	scene    *Framebuffer
+	pingPong [2]*Framebuffer
+	vao, vbo uint32
+	active   bool // Begin redirected the scene
+}
+
+var postQuadVertices = []float32{
+	// positions, texture coords
+	-1, 1, 0, 1,
+	-1, -1, 0, 0,
+	1, -1, 1, 0,
+
+	-1, 1, 0, 1,
+	1, -1, 1, 0,
+	1, 1, 1, 1,
+}
+
+// Create a post processor running effects in order. The framebuffers are RGBA16F so values
+// above 1 survive until the end of the chain, and follow the size of Screen.
+func NewPostProcessor(effects []*PostEffect) (*PostProcessor, error) {
+	p := &PostProcessor{Effects: effects}
+	var err error
+	p.scene, err = NewFramebuffer(FramebufferOptions{Color: []uint32{gl.RGBA16F}, Depth: gl.DEPTH24_STENCIL8})
+	if err != nil {
+		return nil, err
+	}
+	for i := range p.pingPong {
+		p.pingPong[i], err = NewFramebuffer(FramebufferOptions{Color: []uint32{gl.RGBA16F}})
+		if err != nil {
+			p.deleteFramebuffers()
+			return nil, err
+		}
+	}
+
+	gl.GenVertexArrays(1, &p.vao)
+	gl.GenBuffers(1, &p.vbo)
+	gl.BindVertexArray(p.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, p.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*len(postQuadVertices), gl.Ptr(postQuadVertices), gl.STATIC_DRAW)
+	gl.VertexAttribPointerWithOffset(0, 2, gl.FLOAT, false, 4*4, 0)
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointerWithOffset(1, 2, gl.FLOAT, false, 4*4, 2*4)
+	gl.EnableVertexAttribArray(1)
+	gl.BindVertexArray(0)
+	return p, nil
+}
+
+// The effect called name, nil if there is none.
+func (p *PostProcessor) Effect(name string) *PostEffect {
+	for _, e := range p.Effects {
+		if e.Name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+// Bind the framebuffer the scene should be drawn into.
+func (p *PostProcessor) Begin() {
+	p.active = false
+	for _, e := range p.Effects {
+		p.active = p.active || e.Enabled
+	}
+	if p.active {
+		p.scene.Bind()
+		// not every render clears depth, the window's depth buffer is cleared for them by main
+		gl.Clear(gl.DEPTH_BUFFER_BIT | gl.STENCIL_BUFFER_BIT)
+	} else {
+		Screen.Bind()
+	}
+}
+
+// Run the enabled effects on the scene, the result ends up in Screen which is left bound.
+func (p *PostProcessor) End() {
+	if !p.active {
+		return
+	}
+	enabled := []*PostEffect{}
+	for _, e := range p.Effects {
+		if e.Enabled {
+			enabled = append(enabled, e)
+		}
+	}
+
+	depthTest := gl.IsEnabled(gl.DEPTH_TEST)
+	gl.Disable(gl.DEPTH_TEST)
+	gl.BindVertexArray(p.vao)
+	gl.ActiveTexture(gl.TEXTURE0)
+
+	input := p.scene
+	for i, e := range enabled {
+		output := Screen
+		if i < len(enabled)-1 {
+			output = p.pingPong[i%2]
+		}
+		if e.Before != nil {
+			e.Before(input)
+		}
+		output.Bind()
+		e.shader.Use()
+		e.setUniforms(mgl32.Vec2{1 / float32(input.Width), 1 / float32(input.Height)})
+		gl.BindTexture(gl.TEXTURE_2D, input.Color[0])
+		gl.DrawArrays(gl.TRIANGLES, 0, 6)
+		input = output
+	}
+
+	gl.BindVertexArray(0)
+	if depthTest {
+		gl.Enable(gl.DEPTH_TEST)
+	}
+}
+
+// Release the framebuffers and the effects.
+func (p *PostProcessor) Delete() {
+	p.deleteFramebuffers()
+	for _, e := range p.Effects {
+		e.Delete()
+	}
+	gl.DeleteVertexArrays(1, &p.vao)
+	gl.DeleteBuffers(1, &p.vbo)
+}
+
+func (p *PostProcessor) deleteFramebuffers() {
+	for _, f := range append([]*Framebuffer{p.scene}, p.pingPong[:]...) {
+		if f != nil {
+			f.Delete()
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"image/color"
+	"testing"
+
+	"opgl-learn/headless"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+func TestPostProcessor(t *testing.T) {
+	withGLContext(t)
+
+	target, err := headless.NewTarget(32, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Delete()
+	screen := *Screen
+	t.Cleanup(func() { *Screen = screen })
+	SetScreen(target.Framebuffer(), target.Width, target.Height)
+
+	effects, err := DefaultPostEffects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// moves the image down a pixel, every pixel takes the one above it
+	up, err := NewKernelEffect("up", [9]float32{0, 1, 0, 0, 0, 0, 0, 0, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewPostProcessor(append(effects, up))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Delete()
+
+	// blue at the bottom, red in the top half
+	drawScene := func() {
+		p.Begin()
+		gl.ClearColor(0, 0, 1, 1)
+		gl.Clear(gl.COLOR_BUFFER_BIT)
+		gl.Enable(gl.SCISSOR_TEST)
+		gl.Scissor(0, 8, 32, 8)
+		gl.ClearColor(1, 0, 0, 1)
+		gl.Clear(gl.COLOR_BUFFER_BIT)
+		gl.Disable(gl.SCISSOR_TEST)
+		p.End()
+	}
+
+	red, blue := color.NRGBA{255, 0, 0, 255}, color.NRGBA{0, 0, 255, 255}
+	tests := []struct {
+		effects []string
+		// expected colour of image rows 7, 8 and 9, around the edge
+		want [3]color.NRGBA
+	}{
+		{nil, [3]color.NRGBA{red, blue, blue}},
+		{[]string{"invert"}, [3]color.NRGBA{{0, 255, 255, 255}, {255, 255, 0, 255}, {255, 255, 0, 255}}},
+		// ping-pong through two passes
+		{[]string{"invert", "grayscale"}, [3]color.NRGBA{{201, 201, 201, 255}, {236, 236, 236, 255}, {236, 236, 236, 255}}},
+		{[]string{"sharpen"}, [3]color.NRGBA{red, blue, blue}},
+		{[]string{"up"}, [3]color.NRGBA{red, red, blue}},
+	}
+	for _, tt := range tests {
+		for _, e := range p.Effects {
+			e.Enabled = false
+		}
+		for _, name := range tt.effects {
+			p.Effect(name).Enabled = true
+		}
+		drawScene()
+
+		img := target.ReadImage()
+		for i, want := range tt.want {
+			if got := img.NRGBAAt(16, 7+i); !closeColor(got, want) {
+				t.Errorf("%v: row %d got %v, want %v", tt.effects, 7+i, got, want)
+			}
+		}
+	}
+	if glErr := gl.GetError(); glErr != gl.NO_ERROR {
+		t.Errorf("OpenGL error 0x%x", glErr)
+	}
+}
+
+func closeColor(a, b color.NRGBA) bool {
+	near := func(x, y uint8) bool { d := int(x) - int(y); return d >= -2 && d <= 2 }
+	return near(a.R, b.R) && near(a.G, b.G) && near(a.B, b.B) && near(a.A, b.A)
+}
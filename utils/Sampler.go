@@ -0,0 +1,40 @@
+package utils
+
+import "github.com/go-gl/gl/v3.3-core/gl"
+
+// A GL sampler object. While bound to a texture unit it overrides the sampling state of whatever
+// texture is bound there, so the same texture can be sampled differently by different renders.
+type Sampler struct {
+	ID uint32
+}
+
+// Create a sampler with the wrap, border, filter and anisotropy settings of opts,
+// the texture creation fields (mipmaps, formats) are ignored.
+func NewSampler(opts TextureOptions) *Sampler {
+	s := &Sampler{}
+	gl.GenSamplers(1, &s.ID)
+	gl.SamplerParameteri(s.ID, gl.TEXTURE_WRAP_S, opts.wrapS())
+	gl.SamplerParameteri(s.ID, gl.TEXTURE_WRAP_T, opts.wrapT())
+	gl.SamplerParameteri(s.ID, gl.TEXTURE_WRAP_R, opts.wrapR())
+	gl.SamplerParameterfv(s.ID, gl.TEXTURE_BORDER_COLOR, &opts.BorderColor[0])
+	gl.SamplerParameteri(s.ID, gl.TEXTURE_MIN_FILTER, opts.minFilter())
+	gl.SamplerParameteri(s.ID, gl.TEXTURE_MAG_FILTER, opts.magFilter())
+	if level := clampAnisotropy(opts.Anisotropy); level > 1 {
+		gl.SamplerParameterf(s.ID, gl.TEXTURE_MAX_ANISOTROPY, level)
+	}
+	return s
+}
+
+// Use the sampler for texture unit (0 for GL_TEXTURE0 and so on).
+func (s *Sampler) Bind(unit uint32) {
+	gl.BindSampler(unit, s.ID)
+}
+
+// Go back to the texture's own sampling state on unit.
+func (s *Sampler) Unbind(unit uint32) {
+	gl.BindSampler(unit, 0)
+}
+
+func (s *Sampler) Delete() {
+	gl.DeleteSamplers(1, &s.ID)
+}
@@ -2,9 +2,6 @@ package utils
 
 import (
 	"fmt"
-	"image"
-	"image/draw"
-	"image/png"
 	"os"
 	"strings"
 
@@ -12,35 +9,105 @@ import (
 	"github.com/go-gl/mathgl/mgl32"
 )
 
-// Given the filepath to the vertex and fragment shader file, make the shader program
+// An OpenGL shader program ID. Alias of uint32 so it can be passed to the gl package and the Set* functions directly.
+type Program = uint32
+
+// Given the filepath to the vertex and fragment shader file, make the shader program.
+// Exits the program if anything goes wrong, use LoadShader to handle the error instead.
 func NewShader(vertexFile, fragmentFile string) (program uint32) {
+	program, err := LoadShader(vertexFile, fragmentFile)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	return program
+}
 
-	vertSrc, err := os.ReadFile(vertexFile)
+// build and compile our shader program, exits the program on failure.
+// Sources have to be null terminated.
+func CreateShaderProgram(vertexShaderSource, fragmentShaderSource string) (program uint32) {
+	program, err := BuildShaderProgram(vertexShaderSource, fragmentShaderSource)
 	if err != nil {
-		fmt.Println("Error in reading vertex shader file")
 		fmt.Println(err)
 		os.Exit(1)
 	}
+	return program
+}
 
-	fragSrc, err2 := os.ReadFile(fragmentFile)
+// Read, compile and link the vertex and fragment shader files. Errors are *ShaderError.
+// #include directives in the files are expanded, see LoadShaderWithDefines.
+func LoadShader(vertexFile, fragmentFile string) (Program, error) {
+	return LoadShaderWithDefines(vertexFile, fragmentFile, nil)
+}
+
+// Like NewShader, with defines injected into both stages.
+func NewShaderWithDefines(vertexFile, fragmentFile string, defines Defines) (program uint32) {
+	program, err := LoadShaderWithDefines(vertexFile, fragmentFile, defines)
 	if err != nil {
-		fmt.Println("Error in reading fragment shader file")
-		fmt.Println(err2)
+		fmt.Println(err)
 		os.Exit(1)
 	}
+	return program
+}
+
+// Like LoadShader, every name in defines is #define'd in both stages right after the #version line.
+// Included files are resolved relative to the including file and compiler errors point at the file
+// the line came from.
+func LoadShaderWithDefines(vertexFile, fragmentFile string, defines Defines) (Program, error) {
+	program, _, err := loadProgram(vertexFile, fragmentFile, defines)
+	return program, err
+}
+
+// Also returns every file the program was built from, including the ones pulled in by #include.
+// On failure the files read so far are returned so they can still be watched for fixes.
+func loadProgram(vertexFile, fragmentFile string, defines Defines) (Program, []string, error) {
+	vertSrc, vertLines, vertFiles, err := preprocessShader(vertexFile, defines)
+	if err != nil {
+		return 0, vertFiles, &ShaderError{Stage: StageVertex, Path: vertexFile, Err: err}
+	}
+
+	fragSrc, fragLines, fragFiles, err := preprocessShader(fragmentFile, defines)
+	files := append(vertFiles, fragFiles...)
+	if err != nil {
+		return 0, files, &ShaderError{Stage: StageFragment, Path: fragmentFile, Err: err}
+	}
 
-	return CreateShaderProgram(string(vertSrc)+"\x00", string(fragSrc)+"\x00")
+	program, err := buildProgram(
+		shaderSource{stage: StageVertex, path: vertexFile, source: vertSrc, lines: vertLines},
+		shaderSource{stage: StageFragment, path: fragmentFile, source: fragSrc, lines: fragLines},
+	)
+	return program, files, err
+}
 
+// Compile and link a program from in memory sources. Errors are *ShaderError.
+func BuildShaderProgram(vertexShaderSource, fragmentShaderSource string) (Program, error) {
+	return buildProgram(
+		shaderSource{stage: StageVertex, source: vertexShaderSource},
+		shaderSource{stage: StageFragment, source: fragmentShaderSource},
+	)
 }
 
-// build and compile our shader program
-func CreateShaderProgram(vertexShaderSource, fragmentShaderSource string) (program uint32) {
-	// build and compile our shader program
-	// ------------------------------------
+// Source code of a single shader stage. lines maps the line numbers of source back to
+// the file and line they came from, nil for in memory sources.
+type shaderSource struct {
+	stage  ShaderStage
+	path   string
+	source string
+	lines  []sourceLocation
+}
+
+func buildProgram(vertex, fragment shaderSource) (Program, error) {
 	// vertex shader
-	vertexShader := compileShader(vertexShaderSource, gl.VERTEX_SHADER)
+	vertexShader, err := compileShader(vertex)
+	if err != nil {
+		return 0, err
+	}
 	// fragment shader
-	fragmentShader := compileShader(fragmentShaderSource, gl.FRAGMENT_SHADER)
+	fragmentShader, err := compileShader(fragment)
+	if err != nil {
+		gl.DeleteShader(vertexShader)
+		return 0, err
+	}
 
 	// link shaders
 	shaderProgram := gl.CreateProgram()
@@ -48,6 +115,10 @@ func CreateShaderProgram(vertexShaderSource, fragmentShaderSource string) (progr
 	gl.AttachShader(shaderProgram, fragmentShader)
 	gl.LinkProgram(shaderProgram)
 
+	// Deleting shaders because we loaded them into the program.
+	gl.DeleteShader(vertexShader)
+	gl.DeleteShader(fragmentShader)
+
 	// check for linking errors
 	var success int32
 	gl.GetProgramiv(shaderProgram, gl.LINK_STATUS, &success)
@@ -57,21 +128,32 @@ func CreateShaderProgram(vertexShaderSource, fragmentShaderSource string) (progr
 
 		log := strings.Repeat("\x00", int(infoLen+1))
 		gl.GetProgramInfoLog(shaderProgram, infoLen, nil, gl.Str(log))
+		gl.DeleteProgram(shaderProgram)
 
-		fmt.Printf("failed to link shader program %v: %v\n", shaderProgram, log)
-		os.Exit(1)
+		var path string
+		if vertex.path != "" && fragment.path != "" {
+			path = vertex.path + ", " + fragment.path
+		}
+		return 0, newShaderError(StageLink, path, nil, log)
 	}
 
-	// Deleting shaders because we loaded them into the program.
-	gl.DeleteShader(vertexShader)
-	gl.DeleteShader(fragmentShader)
-
-	return shaderProgram
+	bindUniformBlocks(shaderProgram)
+	return shaderProgram, nil
 }
 
-func compileShader(ShaderSource string, ShaderType uint32) uint32 {
-	shader := gl.CreateShader(ShaderType)
-	csources, free := gl.Strs(ShaderSource)
+func compileShader(src shaderSource) (uint32, error) {
+	var shaderType uint32 = gl.VERTEX_SHADER
+	if src.stage == StageFragment {
+		shaderType = gl.FRAGMENT_SHADER
+	}
+
+	source := src.source
+	if !strings.HasSuffix(source, "\x00") {
+		source += "\x00"
+	}
+
+	shader := gl.CreateShader(shaderType)
+	csources, free := gl.Strs(source)
 	gl.ShaderSource(shader, 1, csources, nil)
 	free()
 	gl.CompileShader(shader)
@@ -85,12 +167,12 @@ func compileShader(ShaderSource string, ShaderType uint32) uint32 {
 
 		log := strings.Repeat("\x00", int(infoLen+1))
 		gl.GetShaderInfoLog(shader, infoLen, nil, gl.Str(log))
+		gl.DeleteShader(shader)
 
-		fmt.Printf("failed to compile shader %v: %v\n", shader, log)
-		os.Exit(1)
+		return 0, newShaderError(src.stage, src.path, src.lines, log)
 	}
 
-	return shader
+	return shader, nil
 }
 
 // utility uniform functions
@@ -133,66 +215,3 @@ func SetMat3(program uint32, name string, value *mgl32.Mat3) {
 func SetMat4(program uint32, name string, value *mgl32.Mat4) {
 	gl.UniformMatrix4fv(gl.GetUniformLocation(program, gl.Str(name+"\x00")), 1, false, &value[0])
 }
-
-// load and create a texture
-func New2DTexture(wrap_s, wrap_t, min_filter, max_filter int32, texturePath string) uint32 {
-	var texture uint32
-	gl.GenTextures(1, &texture)
-	gl.BindTexture(gl.TEXTURE_2D, texture) // all upcoming GL_TEXTURE_2D operations now have effect on this texture object
-	// set the texture wrapping parameters
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, wrap_s) // set texture wrapping to GL_REPEAT (default wrapping method)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, wrap_t)
-	// set texture filtering parameters
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, min_filter)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, max_filter)
-
-	// load image, create texture and generate mipmaps
-	file, err := os.Open(texturePath)
-	if err != nil {
-		fmt.Println("Cannot open texture file")
-		fmt.Println(err)
-		os.Exit(1)
-	}
-	defer file.Close()
-
-	img, err2 := png.Decode(file)
-	if err2 != nil {
-		fmt.Println("error decoding the image")
-		fmt.Println(err2)
-		os.Exit(1)
-	}
-
-	rgba := image.NewRGBA(img.Bounds())
-	if rgba.Stride != rgba.Rect.Size().X*4 {
-		fmt.Println("unsupported stride")
-		os.Exit(1)
-	}
-	draw.Draw(rgba, rgba.Bounds(), img, image.Point{0, 0}, draw.Src)
-
-	// Flip the image vertically.
-	bounds := img.Bounds()
-	width, height := bounds.Dx(), bounds.Dy()
-	flipped := image.NewRGBA(bounds)
-
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			originalPixel := img.At(x, y)
-			flipped.Set(x, height-y-1, originalPixel)
-		}
-	}
-
-	gl.TexImage2D(
-		gl.TEXTURE_2D,
-		0,
-		gl.RGBA,
-		int32(img.Bounds().Max.X),
-		int32(img.Bounds().Max.Y),
-		0,
-		gl.RGBA,
-		gl.UNSIGNED_BYTE,
-		gl.Ptr(flipped.Pix),
-	)
-	gl.GenerateMipmap(gl.TEXTURE_2D)
-	gl.BindTexture(gl.TEXTURE_2D, 0)
-	return texture
-}
@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The step of building a shader program that failed.
+type ShaderStage string
+
+const (
+	StageVertex   ShaderStage = "vertex"
+	StageFragment ShaderStage = "fragment"
+	StageLink     ShaderStage = "link"
+)
+
+// Returned when reading, compiling or linking a shader fails.
+type ShaderError struct {
+	Stage ShaderStage
+	// file the failing stage was loaded from, empty for in memory sources
+	Path string
+	// the raw info log from the driver
+	Log string
+	// the info log split into messages, with the file and line they point at when the driver gave one
+	Lines []ShaderLogLine
+	// set when the error did not come from the driver, e.g. the file could not be read
+	Err error
+}
+
+// A single message of the driver's info log.
+type ShaderLogLine struct {
+	Path    string // empty for in memory sources
+	Line    int    // 0 when the message has no location
+	Message string
+}
+
+func (e *ShaderError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s shader %s: %v", e.Stage, e.Path, e.Err)
+	}
+
+	var sb strings.Builder
+	if e.Stage == StageLink {
+		sb.WriteString("failed to link shader program")
+	} else {
+		fmt.Fprintf(&sb, "failed to compile %s shader", e.Stage)
+	}
+	if e.Path != "" {
+		fmt.Fprintf(&sb, " %s", e.Path)
+	}
+	for _, line := range e.Lines {
+		sb.WriteString("\n\t")
+		sb.WriteString(line.String())
+	}
+	return sb.String()
+}
+
+func (e *ShaderError) Unwrap() error {
+	return e.Err
+}
+
+func (l ShaderLogLine) String() string {
+	if l.Line == 0 {
+		return l.Message
+	}
+	if l.Path == "" {
+		return fmt.Sprintf("line %d: %s", l.Line, l.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", l.Path, l.Line, l.Message)
+}
+
+func newShaderError(stage ShaderStage, path string, lines []sourceLocation, log string) *ShaderError {
+	log = strings.TrimRight(log, "\x00\n ")
+	return &ShaderError{
+		Stage: stage,
+		Path:  path,
+		Log:   log,
+		Lines: parseInfoLog(log, lines),
+	}
+}
+
+// Info log locations differ per driver, all of them give a source string number and the line:
+//
+//	Mesa:          0:12(5): error: ...
+//	NVIDIA:        0(12) : error C0000: ...
+//	AMD/Intel/macOS: ERROR: 0:12: ...
+var infoLogPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^(\d+):(\d+)\(\d+\):\s*(.*)$`),
+	regexp.MustCompile(`^(\d+)\((\d+)\)\s*:\s*(.*)$`),
+	regexp.MustCompile(`^(?:ERROR|WARNING):\s*(\d+):(\d+):\s*(.*)$`),
+}
+
+// Split an info log into messages. locations maps the line numbers of the compiled source
+// back to the file they came from, see preprocessShader. Without it the line is kept as is.
+// Source string numbers are ignored, shaders are always uploaded as a single string.
+func parseInfoLog(log string, locations []sourceLocation) []ShaderLogLine {
+	var lines []ShaderLogLine
+	for _, raw := range strings.Split(log, "\n") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		line := ShaderLogLine{Message: raw}
+		for _, pattern := range infoLogPatterns {
+			match := pattern.FindStringSubmatch(raw)
+			if match == nil {
+				continue
+			}
+			line.Line, _ = strconv.Atoi(match[2])
+			line.Message = match[3]
+			if line.Line > 0 && line.Line <= len(locations) {
+				loc := locations[line.Line-1]
+				line.Path, line.Line = loc.Path, loc.Line
+			}
+			break
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,36 @@
+package utils
+
+import "testing"
+
+func TestParseInfoLog(t *testing.T) {
+	locations := []sourceLocation{
+		{"main.glsl", 1},
+		{"common/lights.glsl", 1},
+		{"common/lights.glsl", 2},
+		{"main.glsl", 3},
+	}
+
+	tests := []struct {
+		name string
+		log  string
+		want ShaderLogLine
+	}{
+		{"mesa", "0:4(5): error: `foo' undeclared", ShaderLogLine{"main.glsl", 3, "error: `foo' undeclared"}},
+		{"nvidia", "0(3) : error C1008: undefined variable \"foo\"", ShaderLogLine{"common/lights.glsl", 2, "error C1008: undefined variable \"foo\""}},
+		{"amd", "ERROR: 0:2: 'foo' : undeclared identifier", ShaderLogLine{"common/lights.glsl", 1, "'foo' : undeclared identifier"}},
+		{"past the end", "0:9(1): error: oops", ShaderLogLine{"", 9, "error: oops"}},
+		{"no location", "error: linking with uncompiled shader", ShaderLogLine{"", 0, "error: linking with uncompiled shader"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := parseInfoLog(tt.log+"\n\n", locations)
+			if len(lines) != 1 {
+				t.Fatalf("got %d lines, want 1: %v", len(lines), lines)
+			}
+			if lines[0] != tt.want {
+				t.Errorf("got %+v, want %+v", lines[0], tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// How often the shader files are checked for changes.
+const shaderPollInterval = 250 * time.Millisecond
+
+// Keeps track of the files shader programs were built from and rebuilds them when one changes.
+// The zero value is ready to use. All methods have to be called from the thread owning the GL context.
+type ShaderManager struct {
+	shaders  []*watchedShader
+	lastPoll time.Time
+}
+
+type watchedShader struct {
+	// points at the render's program ID, replaced on a successful rebuild
+	program                  *Program
+	vertexFile, fragmentFile string
+	defines                  Defines
+	onReload                 func(program Program)
+
+	// every file the program is built from, includes too
+	files    []string
+	modTimes map[string]time.Time
+	// last rebuild error, kept so a broken file is only reported once
+	lastErr error
+}
+
+// Build the program like NewShader and store it in *program. Whenever one of the files changes
+// Poll rebuilds it and swaps *program to the new ID, keeping the old program if the build fails.
+// onReload, which may be nil, is called with the new program so uniforms set only once
+// (like sampler units) can be set again.
+func (sm *ShaderManager) Watch(program *Program, vertexFile, fragmentFile string, onReload func(program Program)) {
+	sm.WatchWithDefines(program, vertexFile, fragmentFile, nil, onReload)
+}
+
+// Like Watch, with defines injected into the shaders on every build. Included files are watched as well.
+func (sm *ShaderManager) WatchWithDefines(program *Program, vertexFile, fragmentFile string, defines Defines, onReload func(program Program)) {
+	ws := &watchedShader{
+		program:      program,
+		vertexFile:   vertexFile,
+		fragmentFile: fragmentFile,
+		defines:      defines,
+		onReload:     onReload,
+	}
+
+	built, files, err := loadProgram(vertexFile, fragmentFile, defines)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	ws.files = files
+	ws.modTimes = ws.readModTimes()
+
+	*program = built
+	sm.shaders = append(sm.shaders, ws)
+}
+
+// Rebuild the programs whose files changed since they were last built. Cheap enough to call every frame,
+// the files are only checked every shaderPollInterval.
+func (sm *ShaderManager) Poll() {
+	if time.Since(sm.lastPoll) < shaderPollInterval {
+		return
+	}
+	sm.lastPoll = time.Now()
+
+	for _, ws := range sm.shaders {
+		modTimes := ws.readModTimes()
+		if modTimes == nil || sameModTimes(modTimes, ws.modTimes) {
+			continue
+		}
+		ws.modTimes = modTimes
+		ws.reload()
+	}
+}
+
+func (ws *watchedShader) reload() {
+	program, files, err := loadProgram(ws.vertexFile, ws.fragmentFile, ws.defines)
+	if len(files) > 0 {
+		// includes may have been added or removed
+		ws.files = files
+		ws.modTimes = ws.readModTimes()
+	}
+	if err != nil {
+		if ws.lastErr == nil || ws.lastErr.Error() != err.Error() {
+			fmt.Println(err)
+			fmt.Println("keeping the previous shader program")
+		}
+		ws.lastErr = err
+		return
+	}
+	ws.lastErr = nil
+
+	gl.DeleteProgram(*ws.program)
+	*ws.program = program
+	if ws.onReload != nil {
+		ws.onReload(program)
+	}
+	fmt.Printf("reloaded shader %s, %s\n", ws.vertexFile, ws.fragmentFile)
+}
+
+// Modification times of every file the program is built from, nil if one can't be read
+// (editors often remove the file for a moment while saving).
+func (ws *watchedShader) readModTimes() map[string]time.Time {
+	modTimes := make(map[string]time.Time)
+	for _, file := range ws.files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil
+		}
+		modTimes[file] = info.ModTime()
+	}
+	return modTimes
+}
+
+func sameModTimes(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for file, t := range a {
+		if !t.Equal(b[file]) {
+			return false
+		}
+	}
+	return true
+}
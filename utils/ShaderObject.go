@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// A shader program with its uniform locations cached. The active uniforms are read from the program
+// once instead of calling glGetUniformLocation on every Set, and setting a uniform the program doesn't have
+// prints a warning the first time, which catches misspelled names.
+//
+// ID can be swapped for another program (e.g. by ShaderManager.Watch(&shader.ID, ...)),
+// the cache is rebuilt on the next Set.
+type Shader struct {
+	ID Program
+
+	// the program the cache below was built for
+	cachedID Program
+	uniforms map[string]int32
+	warned   map[string]bool
+}
+
+// Wrap an already linked program.
+func NewShaderObject(program Program) *Shader {
+	s := &Shader{ID: program}
+	s.introspect()
+	return s
+}
+
+// Load the shader files like NewShader (exits on failure) and wrap the program.
+func LoadShaderObject(vertexFile, fragmentFile string) *Shader {
+	return NewShaderObject(NewShader(vertexFile, fragmentFile))
+}
+
+func (s *Shader) Use() {
+	gl.UseProgram(s.ID)
+}
+
+// Location of an active uniform, -1 if the program doesn't have it.
+// Array elements can be looked up by "name" or "name[i]", struct members by "name.member".
+func (s *Shader) Location(name string) int32 {
+	if s.ID != s.cachedID || s.uniforms == nil {
+		s.introspect()
+	}
+	if loc, ok := s.uniforms[name]; ok {
+		return loc
+	}
+	if !s.warned[name] {
+		s.warned[name] = true
+		fmt.Printf("shader %d: uniform %q is not active (misspelled, or optimised out by the compiler)\n", s.ID, name)
+	}
+	return -1
+}
+
+// Whether the program has an active uniform called name, without the warning Location prints.
+func (s *Shader) HasUniform(name string) bool {
+	if s.ID != s.cachedID || s.uniforms == nil {
+		s.introspect()
+	}
+	_, ok := s.uniforms[name]
+	return ok
+}
+
+// Read every active uniform of the program into the cache.
+func (s *Shader) introspect() {
+	s.cachedID = s.ID
+	s.uniforms = make(map[string]int32)
+	s.warned = make(map[string]bool)
+
+	var count, maxLength int32
+	gl.GetProgramiv(s.ID, gl.ACTIVE_UNIFORMS, &count)
+	gl.GetProgramiv(s.ID, gl.ACTIVE_UNIFORM_MAX_LENGTH, &maxLength)
+	if count == 0 || maxLength == 0 {
+		return
+	}
+
+	buf := make([]uint8, maxLength)
+	for i := int32(0); i < count; i++ {
+		var length, size int32
+		var xtype uint32
+		gl.GetActiveUniform(s.ID, uint32(i), maxLength, &length, &size, &xtype, &buf[0])
+		name := string(buf[:length])
+
+		loc := gl.GetUniformLocation(s.ID, gl.Str(name+"\x00"))
+		if loc < 0 {
+			// members of uniform blocks have no location
+			continue
+		}
+		s.uniforms[name] = loc
+
+		// arrays of basic types are reported once as "name[0]" with their size
+		if base, ok := strings.CutSuffix(name, "[0]"); ok {
+			s.uniforms[base] = loc
+			for j := int32(1); j < size; j++ {
+				element := fmt.Sprintf("%s[%d]", base, j)
+				s.uniforms[element] = gl.GetUniformLocation(s.ID, gl.Str(element+"\x00"))
+			}
+		}
+	}
+}
+
+// uniform setters, the program has to be in use like with the Set* functions
+
+/*
+boolValue should either be GL_FALSE or GL_TRUE
+*/
+func (s *Shader) SetBool(name string, boolValue int32) {
+	gl.Uniform1i(s.Location(name), boolValue)
+}
+
+func (s *Shader) SetInt(name string, value int32) {
+	gl.Uniform1i(s.Location(name), value)
+}
+
+func (s *Shader) SetFloat(name string, value float32) {
+	gl.Uniform1f(s.Location(name), value)
+}
+
+func (s *Shader) SetVec2(name string, value *mgl32.Vec2) {
+	gl.Uniform2fv(s.Location(name), 1, &value[0])
+}
+
+func (s *Shader) SetVec3(name string, value *mgl32.Vec3) {
+	gl.Uniform3fv(s.Location(name), 1, &value[0])
+}
+
+func (s *Shader) SetVec4(name string, value *mgl32.Vec4) {
+	gl.Uniform4fv(s.Location(name), 1, &value[0])
+}
+
+func (s *Shader) SetMat2(name string, value *mgl32.Mat2) {
+	gl.UniformMatrix2fv(s.Location(name), 1, false, &value[0])
+}
+
+func (s *Shader) SetMat3(name string, value *mgl32.Mat3) {
+	gl.UniformMatrix3fv(s.Location(name), 1, false, &value[0])
+}
+
+func (s *Shader) SetMat4(name string, value *mgl32.Mat4) {
+	gl.UniformMatrix4fv(s.Location(name), 1, false, &value[0])
+}
@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Preprocessor macros injected into a shader right after its #version line, NAME -> value.
+type Defines map[string]string
+
+// Where a line of a preprocessed shader came from.
+type sourceLocation struct {
+	Path string
+	Line int
+}
+
+var (
+	includeDirective = regexp.MustCompile(`^\s*#\s*include\s+"([^"]+)"\s*$`)
+	versionDirective = regexp.MustCompile(`(?m)^\s*#\s*version\b`)
+)
+
+// Expands #include "file" directives, paths are relative to the including file.
+// Every file is included at most once per shader so headers don't need include guards.
+//
+// Instead of emitting #line directives the preprocessor records where every output line came from,
+// drivers don't agree on how they report source string numbers (Mesa gives 0 for most expression errors)
+// but the line number is always right. The returned locations are indexed by output line - 1 and
+// files lists every file that was read.
+//
+// Includes are expanded textually, they are not affected by #if blocks.
+func preprocessShader(path string, defines Defines) (string, []sourceLocation, []string, error) {
+	p := preprocessor{
+		defines:  defines,
+		included: make(map[string]bool),
+	}
+	err := p.process(filepath.Clean(path))
+	return p.out.String(), p.lines, p.files, err
+}
+
+type preprocessor struct {
+	defines  Defines
+	out      strings.Builder
+	lines    []sourceLocation
+	files    []string
+	included map[string]bool
+	// files currently being expanded, to detect include cycles
+	stack []string
+}
+
+func (p *preprocessor) process(path string) error {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		if len(p.stack) > 0 {
+			return fmt.Errorf("%s: %w", p.stack[len(p.stack)-1], err)
+		}
+		return err
+	}
+
+	isRoot := len(p.files) == 0
+	p.files = append(p.files, path)
+	p.included[path] = true
+	p.stack = append(p.stack, path)
+	defer func() { p.stack = p.stack[:len(p.stack)-1] }()
+
+	if isRoot && !versionDirective.Match(source) {
+		// no #version to put the defines after, so they go first
+		p.writeDefines(path)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(source))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+
+		if versionDirective.MatchString(line) {
+			if !isRoot {
+				return fmt.Errorf("%s:%d: #version is only allowed in the main shader file", path, lineNo)
+			}
+			p.writeLine(line, path, lineNo)
+			p.writeDefines(path)
+			continue
+		}
+
+		match := includeDirective.FindStringSubmatch(line)
+		if match == nil {
+			p.writeLine(line, path, lineNo)
+			continue
+		}
+
+		includePath := filepath.Clean(filepath.Join(filepath.Dir(path), match[1]))
+		for _, open := range p.stack {
+			if open == includePath {
+				return fmt.Errorf("%s:%d: include cycle %s -> %s", path, lineNo, strings.Join(p.stack, " -> "), includePath)
+			}
+		}
+		if p.included[includePath] {
+			// already included, keep an empty line in its place
+			p.writeLine("", path, lineNo)
+			continue
+		}
+
+		if err := p.process(includePath); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (p *preprocessor) writeLine(line, path string, lineNo int) {
+	p.out.WriteString(line)
+	p.out.WriteString("\n")
+	p.lines = append(p.lines, sourceLocation{Path: path, Line: lineNo})
+}
+
+// Injected defines are attributed to line 0 of the main file.
+func (p *preprocessor) writeDefines(path string) {
+	names := make([]string, 0, len(p.defines))
+	for name := range p.defines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p.writeLine(fmt.Sprintf("#define %s %s", name, p.defines[name]), path, 0)
+	}
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeShaderFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestPreprocessShader(t *testing.T) {
+	dir := writeShaderFiles(t, map[string]string{
+		"main.glsl":     "#version 330 core\n#include \"common/a.glsl\"\n#include \"common/b.glsl\"\nvoid main() {}\n",
+		"common/a.glsl": "// a\n#include \"b.glsl\"\n",
+		"common/b.glsl": "// b\n",
+	})
+	main := filepath.Join(dir, "main.glsl")
+	a := filepath.Join(dir, "common", "a.glsl")
+	b := filepath.Join(dir, "common", "b.glsl")
+
+	source, lines, files, err := preprocessShader(main, Defines{"NR_LIGHTS": "4", "A": "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantSource := "#version 330 core\n#define A 1\n#define NR_LIGHTS 4\n// a\n// b\n\nvoid main() {}\n"
+	if source != wantSource {
+		t.Errorf("source:\n%s\nwant:\n%s", source, wantSource)
+	}
+
+	wantLines := []sourceLocation{{main, 1}, {main, 0}, {main, 0}, {a, 1}, {b, 1}, {main, 3}, {main, 4}}
+	if len(lines) != len(wantLines) {
+		t.Fatalf("got %d line locations, want %d: %v", len(lines), len(wantLines), lines)
+	}
+	for i := range wantLines {
+		if lines[i] != wantLines[i] {
+			t.Errorf("line %d: got %v, want %v", i+1, lines[i], wantLines[i])
+		}
+	}
+
+	if strings.Join(files, ",") != strings.Join([]string{main, a, b}, ",") {
+		t.Errorf("files: got %v", files)
+	}
+}
+
+func TestPreprocessShaderErrors(t *testing.T) {
+	dir := writeShaderFiles(t, map[string]string{
+		"cycle.glsl":   "#version 330 core\n#include \"loop.glsl\"\n",
+		"loop.glsl":    "#include \"cycle.glsl\"\n",
+		"missing.glsl": "#version 330 core\n#include \"nope.glsl\"\n",
+		"version.glsl": "#version 330 core\n#include \"v.glsl\"\n",
+		"v.glsl":       "#version 330 core\n",
+	})
+
+	tests := []struct {
+		file, want string
+	}{
+		{"cycle.glsl", "include cycle"},
+		{"missing.glsl", "nope.glsl"},
+		{"version.glsl", "#version is only allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			_, _, _, err := preprocessShader(filepath.Join(dir, tt.file), nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Everything a linked program consumes, as reported by the driver. Only active
+// (actually used) inputs are listed, the compiler removes everything else.
+type ShaderReflection struct {
+	Attributes    []AttributeInfo
+	Uniforms      []UniformInfo
+	Samplers      []SamplerInfo
+	UniformBlocks []UniformBlockInfo
+}
+
+// An active vertex shader input.
+type AttributeInfo struct {
+	Name     string
+	Location int32
+	Type     uint32 // e.g. gl.FLOAT_VEC3, see GLTypeName
+	Size     int32  // array size, 1 for non arrays
+}
+
+// An active uniform. Arrays of basic types are listed once as "name[0]" with their size,
+// arrays of structs have an entry per element and member.
+type UniformInfo struct {
+	Name     string
+	Location int32 // -1 for members of uniform blocks
+	Type     uint32
+	Size     int32
+	Block    int32 // index into UniformBlocks, -1 for the default block
+	Offset   int32 // byte offset inside the block, -1 for the default block
+}
+
+// A sampler uniform and the texture unit it currently reads from.
+type SamplerInfo struct {
+	Name     string
+	Location int32
+	Type     uint32
+	Unit     int32
+}
+
+// An active uniform block and its members.
+type UniformBlockInfo struct {
+	Name    string
+	Index   uint32
+	Binding uint32
+	Size    int32 // in bytes
+	Members []string
+}
+
+// A vertex attribute a VAO provides, to check against what a shader consumes.
+type VertexAttribute struct {
+	Name     string
+	Location int32
+	Type     uint32
+}
+
+// Query the reflection description of a linked program. Sampler units are read at call time,
+// so call it after setting them if they matter.
+func ReflectProgram(program Program) *ShaderReflection {
+	r := &ShaderReflection{}
+
+	var count, maxLength int32
+	gl.GetProgramiv(program, gl.ACTIVE_ATTRIBUTES, &count)
+	gl.GetProgramiv(program, gl.ACTIVE_ATTRIBUTE_MAX_LENGTH, &maxLength)
+	buf := make([]uint8, maxLength+1)
+	for i := int32(0); i < count; i++ {
+		var length, size int32
+		var xtype uint32
+		gl.GetActiveAttrib(program, uint32(i), maxLength+1, &length, &size, &xtype, &buf[0])
+		name := string(buf[:length])
+		r.Attributes = append(r.Attributes, AttributeInfo{
+			Name:     name,
+			Location: gl.GetAttribLocation(program, gl.Str(name+"\x00")),
+			Type:     xtype,
+			Size:     size,
+		})
+	}
+
+	gl.GetProgramiv(program, gl.ACTIVE_UNIFORMS, &count)
+	gl.GetProgramiv(program, gl.ACTIVE_UNIFORM_MAX_LENGTH, &maxLength)
+	buf = make([]uint8, maxLength+1)
+	for i := int32(0); i < count; i++ {
+		var length, size, block, offset int32
+		var xtype uint32
+		index := uint32(i)
+		gl.GetActiveUniform(program, index, maxLength+1, &length, &size, &xtype, &buf[0])
+		gl.GetActiveUniformsiv(program, 1, &index, gl.UNIFORM_BLOCK_INDEX, &block)
+		gl.GetActiveUniformsiv(program, 1, &index, gl.UNIFORM_OFFSET, &offset)
+		name := string(buf[:length])
+
+		uniform := UniformInfo{
+			Name:     name,
+			Location: gl.GetUniformLocation(program, gl.Str(name+"\x00")),
+			Type:     xtype,
+			Size:     size,
+			Block:    block,
+			Offset:   offset,
+		}
+		r.Uniforms = append(r.Uniforms, uniform)
+
+		if isSamplerType(xtype) && uniform.Location >= 0 {
+			var unit int32
+			gl.GetUniformiv(program, uniform.Location, &unit)
+			r.Samplers = append(r.Samplers, SamplerInfo{Name: name, Location: uniform.Location, Type: xtype, Unit: unit})
+		}
+	}
+
+	gl.GetProgramiv(program, gl.ACTIVE_UNIFORM_BLOCKS, &count)
+	for i := int32(0); i < count; i++ {
+		index := uint32(i)
+		var length, binding, size int32
+		gl.GetActiveUniformBlockiv(program, index, gl.UNIFORM_BLOCK_NAME_LENGTH, &length)
+		gl.GetActiveUniformBlockiv(program, index, gl.UNIFORM_BLOCK_BINDING, &binding)
+		gl.GetActiveUniformBlockiv(program, index, gl.UNIFORM_BLOCK_DATA_SIZE, &size)
+		name := make([]uint8, length+1)
+		gl.GetActiveUniformBlockName(program, index, length+1, nil, &name[0])
+
+		block := UniformBlockInfo{
+			Name:    strings.TrimRight(string(name), "\x00"),
+			Index:   index,
+			Binding: uint32(binding),
+			Size:    size,
+		}
+		for _, uniform := range r.Uniforms {
+			if uniform.Block == i {
+				block.Members = append(block.Members, uniform.Name)
+			}
+		}
+		r.UniformBlocks = append(r.UniformBlocks, block)
+	}
+
+	return r
+}
+
+// Reflection description of the wrapped program.
+func (s *Shader) Reflect() *ShaderReflection {
+	return ReflectProgram(s.ID)
+}
+
+// Find an active attribute by name.
+func (r *ShaderReflection) Attribute(name string) (AttributeInfo, bool) {
+	for _, attribute := range r.Attributes {
+		if attribute.Name == name {
+			return attribute, true
+		}
+	}
+	return AttributeInfo{}, false
+}
+
+// Find an active uniform by name, arrays can be looked up with or without "[0]".
+func (r *ShaderReflection) Uniform(name string) (UniformInfo, bool) {
+	for _, uniform := range r.Uniforms {
+		if uniform.Name == name || uniform.Name == name+"[0]" {
+			return uniform, true
+		}
+	}
+	return UniformInfo{}, false
+}
+
+// Check that every attribute the shader consumes is provided by layout at the same location
+// with the same type, e.g. ValidateVertexLayout(MeshVertexLayout) for shaders used with Mesh.
+func (r *ShaderReflection) ValidateVertexLayout(layout []VertexAttribute) error {
+	var problems []string
+	for _, attribute := range r.Attributes {
+		if strings.HasPrefix(attribute.Name, "gl_") {
+			continue
+		}
+
+		var provided *VertexAttribute
+		for i := range layout {
+			if layout[i].Location == attribute.Location {
+				provided = &layout[i]
+				break
+			}
+		}
+
+		switch {
+		case provided == nil:
+			problems = append(problems, fmt.Sprintf("%s (location %d) is not provided", attribute.Name, attribute.Location))
+		case provided.Type != attribute.Type:
+			problems = append(problems, fmt.Sprintf("%s (location %d) is %s in the shader but %s (%s) is provided",
+				attribute.Name, attribute.Location, GLTypeName(attribute.Type), GLTypeName(provided.Type), provided.Name))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("vertex layout mismatch: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+var glTypeNames = map[uint32]string{
+	gl.FLOAT:             "float",
+	gl.FLOAT_VEC2:        "vec2",
+	gl.FLOAT_VEC3:        "vec3",
+	gl.FLOAT_VEC4:        "vec4",
+	gl.INT:               "int",
+	gl.INT_VEC2:          "ivec2",
+	gl.INT_VEC3:          "ivec3",
+	gl.INT_VEC4:          "ivec4",
+	gl.UNSIGNED_INT:      "uint",
+	gl.UNSIGNED_INT_VEC2: "uvec2",
+	gl.UNSIGNED_INT_VEC3: "uvec3",
+	gl.UNSIGNED_INT_VEC4: "uvec4",
+	gl.BOOL:              "bool",
+	gl.BOOL_VEC2:         "bvec2",
+	gl.BOOL_VEC3:         "bvec3",
+	gl.BOOL_VEC4:         "bvec4",
+	gl.FLOAT_MAT2:        "mat2",
+	gl.FLOAT_MAT3:        "mat3",
+	gl.FLOAT_MAT4:        "mat4",
+
+	gl.SAMPLER_1D:              "sampler1D",
+	gl.SAMPLER_2D:              "sampler2D",
+	gl.SAMPLER_3D:              "sampler3D",
+	gl.SAMPLER_CUBE:            "samplerCube",
+	gl.SAMPLER_2D_SHADOW:       "sampler2DShadow",
+	gl.SAMPLER_2D_ARRAY:        "sampler2DArray",
+	gl.SAMPLER_2D_ARRAY_SHADOW: "sampler2DArrayShadow",
+	gl.SAMPLER_CUBE_SHADOW:     "samplerCubeShadow",
+	gl.SAMPLER_2D_MULTISAMPLE:  "sampler2DMS",
+	gl.INT_SAMPLER_2D:          "isampler2D",
+	gl.UNSIGNED_INT_SAMPLER_2D: "usampler2D",
+}
+
+// GLSL name of a type returned by the reflection, e.g. gl.FLOAT_VEC3 -> "vec3".
+func GLTypeName(xtype uint32) string {
+	if name, ok := glTypeNames[xtype]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%x", xtype)
+}
+
+func isSamplerType(xtype uint32) bool {
+	return strings.Contains(GLTypeName(xtype), "sampler")
+}
@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"opgl-learn/headless"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Make a software OpenGL context current for the rest of the test, skips the test when there is none.
+func withGLContext(t *testing.T) {
+	t.Helper()
+	runtime.LockOSThread()
+	ctx, err := headless.NewContext()
+	if err != nil {
+		runtime.UnlockOSThread()
+		t.Skipf("no software OpenGL context: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx.Destroy()
+		runtime.UnlockOSThread()
+	})
+}
+
+func TestReflectModelShader(t *testing.T) {
+	withGLContext(t)
+
+	program, err := LoadShader("../shaders/ModelLoading/1-ModelVert.glsl", "../shaders/ModelLoading/1-ModelFrag.glsl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gl.DeleteProgram(program)
+	r := ReflectProgram(program)
+
+	if a, ok := r.Attribute("aTexCoords"); !ok || a.Location != 2 || a.Type != gl.FLOAT_VEC2 {
+		t.Errorf("aTexCoords: got %+v, %v", a, ok)
+	}
+	if u, ok := r.Uniform("projection"); !ok || u.Type != gl.FLOAT_MAT4 || u.Block != -1 {
+		t.Errorf("projection: got %+v, %v", u, ok)
+	}
+	if len(r.Samplers) != 1 || r.Samplers[0].Type != gl.SAMPLER_2D || r.Samplers[0].Unit != 0 {
+		t.Errorf("samplers: got %+v", r.Samplers)
+	}
+
+	if err := r.ValidateVertexLayout(MeshVertexLayout); err != nil {
+		t.Errorf("mesh layout: %v", err)
+	}
+	wrong := []VertexAttribute{{Name: "Position", Location: 0, Type: gl.FLOAT_VEC3}, {Name: "Color", Location: 2, Type: gl.FLOAT_VEC3}}
+	if err := r.ValidateVertexLayout(wrong); err == nil || !strings.Contains(err.Error(), "aTexCoords") {
+		t.Errorf("expected a mismatch on aTexCoords, got %v", err)
+	}
+}
+
+func TestReflectUniformBlock(t *testing.T) {
+	withGLContext(t)
+
+	program, err := BuildShaderProgram(`#version 330 core
+layout (location = 0) in vec3 aPos;
+layout (std140) uniform Camera {
+    mat4 projection;
+    mat4 view;
+};
+void main() { gl_Position = projection * view * vec4(aPos, 1.0); }
+`, `#version 330 core
+out vec4 FragColor;
+uniform float weights[3];
+void main() { FragColor = vec4(weights[0] + weights[2]); }
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gl.DeleteProgram(program)
+	r := ReflectProgram(program)
+
+	if len(r.UniformBlocks) != 1 {
+		t.Fatalf("got %d uniform blocks, want 1", len(r.UniformBlocks))
+	}
+	block := r.UniformBlocks[0]
+	if block.Name != "Camera" || block.Size != 128 || len(block.Members) != 2 {
+		t.Errorf("block: got %+v", block)
+	}
+	if u, ok := r.Uniform("view"); !ok || u.Offset != 64 || u.Location != -1 {
+		t.Errorf("view: got %+v, %v", u, ok)
+	}
+	if u, ok := r.Uniform("weights"); !ok || u.Size != 3 || u.Type != gl.FLOAT {
+		t.Errorf("weights: got %+v, %v", u, ok)
+	}
+}
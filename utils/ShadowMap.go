@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"math"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// The depth pass only needs the positions, the shaders are kept here so ShadowMap works from any directory.
+const shadowDepthVertexShader = `#version 330 core
+layout (location = 0) in vec3 aPos;
+
+uniform mat4 lightSpaceMatrix;
+uniform mat4 model;
+
+void main()
+{
+    gl_Position = lightSpaceMatrix * model * vec4(aPos, 1.0);
+}
+`
+
+const shadowDepthFragmentShader = `#version 330 core
+
+void main()
+{
+    // depth is written by the rasteriser
+}
+`
+
+// An axis aligned box in world space, what a light has to cover to shadow the scene.
+type Bounds struct {
+	Min, Max mgl32.Vec3
+}
+
+// The smallest box around points.
+func BoundsOf(points ...mgl32.Vec3) Bounds {
+	inf := float32(math.Inf(1))
+	b := Bounds{Min: mgl32.Vec3{inf, inf, inf}, Max: mgl32.Vec3{-inf, -inf, -inf}}
+	for _, p := range points {
+		b = b.Extend(p, 0)
+	}
+	return b
+}
+
+// The box grown to hold a sphere of radius around p, radius 0 for just the point.
+func (b Bounds) Extend(p mgl32.Vec3, radius float32) Bounds {
+	for i := range 3 {
+		b.Min[i] = min(b.Min[i], p[i]-radius)
+		b.Max[i] = max(b.Max[i], p[i]+radius)
+	}
+	return b
+}
+
+// The eight corners of the box.
+func (b Bounds) Corners() [8]mgl32.Vec3 {
+	var corners [8]mgl32.Vec3
+	for i := range corners {
+		for axis := range 3 {
+			if i&(1<<axis) == 0 {
+				corners[i][axis] = b.Min[axis]
+			} else {
+				corners[i][axis] = b.Max[axis]
+			}
+		}
+	}
+	return corners
+}
+
+// Projection * view of a directional light shining along direction, with the orthographic box fitted
+// tightly around bounds as the light sees it. Every point of bounds ends up inside clip space.
+func DirectionalLightSpace(direction mgl32.Vec3, bounds Bounds) mgl32.Mat4 {
+	direction = direction.Normalize()
+	centre := bounds.Min.Add(bounds.Max).Mul(0.5)
+	radius := bounds.Max.Sub(bounds.Min).Len() / 2
+
+	// LookAt needs an up vector that isn't parallel to the light
+	up := mgl32.Vec3{0, 1, 0}
+	if math.Abs(float64(direction.Y())) > 0.99 {
+		up = mgl32.Vec3{0, 0, 1}
+	}
+	eye := centre.Sub(direction.Mul(radius))
+	view := mgl32.LookAtV(eye, centre, up)
+
+	inf := float32(math.Inf(1))
+	lo, hi := mgl32.Vec3{inf, inf, inf}, mgl32.Vec3{-inf, -inf, -inf}
+	for _, corner := range bounds.Corners() {
+		p := view.Mul4x1(corner.Vec4(1)).Vec3()
+		for i := range 3 {
+			lo[i], hi[i] = min(lo[i], p[i]), max(hi[i], p[i])
+		}
+	}
+	// the light looks down -z, near and far are distances in front of it
+	projection := mgl32.Ortho(lo.X(), hi.X(), lo.Y(), hi.Y(), -hi.Z(), -lo.Z())
+	return projection.Mul4(view)
+}
+
+// A depth map of the scene as seen from a light, for telling which fragments it doesn't reach.
+// Draw the shadow casters between Begin and End with the shader Begin returns, then sample the map
+// in the lighting shader through shaders/Lighting/common/shadows.glsl, see SetUniforms.
+type ShadowMap struct {
+	// Projection * view of the light, set by Begin.
+	LightSpace mgl32.Mat4
+	// Depth offset added while drawing the map so lit surfaces don't shadow themselves (shadow acne).
+	// SlopeBias scales with how steep the surface is as seen from the light, ConstantBias is in units
+	// of the smallest depth difference, like the factor and units of glPolygonOffset.
+	SlopeBias, ConstantBias float32
+	// Percentage closer filtering averages a (2 * PCFRadius + 1)^2 block of texels around the fragment,
+	// which softens the edges of the shadows. 0 gives hard, blocky edges.
+	PCFRadius int32
+
+	framebuffer *Framebuffer
+	shader      *Shader
+
+	// what Begin replaced, put back by End
+	previousFramebuffer int32
+	previousViewport    [4]int32
+}
+
+// Create a size x size shadow map. Outside the map nothing is in shadow.
+func NewShadowMap(size int) (*ShadowMap, error) {
+	program, err := BuildShaderProgram(shadowDepthVertexShader, shadowDepthFragmentShader)
+	if err != nil {
+		return nil, err
+	}
+	framebuffer, err := NewFramebuffer(FramebufferOptions{
+		Width: size, Height: size,
+		Depth: gl.DEPTH_COMPONENT24, DepthTexture: true,
+		// PCF does the filtering, interpolating depths would blur occluders into what they occlude
+		Filter: gl.NEAREST,
+	})
+	if err != nil {
+		gl.DeleteProgram(program)
+		return nil, err
+	}
+
+	// everything beyond the edge of the map reads the far plane, so it is lit
+	border := [4]float32{1, 1, 1, 1}
+	gl.BindTexture(gl.TEXTURE_2D, framebuffer.Depth)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_BORDER)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_BORDER)
+	gl.TexParameterfv(gl.TEXTURE_2D, gl.TEXTURE_BORDER_COLOR, &border[0])
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	return &ShadowMap{
+		LightSpace:   mgl32.Ident4(),
+		SlopeBias:    2,
+		ConstantBias: 4,
+		PCFRadius:    1,
+		framebuffer:  framebuffer,
+		shader:       NewShaderObject(program),
+	}, nil
+}
+
+// The depth texture, sample its red channel.
+func (s *ShadowMap) Texture() uint32 {
+	return s.framebuffer.Depth
+}
+
+// Size of the map in texels.
+func (s *ShadowMap) Size() int {
+	return s.framebuffer.Width
+}
+
+// Start drawing the map from lightSpace (see DirectionalLightSpace). The framebuffer and viewport
+// in use are saved for End. Returns the depth shader, set "model" on it before each draw.
+func (s *ShadowMap) Begin(lightSpace mgl32.Mat4) *Shader {
+	s.LightSpace = lightSpace
+	gl.GetIntegerv(gl.DRAW_FRAMEBUFFER_BINDING, &s.previousFramebuffer)
+	gl.GetIntegerv(gl.VIEWPORT, &s.previousViewport[0])
+
+	s.framebuffer.Bind()
+	gl.Clear(gl.DEPTH_BUFFER_BIT)
+	gl.Enable(gl.POLYGON_OFFSET_FILL)
+	gl.PolygonOffset(s.SlopeBias, s.ConstantBias)
+
+	s.shader.Use()
+	s.shader.SetMat4("lightSpaceMatrix", &s.LightSpace)
+	return s.shader
+}
+
+// Finish the map and go back to the framebuffer and viewport from before Begin.
+func (s *ShadowMap) End() {
+	gl.Disable(gl.POLYGON_OFFSET_FILL)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(s.previousFramebuffer))
+	gl.Viewport(s.previousViewport[0], s.previousViewport[1], s.previousViewport[2], s.previousViewport[3])
+}
+
+// Bind the map to texture unit and set the uniforms of shadows.glsl on program, which has to be in use.
+func (s *ShadowMap) SetUniforms(program Program, unit uint32) {
+	gl.ActiveTexture(gl.TEXTURE0 + unit)
+	gl.BindTexture(gl.TEXTURE_2D, s.framebuffer.Depth)
+	gl.ActiveTexture(gl.TEXTURE0)
+	SetInt(program, "shadowMap", int32(unit))
+	SetInt(program, "pcfRadius", s.PCFRadius)
+	SetMat4(program, "lightSpaceMatrix", &s.LightSpace)
+}
+
+// Release the depth map and the depth shader.
+func (s *ShadowMap) Delete() {
+	s.framebuffer.Delete()
+	gl.DeleteProgram(s.shader.ID)
+}
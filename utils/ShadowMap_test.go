@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestDirectionalLightSpace(t *testing.T) {
+	bounds := BoundsOf(mgl32.Vec3{-3, -1, -10}, mgl32.Vec3{5, 4, 2})
+	for _, direction := range []mgl32.Vec3{{-0.2, -1, -0.3}, {0, -1, 0}, {1, 0, 0}} {
+		m := DirectionalLightSpace(direction, bounds)
+
+		// every corner is inside clip space and the box touches each of its sides
+		lo, hi := mgl32.Vec3{1, 1, 1}, mgl32.Vec3{-1, -1, -1}
+		for _, corner := range bounds.Corners() {
+			p := m.Mul4x1(corner.Vec4(1))
+			for i := range 3 {
+				lo[i], hi[i] = min(lo[i], p[i]), max(hi[i], p[i])
+			}
+		}
+		for i := range 3 {
+			if lo[i] < -1.001 || lo[i] > -0.999 || hi[i] < 0.999 || hi[i] > 1.001 {
+				t.Errorf("%v: axis %d covers [%v, %v], want [-1, 1]", direction, i, lo[i], hi[i])
+			}
+		}
+
+		// the corner the light reaches first is on the near plane, the opposite one on the far plane
+		if direction == (mgl32.Vec3{-0.2, -1, -0.3}) {
+			near, far := m.Mul4x1(mgl32.Vec4{5, 4, 2, 1}), m.Mul4x1(mgl32.Vec4{-3, -1, -10, 1})
+			if near.Z() > -0.999 || far.Z() < 0.999 {
+				t.Errorf("%v: depth of the corners facing and away from the light: got %v and %v", direction, near.Z(), far.Z())
+			}
+		}
+	}
+}
+
+func TestShadowMap(t *testing.T) {
+	withGLContext(t)
+
+	s, err := NewShadowMap(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Delete()
+
+	// a quad over the x < 0 half of a 2x1x2 box, lit from straight above
+	quad := []float32{-1, 1, -1, 0, 1, -1, 0, 1, 1, -1, 1, -1, 0, 1, 1, -1, 1, 1}
+	var vao, vbo uint32
+	gl.GenVertexArrays(1, &vao)
+	gl.GenBuffers(1, &vbo)
+	defer gl.DeleteVertexArrays(1, &vao)
+	defer gl.DeleteBuffers(1, &vbo)
+	gl.BindVertexArray(vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*len(quad), gl.Ptr(quad), gl.STATIC_DRAW)
+	gl.VertexAttribPointerWithOffset(0, 3, gl.FLOAT, false, 3*4, 0)
+	gl.EnableVertexAttribArray(0)
+
+	gl.Viewport(1, 2, 3, 4)
+	gl.Enable(gl.DEPTH_TEST)
+	defer gl.Disable(gl.DEPTH_TEST)
+	bounds := Bounds{Min: mgl32.Vec3{-1, 0, -1}, Max: mgl32.Vec3{1, 1, 1}}
+	depth := s.Begin(DirectionalLightSpace(mgl32.Vec3{0, -1, 0}, bounds))
+	model := mgl32.Ident4()
+	depth.SetMat4("model", &model)
+	gl.DrawArrays(gl.TRIANGLES, 0, 6)
+	s.End()
+
+	var viewport [4]int32
+	gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+	if viewport != [4]int32{1, 2, 3, 4} {
+		t.Errorf("viewport after End: got %v", viewport)
+	}
+
+	texels := make([]float32, 32*32)
+	gl.BindTexture(gl.TEXTURE_2D, s.Texture())
+	gl.GetTexImage(gl.TEXTURE_2D, 0, gl.DEPTH_COMPONENT, gl.FLOAT, gl.Ptr(texels))
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	// the quad is at the top of the box, on the near plane; the rest is cleared to the far plane
+	var caster, empty int
+	for _, d := range texels {
+		switch {
+		case d < 0.01:
+			caster++
+		case d == 1:
+			empty++
+		}
+	}
+	if caster != 32*16 || empty != 32*16 {
+		t.Errorf("got %d texels on the quad and %d empty, want %d of each", caster, empty, 32*16)
+	}
+	if glErr := gl.GetError(); glErr != gl.NO_ERROR {
+		t.Errorf("OpenGL error 0x%x", glErr)
+	}
+}
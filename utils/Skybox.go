@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// The skybox shaders are small and fixed, they are kept here so Skybox works from any directory.
+const skyboxVertexShader = `#version 330 core
+layout (location = 0) in vec3 aPos;
+
+out vec3 TexCoords;
+
+uniform mat4 projection;
+uniform mat4 view;
+
+void main()
+{
+    TexCoords = aPos;
+    vec4 pos = projection * view * vec4(aPos, 1.0);
+    // z = w puts the skybox on the far plane, behind everything else
+    gl_Position = pos.xyww;
+}
+`
+
+const skyboxFragmentShader = `#version 330 core
+out vec4 FragColor;
+
+in vec3 TexCoords;
+
+uniform samplerCube skybox;
+
+void main()
+{
+    FragColor = texture(skybox, TexCoords);
+}
+`
+
+var skyboxVertices = []float32{
+	// positions
+	-1.0, 1.0, -1.0,
+	-1.0, -1.0, -1.0,
+	1.0, -1.0, -1.0,
+	1.0, -1.0, -1.0,
+	1.0, 1.0, -1.0,
+	-1.0, 1.0, -1.0,
+
+	-1.0, -1.0, 1.0,
+	-1.0, -1.0, -1.0,
+	-1.0, 1.0, -1.0,
+	-1.0, 1.0, -1.0,
+	-1.0, 1.0, 1.0,
+	-1.0, -1.0, 1.0,
+
+	1.0, -1.0, -1.0,
+	1.0, -1.0, 1.0,
+	1.0, 1.0, 1.0,
+	1.0, 1.0, 1.0,
+	1.0, 1.0, -1.0,
+	1.0, -1.0, -1.0,
+
+	-1.0, -1.0, 1.0,
+	-1.0, 1.0, 1.0,
+	1.0, 1.0, 1.0,
+	1.0, 1.0, 1.0,
+	1.0, -1.0, 1.0,
+	-1.0, -1.0, 1.0,
+
+	-1.0, 1.0, -1.0,
+	1.0, 1.0, -1.0,
+	1.0, 1.0, 1.0,
+	1.0, 1.0, 1.0,
+	-1.0, 1.0, 1.0,
+	-1.0, 1.0, -1.0,
+
+	-1.0, -1.0, -1.0,
+	-1.0, -1.0, 1.0,
+	1.0, -1.0, -1.0,
+	1.0, -1.0, -1.0,
+	-1.0, -1.0, 1.0,
+	1.0, -1.0, 1.0,
+}
+
+// A cubemap drawn around the camera. Draw it after the opaque geometry, it only covers
+// pixels nothing else was drawn to.
+type Skybox struct {
+	Cubemap uint32
+
+	shader   *Shader
+	vao, vbo uint32
+}
+
+// Create a skybox showing cubemap, see LoadCubemap and LoadEquirectangularCubemap.
+// The cubemap stays the caller's, Delete leaves it alone.
+func NewSkybox(cubemap uint32) (*Skybox, error) {
+	program, err := BuildShaderProgram(skyboxVertexShader, skyboxFragmentShader)
+	if err != nil {
+		return nil, err
+	}
+	s := &Skybox{Cubemap: cubemap, shader: NewShaderObject(program)}
+	s.shader.Use()
+	s.shader.SetInt("skybox", 0)
+
+	gl.GenVertexArrays(1, &s.vao)
+	gl.GenBuffers(1, &s.vbo)
+	gl.BindVertexArray(s.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, s.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*len(skyboxVertices), gl.Ptr(skyboxVertices), gl.STATIC_DRAW)
+	gl.VertexAttribPointerWithOffset(0, 3, gl.FLOAT, false, 3*4, 0)
+	gl.EnableVertexAttribArray(0)
+	gl.BindVertexArray(0)
+	return s, nil
+}
+
+// Draw the skybox with the camera's view and projection. The translation is stripped from view
+// so the box stays centred on the camera, and the depth test passes at the far plane (GL_LEQUAL).
+func (s *Skybox) Draw(view, projection mgl32.Mat4) {
+	var depthFunc int32
+	gl.GetIntegerv(gl.DEPTH_FUNC, &depthFunc)
+	gl.DepthFunc(gl.LEQUAL)
+
+	rotation := view.Mat3().Mat4()
+	s.shader.Use()
+	s.shader.SetMat4("view", &rotation)
+	s.shader.SetMat4("projection", &projection)
+
+	gl.BindVertexArray(s.vao)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, s.Cubemap)
+	gl.DrawArrays(gl.TRIANGLES, 0, 36)
+	gl.BindVertexArray(0)
+
+	gl.DepthFunc(uint32(depthFunc))
+}
+
+// Delete the GL objects of the skybox, not the cubemap it shows.
+func (s *Skybox) Delete() {
+	gl.DeleteVertexArrays(1, &s.vao)
+	gl.DeleteBuffers(1, &s.vbo)
+	gl.DeleteProgram(s.shader.ID)
+}
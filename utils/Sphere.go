@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// A sphere of radius 1 around the origin, cut into segments around and rings from pole to pole.
+// Texture coordinates wrap once around it, the tangents follow them so normal maps work.
+func NewSphere(segments, rings int) Mesh {
+	vertices, indices := sphereGeometry(segments, rings)
+	return NewMesh(vertices, indices, nil)
+}
+
+func sphereGeometry(segments, rings int) ([]Vertex, []uint32) {
+	vertices := make([]Vertex, 0, (segments+1)*(rings+1))
+	for y := 0; y <= rings; y++ {
+		for x := 0; x <= segments; x++ {
+			u, v := float64(x)/float64(segments), float64(y)/float64(rings)
+			phi, theta := u*2*math.Pi, v*math.Pi
+			sinPhi, cosPhi := math.Sincos(phi)
+			sinTheta, cosTheta := math.Sincos(theta)
+
+			position := mgl32.Vec3{float32(cosPhi * sinTheta), float32(cosTheta), float32(sinPhi * sinTheta)}
+			vertices = append(vertices, Vertex{
+				Position:  position,
+				Normal:    position,
+				TexCoords: mgl32.Vec2{float32(u), float32(v)},
+				// the directions u and v grow in, the tangent is undefined at the poles so keep it horizontal
+				Tangent:   mgl32.Vec3{float32(-sinPhi), 0, float32(cosPhi)},
+				Bitangent: mgl32.Vec3{float32(cosPhi * cosTheta), float32(-sinTheta), float32(sinPhi * cosTheta)},
+			})
+		}
+	}
+
+	indices := make([]uint32, 0, segments*rings*6)
+	for y := 0; y < rings; y++ {
+		for x := 0; x < segments; x++ {
+			a := uint32(y*(segments+1) + x)
+			b, c := a+1, a+uint32(segments+1)
+			d := c + 1
+			// counter-clockwise seen from outside
+			indices = append(indices, a, b, c, b, d, c)
+		}
+	}
+	return vertices, indices
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSphereGeometry(t *testing.T) {
+	vertices, indices := sphereGeometry(16, 8)
+	if len(vertices) != 17*9 || len(indices) != 16*8*6 {
+		t.Fatalf("got %d vertices and %d indices", len(vertices), len(indices))
+	}
+	for i, v := range vertices {
+		if math.Abs(float64(v.Position.Len()-1)) > 1e-5 || v.Normal != v.Position {
+			t.Errorf("vertex %d: position %v, normal %v", i, v.Position, v.Normal)
+		}
+		if d := v.Tangent.Dot(v.Normal); math.Abs(float64(d)) > 1e-5 {
+			t.Errorf("vertex %d: tangent not on the surface, dot %v", i, d)
+		}
+		// right handed tangent space, as the normal mapping shaders build it
+		if v.Bitangent.Len() > 0.01 && v.Tangent.Cross(v.Bitangent).Dot(v.Normal) <= 0 {
+			t.Errorf("vertex %d: tangent %v and bitangent %v don't face out", i, v.Tangent, v.Bitangent)
+		}
+	}
+	for i := 0; i < len(indices); i += 3 {
+		a, b, c := vertices[indices[i]].Position, vertices[indices[i+1]].Position, vertices[indices[i+2]].Position
+		face := b.Sub(a).Cross(c.Sub(a))
+		// triangles touching the poles have two corners at the same point
+		if face.Len() < 1e-6 {
+			continue
+		}
+		if face.Dot(a.Add(b).Add(c)) <= 0 {
+			t.Errorf("triangle %d faces inwards", i/3)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Pack a Go value into the std140 layout used by uniform blocks. Supported field types are
+// float32, int32, uint32, bool, mgl32.Vec2/3/4, mgl32.Mat3/4, structs of those,
+// and arrays or slices of any of them. Fields are packed in declaration order,
+// so they have to match the order of the block members in the shader.
+func Std140(v any) []byte {
+	value := reflect.ValueOf(v)
+	if value.Kind() == reflect.Pointer {
+		value = value.Elem()
+	}
+	buf := appendStd140(nil, value)
+	// uniform buffers are allocated in multiples of a vec4
+	return padTo(buf, 16)
+}
+
+var (
+	vec2Type = reflect.TypeOf(mgl32.Vec2{})
+	vec3Type = reflect.TypeOf(mgl32.Vec3{})
+	vec4Type = reflect.TypeOf(mgl32.Vec4{})
+	mat3Type = reflect.TypeOf(mgl32.Mat3{})
+	mat4Type = reflect.TypeOf(mgl32.Mat4{})
+)
+
+// The base alignment of a type, in bytes.
+func std140Alignment(t reflect.Type) int {
+	switch t {
+	case vec2Type:
+		return 8
+	case vec3Type, vec4Type, mat3Type, mat4Type:
+		return 16
+	}
+
+	switch t.Kind() {
+	case reflect.Float32, reflect.Int32, reflect.Uint32, reflect.Bool:
+		return 4
+	case reflect.Array, reflect.Slice, reflect.Struct:
+		// arrays and structures are rounded up to the alignment of a vec4
+		return 16
+	}
+	panic(fmt.Sprintf("std140: unsupported type %s", t))
+}
+
+func appendStd140(buf []byte, v reflect.Value) []byte {
+	t := v.Type()
+	buf = padTo(buf, std140Alignment(t))
+
+	switch t {
+	case vec2Type, vec3Type, vec4Type:
+		for i := 0; i < v.Len(); i++ {
+			buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(float32(v.Index(i).Float())))
+		}
+		return buf
+	case mat3Type, mat4Type:
+		// column major, every column padded to a vec4
+		size := 3
+		if t == mat4Type {
+			size = 4
+		}
+		for col := 0; col < size; col++ {
+			buf = padTo(buf, 16)
+			for row := 0; row < size; row++ {
+				buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(float32(v.Index(col*size+row).Float())))
+			}
+		}
+		return padTo(buf, 16)
+	}
+
+	switch t.Kind() {
+	case reflect.Float32:
+		return binary.LittleEndian.AppendUint32(buf, math.Float32bits(float32(v.Float())))
+	case reflect.Int32:
+		return binary.LittleEndian.AppendUint32(buf, uint32(int32(v.Int())))
+	case reflect.Uint32:
+		return binary.LittleEndian.AppendUint32(buf, uint32(v.Uint()))
+	case reflect.Bool:
+		var b uint32
+		if v.Bool() {
+			b = 1
+		}
+		return binary.LittleEndian.AppendUint32(buf, b)
+	case reflect.Array, reflect.Slice:
+		// every element starts on a vec4 boundary
+		for i := 0; i < v.Len(); i++ {
+			buf = padTo(appendStd140(buf, v.Index(i)), 16)
+		}
+		return buf
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			buf = appendStd140(buf, v.Field(i))
+		}
+		return padTo(buf, 16)
+	}
+	panic(fmt.Sprintf("std140: unsupported type %s", t))
+}
+
+func padTo(buf []byte, alignment int) []byte {
+	for len(buf)%alignment != 0 {
+		buf = append(buf, 0)
+	}
+	return buf
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestStd140Padding(t *testing.T) {
+	packed := Std140(&struct {
+		A float32
+		B mgl32.Vec3
+		C mgl32.Vec2
+		D []float32
+		E mgl32.Mat3
+	}{D: []float32{1, 2}})
+
+	// A at 0, B at 16, C at 32, D at 48 with a 16 byte stride, E at 80 with 3 columns of 16
+	if len(packed) != 128 {
+		t.Errorf("got %d bytes, want 128", len(packed))
+	}
+	if got := math.Float32frombits(binary.LittleEndian.Uint32(packed[64:])); got != 2 {
+		t.Errorf("D[1]: got %v, want 2", got)
+	}
+}
+
+// Pack the lights of the multiple lights shader and check every value lands where the driver expects it.
+func TestStd140MatchesDriver(t *testing.T) {
+	withGLContext(t)
+
+	program, err := LoadShaderWithDefines("../shaders/Lighting/6-MultipleLightsVert.glsl", "../shaders/Lighting/6-MultipleLightsFrag.glsl", Defines{"NR_POINT_LIGHTS": "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gl.DeleteProgram(program)
+	r := ReflectProgram(program)
+
+	lights := LightsUniforms{
+		DirLight: DirLightUniform{Direction: mgl32.Vec3{1, 2, 3}, Specular: mgl32.Vec3{4, 5, 6}},
+		PointLights: []PointLightUniform{
+			{Quadratic: 7},
+			{Position: mgl32.Vec3{8, 9, 10}, Diffuse: mgl32.Vec3{11, 12, 13}},
+		},
+		SpotLight: SpotLightUniform{OuterCutOff: 14, Specular: mgl32.Vec3{15, 16, 17}},
+	}
+	packed := Std140(&lights)
+
+	for _, b := range r.UniformBlocks {
+		switch b.Name {
+		case "Lights":
+			if int(b.Size) != len(packed) {
+				t.Errorf("Lights: driver size %d, packed %d bytes", b.Size, len(packed))
+			}
+			if b.Binding != LightsBinding {
+				t.Errorf("Lights: bound to %d, want %d", b.Binding, LightsBinding)
+			}
+		case "Camera":
+			if b.Binding != CameraBinding {
+				t.Errorf("Camera: bound to %d, want %d", b.Binding, CameraBinding)
+			}
+		}
+	}
+
+	want := map[string]float32{
+		"dirLight.direction":       1,
+		"dirLight.specular":        4,
+		"pointLights[0].quadratic": 7,
+		"pointLights[1].position":  8,
+		"pointLights[1].diffuse":   11,
+		"spotLight.outerCutOff":    14,
+		"spotLight.specular":       15,
+	}
+	for name, value := range want {
+		u, ok := r.Uniform(name)
+		if !ok {
+			t.Errorf("%s: not in the Lights block", name)
+			continue
+		}
+		if got := math.Float32frombits(binary.LittleEndian.Uint32(packed[u.Offset:])); got != value {
+			t.Errorf("%s at offset %d: got %v, want %v", name, u.Offset, got, value)
+		}
+	}
+}
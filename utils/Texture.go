@@ -0,0 +1,222 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"os"
+	"slices"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// How the colour values stored in an image file should be interpreted.
+type ColorSpace int
+
+const (
+	// Values are used as they are, for data such as specular, normal and height maps.
+	Linear ColorSpace = iota
+	// Values are sRGB encoded colours, like diffuse/albedo maps painted on a monitor. Uploaded as
+	// GL_SRGB8_ALPHA8 so sampling returns linear values for the lighting maths.
+	SRGB
+)
+
+// How a texture is created and sampled. The zero value is a mipmapped, trilinear filtered,
+// repeating texture in linear colour space.
+type TextureOptions struct {
+	// gl.REPEAT when left at 0, gl.CLAMP_TO_EDGE for cubemaps
+	WrapS, WrapT, WrapR int32
+	// Used with gl.CLAMP_TO_BORDER
+	BorderColor mgl32.Vec4
+	// gl.LINEAR_MIPMAP_LINEAR (gl.LINEAR without mipmaps) and gl.LINEAR when left at 0
+	MinFilter, MagFilter int32
+	// Maximum anisotropic filtering level, values above 1 need GL_ARB_texture_filter_anisotropic
+	// (or the EXT version) and are clamped to what the driver supports.
+	Anisotropy float32
+	NoMipmaps  bool
+	// Picked from the image and ColorSpace when left at 0: gl.RGBA, gl.SRGB8_ALPHA8, gl.R8 for grayscale
+	// images in linear space, or gl.RGB16F for HDR images.
+	InternalFormat int32
+	ColorSpace     ColorSpace
+}
+
+func (o TextureOptions) wrapS() int32 { return orDefault(o.WrapS, gl.REPEAT) }
+func (o TextureOptions) wrapT() int32 { return orDefault(o.WrapT, gl.REPEAT) }
+func (o TextureOptions) wrapR() int32 { return orDefault(o.WrapR, gl.REPEAT) }
+
+func (o TextureOptions) minFilter() int32 {
+	if o.NoMipmaps {
+		return orDefault(o.MinFilter, gl.LINEAR)
+	}
+	return orDefault(o.MinFilter, gl.LINEAR_MIPMAP_LINEAR)
+}
+
+func (o TextureOptions) magFilter() int32 { return orDefault(o.MagFilter, gl.LINEAR) }
+
+func orDefault(v, def int32) int32 {
+	if v == 0 {
+		return def
+	}
+	return v
+}
+
+// Set the sampling state in o on the texture bound to target.
+func (o TextureOptions) apply(target uint32) {
+	gl.TexParameteri(target, gl.TEXTURE_WRAP_S, o.wrapS())
+	gl.TexParameteri(target, gl.TEXTURE_WRAP_T, o.wrapT())
+	gl.TexParameteri(target, gl.TEXTURE_WRAP_R, o.wrapR())
+	gl.TexParameterfv(target, gl.TEXTURE_BORDER_COLOR, &o.BorderColor[0])
+	gl.TexParameteri(target, gl.TEXTURE_MIN_FILTER, o.minFilter())
+	gl.TexParameteri(target, gl.TEXTURE_MAG_FILTER, o.magFilter())
+	if level := clampAnisotropy(o.Anisotropy); level > 1 {
+		gl.TexParameterf(target, gl.TEXTURE_MAX_ANISOTROPY, level)
+	}
+}
+
+// Load an image file into a 2D texture, the image format is picked from the file contents (see LoadImage).
+// Exits the program if anything goes wrong, use LoadTexture to handle the error instead.
+func NewTexture(texturePath string, opts TextureOptions) uint32 {
+	texture, err := LoadTexture(texturePath, opts)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	return texture
+}
+
+// Same as NewTexture but returns the error.
+// HDR images always hold linear values, ColorSpace is ignored for them.
+func LoadTexture(texturePath string, opts TextureOptions) (uint32, error) {
+	img, err := LoadImage(texturePath)
+	if err != nil {
+		return 0, err
+	}
+	return NewTextureFromImage(img, opts), nil
+}
+
+// Upload an already decoded image into a new 2D texture, see LoadImage.
+func NewTextureFromImage(img image.Image, opts TextureOptions) uint32 {
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	gl.BindTexture(gl.TEXTURE_2D, texture) // all upcoming GL_TEXTURE_2D operations now have effect on this texture object
+	opts.apply(gl.TEXTURE_2D)
+
+	if hdr, ok := img.(*HDRImage); ok {
+		uploadHDR(gl.TEXTURE_2D, hdr, orDefault(opts.InternalFormat, gl.RGB16F), true)
+	} else {
+		uploadImage(gl.TEXTURE_2D, img, opts, true)
+	}
+	if !opts.NoMipmaps {
+		gl.GenerateMipmap(gl.TEXTURE_2D)
+	}
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return texture
+}
+
+// The anisotropy level the driver will accept for level, 0 when anisotropic filtering is not supported.
+func clampAnisotropy(level float32) float32 {
+	if level <= 1 || !hasExtension("GL_ARB_texture_filter_anisotropic", "GL_EXT_texture_filter_anisotropic") {
+		return 0
+	}
+	var max float32
+	gl.GetFloatv(gl.MAX_TEXTURE_MAX_ANISOTROPY, &max)
+	return min(level, max)
+}
+
+// Whether the current context supports any of the named extensions.
+func hasExtension(names ...string) bool {
+	var count int32
+	gl.GetIntegerv(gl.NUM_EXTENSIONS, &count)
+	for i := uint32(0); i < uint32(count); i++ {
+		ext := gl.GoStr(gl.GetStringi(gl.EXTENSIONS, i))
+		if slices.Contains(names, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// Upload an 8 bit image to target of the bound texture (a 2D texture or a cubemap face), flip puts
+// the bottom row first like OpenGL wants it for 2D textures. Grayscale 2D textures in linear space are
+// uploaded as a single GL_RED channel, swizzled so they still sample as gray; everything else as RGBA.
+func uploadImage(target uint32, img image.Image, opts TextureOptions, flip bool) {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+
+	// there is no single channel sRGB format in 3.3
+	if gray, ok := img.(*image.Gray); ok && opts.ColorSpace == Linear && target == gl.TEXTURE_2D {
+		gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1) // rows are not a multiple of 4 bytes
+		gl.TexImage2D(gl.TEXTURE_2D, 0, orDefault(opts.InternalFormat, gl.R8), int32(width), int32(height), 0, gl.RED, gl.UNSIGNED_BYTE, gl.Ptr(copyRows(gray.Pix, gray.Stride, width, height, flip)))
+		gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+
+		swizzle := [4]int32{gl.RED, gl.RED, gl.RED, gl.ONE}
+		gl.TexParameteriv(gl.TEXTURE_2D, gl.TEXTURE_SWIZZLE_RGBA, &swizzle[0])
+		return
+	}
+
+	internalFormat := orDefault(opts.InternalFormat, gl.RGBA)
+	if opts.ColorSpace == SRGB {
+		internalFormat = orDefault(opts.InternalFormat, gl.SRGB8_ALPHA8)
+	}
+	gl.TexImage2D(target, 0, internalFormat, int32(width), int32(height), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgbaPixels(img, flip)))
+}
+
+// The pixels of img as tightly packed RGBA rows, bottom row first when flip is set.
+// NRGBA and RGBA images are copied row by row, anything else is converted with draw.Draw first.
+func rgbaPixels(img image.Image, flip bool) []byte {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+
+	switch img := img.(type) {
+	case *image.NRGBA:
+		return copyRows(img.Pix, img.Stride, width*4, height, flip)
+	case *image.RGBA:
+		return copyRows(img.Pix, img.Stride, width*4, height, flip)
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	if flip {
+		flipInPlace(rgba.Pix, width*4, height)
+	}
+	return rgba.Pix
+}
+
+// Copy height rows of rowSize bytes out of pix (stride bytes apart), in reverse order when flip is set.
+func copyRows(pix []byte, stride, rowSize, height int, flip bool) []byte {
+	rows := make([]byte, rowSize*height)
+	for y := 0; y < height; y++ {
+		dst := y
+		if flip {
+			dst = height - 1 - y
+		}
+		copy(rows[dst*rowSize:(dst+1)*rowSize], pix[y*stride:y*stride+rowSize])
+	}
+	return rows
+}
+
+// Reverse the order of the rows of pix.
+func flipInPlace(pix []byte, rowSize, height int) {
+	tmp := make([]byte, rowSize)
+	for top, bottom := 0, height-1; top < bottom; top, bottom = top+1, bottom-1 {
+		a, b := pix[top*rowSize:(top+1)*rowSize], pix[bottom*rowSize:(bottom+1)*rowSize]
+		copy(tmp, a)
+		copy(a, b)
+		copy(b, tmp)
+	}
+}
+
+// Upload a float image to target of the bound texture, flipped like uploadImage.
+func uploadHDR(target uint32, img *HDRImage, internalFormat int32, flip bool) {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	rowSize := width * 3
+	rows := make([]float32, rowSize*height)
+	for y := 0; y < height; y++ {
+		dst := y
+		if flip {
+			dst = height - 1 - y
+		}
+		copy(rows[dst*rowSize:(dst+1)*rowSize], img.Pix[y*img.Stride:y*img.Stride+rowSize])
+	}
+
+	gl.TexImage2D(target, 0, internalFormat, int32(width), int32(height), 0, gl.RGB, gl.FLOAT, gl.Ptr(rows))
+}
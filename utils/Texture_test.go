@@ -0,0 +1,229 @@
+package utils
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestTextureColorSpace(t *testing.T) {
+	withGLContext(t)
+
+	dir := t.TempDir()
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	src.Set(0, 0, color.NRGBA{128, 128, 128, 255})
+	file, err := os.Create(filepath.Join(dir, "diffuse.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	png.Encode(file, src)
+	file.Close()
+	if err := os.WriteFile(filepath.Join(dir, "sky.hdr"), testHDRFile(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		file  string
+		space ColorSpace
+		want  int32
+	}{
+		{"diffuse.png", Linear, gl.RGBA},
+		{"diffuse.png", SRGB, gl.SRGB8_ALPHA8},
+		{"sky.hdr", SRGB, gl.RGB16F},
+	}
+	for _, tt := range tests {
+		texture, err := LoadTexture(filepath.Join(dir, tt.file), TextureOptions{ColorSpace: tt.space})
+		if err != nil {
+			t.Fatal(err)
+		}
+		gl.BindTexture(gl.TEXTURE_2D, texture)
+		var internal int32
+		gl.GetTexLevelParameteriv(gl.TEXTURE_2D, 0, gl.TEXTURE_INTERNAL_FORMAT, &internal)
+		gl.DeleteTextures(1, &texture)
+
+		// drivers may report the unsized GL_RGBA as GL_RGBA8
+		if internal != tt.want && !(tt.want == gl.RGBA && internal == gl.RGBA8) {
+			t.Errorf("%s as %v: got internal format %#x, want %#x", tt.file, tt.space, internal, tt.want)
+		}
+	}
+}
+
+func TestTextureOptions(t *testing.T) {
+	withGLContext(t)
+
+	path := filepath.Join(t.TempDir(), "data.png")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	png.Encode(file, image.NewNRGBA(image.Rect(0, 0, 4, 4)))
+	file.Close()
+
+	opts := TextureOptions{
+		WrapS:       gl.CLAMP_TO_BORDER,
+		BorderColor: mgl32.Vec4{1, 0, 0, 1},
+		MagFilter:   gl.NEAREST,
+		Anisotropy:  1000,
+		NoMipmaps:   true,
+	}
+	texture, err := LoadTexture(path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gl.DeleteTextures(1, &texture)
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+
+	params := map[uint32]int32{
+		gl.TEXTURE_WRAP_S:     gl.CLAMP_TO_BORDER,
+		gl.TEXTURE_WRAP_T:     gl.REPEAT,
+		gl.TEXTURE_MIN_FILTER: gl.LINEAR, // no mipmaps to filter between
+		gl.TEXTURE_MAG_FILTER: gl.NEAREST,
+	}
+	for pname, want := range params {
+		var got int32
+		gl.GetTexParameteriv(gl.TEXTURE_2D, pname, &got)
+		if got != want {
+			t.Errorf("texture parameter %#x: got %#x, want %#x", pname, got, want)
+		}
+	}
+	var border mgl32.Vec4
+	gl.GetTexParameterfv(gl.TEXTURE_2D, gl.TEXTURE_BORDER_COLOR, &border[0])
+	if border != opts.BorderColor {
+		t.Errorf("border colour: got %v", border)
+	}
+	var width int32
+	gl.GetTexLevelParameteriv(gl.TEXTURE_2D, 1, gl.TEXTURE_WIDTH, &width)
+	if width != 0 {
+		t.Errorf("mip level 1 exists with NoMipmaps")
+	}
+
+	sampler := NewSampler(TextureOptions{WrapT: gl.MIRRORED_REPEAT, MinFilter: gl.NEAREST_MIPMAP_NEAREST, Anisotropy: 1000})
+	defer sampler.Delete()
+	params = map[uint32]int32{
+		gl.TEXTURE_WRAP_S:     gl.REPEAT,
+		gl.TEXTURE_WRAP_T:     gl.MIRRORED_REPEAT,
+		gl.TEXTURE_MIN_FILTER: gl.NEAREST_MIPMAP_NEAREST,
+		gl.TEXTURE_MAG_FILTER: gl.LINEAR,
+	}
+	for pname, want := range params {
+		var got int32
+		gl.GetSamplerParameteriv(sampler.ID, pname, &got)
+		if got != want {
+			t.Errorf("sampler parameter %#x: got %#x, want %#x", pname, got, want)
+		}
+	}
+
+	// an anisotropy level above the driver maximum must be clamped, not rejected
+	if err := gl.GetError(); err != gl.NO_ERROR {
+		t.Errorf("GL error %#x", err)
+	}
+}
+
+// What texture upload used to do: convert with draw.Draw, then flip with At/Set one pixel at a time.
+func flippedRGBASlow(img image.Image) []byte {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+
+	flipped := image.NewRGBA(rgba.Bounds())
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			flipped.Set(x, height-y-1, rgba.At(x, y))
+		}
+	}
+	return flipped.Pix
+}
+
+func testImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = byte(i * 7)
+	}
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 255 // opaque, NRGBA and RGBA hold the same values then
+	}
+	return img
+}
+
+func TestRGBAPixels(t *testing.T) {
+	src := testImage(5, 3)
+	nrgba := image.NewNRGBA(src.Rect)
+	copy(nrgba.Pix, src.Pix)
+	gray := image.NewGray(src.Rect)
+	draw.Draw(gray, gray.Rect, src, image.Point{}, draw.Src)
+
+	tests := map[string]image.Image{
+		"RGBA":     src,
+		"NRGBA":    nrgba,
+		"Gray":     gray,
+		"SubImage": testImage(9, 9).SubImage(image.Rect(2, 3, 7, 6)),
+		"Paletted": image.NewPaletted(src.Rect, color.Palette{color.Black, color.White}),
+		"Gray16":   image.NewGray16(src.Rect),
+	}
+	for name, img := range tests {
+		if got, want := rgbaPixels(img, true), flippedRGBASlow(img); !bytes.Equal(got, want) {
+			t.Errorf("%s: rows differ from the pixel by pixel flip", name)
+		}
+	}
+}
+
+func TestGrayTexture(t *testing.T) {
+	withGLContext(t)
+
+	path := filepath.Join(t.TempDir(), "height.png")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 3 pixels wide so rows are not 4 byte aligned
+	gray := image.NewGray(image.Rect(0, 0, 3, 2))
+	copy(gray.Pix, []byte{1, 2, 3, 4, 5, 6})
+	png.Encode(file, gray)
+	file.Close()
+
+	texture, err := LoadTexture(path, TextureOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gl.DeleteTextures(1, &texture)
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+
+	var internal int32
+	gl.GetTexLevelParameteriv(gl.TEXTURE_2D, 0, gl.TEXTURE_INTERNAL_FORMAT, &internal)
+	if internal != gl.R8 {
+		t.Errorf("internal format: got %#x, want GL_R8", internal)
+	}
+
+	pixels := make([]byte, 6)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.GetTexImage(gl.TEXTURE_2D, 0, gl.RED, gl.UNSIGNED_BYTE, gl.Ptr(pixels))
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 4)
+	if want := []byte{4, 5, 6, 1, 2, 3}; !bytes.Equal(pixels, want) {
+		t.Errorf("got %v, want %v", pixels, want)
+	}
+}
+
+func BenchmarkFlipPixelByPixel(b *testing.B) {
+	img := testImage(2048, 2048)
+	b.SetBytes(int64(len(img.Pix)))
+	for i := 0; i < b.N; i++ {
+		flippedRGBASlow(img)
+	}
+}
+
+func BenchmarkFlipRows(b *testing.B) {
+	img := testImage(2048, 2048)
+	b.SetBytes(int64(len(img.Pix)))
+	for i := 0; i < b.N; i++ {
+		rgbaPixels(img, true)
+	}
+}
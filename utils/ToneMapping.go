@@ -0,0 +1,290 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Curve mapping HDR colours into [0, 1].
+type ToneMapOperator int32
+
+const (
+	Reinhard ToneMapOperator = iota
+	// Krzysztof Narkowicz's fit of the ACES filmic curve
+	ACESFilmic
+	// John Hable's filmic curve from Uncharted 2
+	Uncharted2
+	toneMapOperators
+)
+
+func (o ToneMapOperator) String() string {
+	switch o {
+	case Reinhard:
+		return "reinhard"
+	case ACESFilmic:
+		return "aces"
+	case Uncharted2:
+		return "uncharted2"
+	}
+	return fmt.Sprintf("ToneMapOperator(%d)", int32(o))
+}
+
+// Next operator, wrapping around, for cycling through them from the keyboard.
+func (o ToneMapOperator) Next() ToneMapOperator {
+	return (o + 1) % toneMapOperators
+}
+
+// The operator called name (reinhard, aces or uncharted2).
+func ParseToneMapOperator(name string) (ToneMapOperator, error) {
+	for o := ToneMapOperator(0); o < toneMapOperators; o++ {
+		if o.String() == name {
+			return o, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown tone map operator %q, use reinhard, aces or uncharted2", name)
+}
+
+const toneMapShader = postFragmentHeader + `
+uniform int toneMapOperator;
+uniform float exposure;
+
+vec3 reinhard(vec3 x)
+{
+    return x / (1.0 + x);
+}
+
+vec3 acesFilmic(vec3 x)
+{
+    return clamp((x * (2.51 * x + 0.03)) / (x * (2.43 * x + 0.59) + 0.14), 0.0, 1.0);
+}
+
+vec3 hable(vec3 x)
+{
+    const float A = 0.15, B = 0.50, C = 0.10, D = 0.20, E = 0.02, F = 0.30;
+    return ((x * (A * x + C * B) + D * E) / (x * (A * x + B) + D * F)) - E / F;
+}
+
+vec3 uncharted2(vec3 x)
+{
+    // the curve is scaled so the white point W ends up at 1
+    const float W = 11.2;
+    return hable(2.0 * x) / hable(vec3(W));
+}
+
+void main()
+{
+    vec3 color = texture(screenTexture, TexCoords).rgb * exposure;
+    if (toneMapOperator == 1) {
+        color = acesFilmic(color);
+    } else if (toneMapOperator == 2) {
+        color = uncharted2(color);
+    } else {
+        color = reinhard(color);
+    }
+    FragColor = vec4(color, 1.0);
+}
+`
+
+const gammaShader = postFragmentHeader + `
+uniform float gamma;
+
+void main()
+{
+    vec3 color = texture(screenTexture, TexCoords).rgb;
+    FragColor = vec4(pow(color, vec3(1.0 / gamma)), 1.0);
+}
+`
+
+// Writes the log2 luminance of the image, each pixel averaging a cellSize block of it.
+const logLuminanceShader = postFragmentHeader + `
+uniform vec2 cellSize;
+
+void main()
+{
+    vec3 color = vec3(0.0);
+    for (int x = 0; x < 2; x++) {
+        for (int y = 0; y < 2; y++) {
+            color += texture(screenTexture, TexCoords + (vec2(x, y) - 0.5) * 0.5 * cellSize).rgb;
+        }
+    }
+    float luminance = dot(color * 0.25, vec3(0.2126, 0.7152, 0.0722));
+    FragColor = vec4(log2(max(luminance, 1e-5)), 0.0, 0.0, 1.0);
+}
+`
+
+// Auto exposure looks at the scene through a luminanceSize x luminanceSize image.
+const luminanceSize = 64
+
+// The luminance histogram covers 2^minLogLuminance to 2^maxLogLuminance, anything darker or brighter
+// counts towards the first or last bin.
+const (
+	histogramBins    = 128
+	minLogLuminance  = -10.0
+	maxLogLuminance  = 6.0
+	histogramLowCut  = 0.5  // the darkest half of the image doesn't count
+	histogramHighCut = 0.95 // nor do the brightest highlights
+)
+
+// Tone mapping and gamma correction for a PostProcessor drawing into floating point framebuffers.
+// Add ToneMapEffect before and GammaEffect after the other effects, so they work on displayable colours.
+type ToneMapping struct {
+	Operator ToneMapOperator
+	// Scales the scene before tone mapping. With AutoExposure it is exposure compensation on top of
+	// the exposure picked by the eye adaptation.
+	Exposure     float32
+	AutoExposure bool
+	// Average scene luminance auto exposure maps to, middle gray (0.18) by default.
+	KeyValue float32
+	// How fast the eye adapts to a change in brightness, in 1/second.
+	AdaptationRate float32
+	// 2.2 by default, use 1 with an sRGB framebuffer where OpenGL encodes the output.
+	Gamma float32
+
+	ToneMapEffect, GammaEffect *PostEffect
+
+	clock      func() float64
+	lastTime   float64
+	adapted    float32 // adapted average luminance, 0 until the first frame was measured
+	luminance  *Framebuffer
+	logShader  *PostEffect
+	logSamples []float32
+}
+
+// Create the tone mapping passes. clock returns the time in seconds, it paces the eye adaptation.
+func NewToneMapping(clock func() float64) (*ToneMapping, error) {
+	tm := &ToneMapping{
+		Operator:       ACESFilmic,
+		Exposure:       1,
+		KeyValue:       0.18,
+		AdaptationRate: 1.5,
+		Gamma:          2.2,
+		clock:          clock,
+		logSamples:     make([]float32, luminanceSize*luminanceSize),
+	}
+
+	var err error
+	if tm.ToneMapEffect, err = NewPostEffect("tone-mapping", toneMapShader, nil); err != nil {
+		return nil, err
+	}
+	if tm.GammaEffect, err = NewPostEffect("gamma", gammaShader, nil); err != nil {
+		tm.deleteAll()
+		return nil, err
+	}
+	if tm.logShader, err = NewPostEffect("log-luminance", logLuminanceShader, nil); err != nil {
+		tm.deleteAll()
+		return nil, err
+	}
+	tm.luminance, err = NewFramebuffer(FramebufferOptions{Width: luminanceSize, Height: luminanceSize, Color: []uint32{gl.R16F}})
+	if err != nil {
+		tm.deleteAll()
+		return nil, err
+	}
+
+	tm.ToneMapEffect.Enabled = true
+	tm.GammaEffect.Enabled = true
+	tm.ToneMapEffect.Before = tm.measure
+	tm.GammaEffect.Before = func(*Framebuffer) { tm.GammaEffect.Uniforms["gamma"] = tm.Gamma }
+	return tm, nil
+}
+
+// The exposure the scene is drawn with in the next frame.
+func (tm *ToneMapping) CurrentExposure() float32 {
+	if !tm.AutoExposure || tm.adapted == 0 {
+		return tm.Exposure
+	}
+	return tm.Exposure * tm.KeyValue / tm.adapted
+}
+
+// Release the GL objects used for auto exposure. The effects are deleted with the PostProcessor they were added to.
+func (tm *ToneMapping) Delete() {
+	if tm.logShader != nil {
+		tm.logShader.Delete()
+	}
+	if tm.luminance != nil {
+		tm.luminance.Delete()
+	}
+}
+
+// Clean up after NewToneMapping failed half way.
+func (tm *ToneMapping) deleteAll() {
+	for _, e := range []*PostEffect{tm.ToneMapEffect, tm.GammaEffect} {
+		if e != nil {
+			e.Delete()
+		}
+	}
+	tm.Delete()
+}
+
+// Measure the scene luminance, adapt to it and set the uniforms of the tone mapping pass.
+func (tm *ToneMapping) measure(scene *Framebuffer) {
+	if tm.AutoExposure {
+		tm.luminance.Bind()
+		tm.logShader.shader.Use()
+		tm.logShader.Uniforms["cellSize"] = mgl32.Vec2{1.0 / luminanceSize, 1.0 / luminanceSize}
+		tm.logShader.setUniforms(mgl32.Vec2{1 / float32(scene.Width), 1 / float32(scene.Height)})
+		gl.BindTexture(gl.TEXTURE_2D, scene.Color[0])
+		gl.DrawArrays(gl.TRIANGLES, 0, 6)
+		// reading back stalls until the frame so far is drawn, 16K floats is cheap enough for a demo
+		gl.ReadPixels(0, 0, luminanceSize, luminanceSize, gl.RED, gl.FLOAT, gl.Ptr(tm.logSamples))
+
+		measured := histogramAverage(luminanceHistogram(tm.logSamples), histogramLowCut, histogramHighCut)
+		now := tm.clock()
+		if tm.adapted == 0 {
+			tm.adapted = measured
+		} else {
+			// exponential decay towards the measured value, independent of the frame rate
+			dt := math.Max(now-tm.lastTime, 0)
+			tm.adapted += (measured - tm.adapted) * float32(1-math.Exp(-dt*float64(tm.AdaptationRate)))
+		}
+		tm.lastTime = now
+	}
+
+	tm.ToneMapEffect.Uniforms["toneMapOperator"] = int32(tm.Operator)
+	tm.ToneMapEffect.Uniforms["exposure"] = tm.CurrentExposure()
+}
+
+// Count log2 luminance samples into histogramBins bins between minLogLuminance and maxLogLuminance.
+func luminanceHistogram(logLuminance []float32) [histogramBins]int {
+	var histogram [histogramBins]int
+	for _, l := range logLuminance {
+		bin := int((l - minLogLuminance) / (maxLogLuminance - minLogLuminance) * histogramBins)
+		histogram[min(max(bin, 0), histogramBins-1)]++
+	}
+	return histogram
+}
+
+// Average luminance of the samples counted in histogram, leaving out the darkest low and
+// everything above the high fraction of them. The average is taken in log space.
+func histogramAverage(histogram [histogramBins]int, low, high float32) float32 {
+	total := 0
+	for _, n := range histogram {
+		total += n
+	}
+	if total == 0 {
+		return 1
+	}
+
+	skip, keep := float64(low)*float64(total), float64(high-low)*float64(total)
+	var sum, weight float64
+	for bin, n := range histogram {
+		count := float64(n)
+		// drop what's still below the low cut
+		dropped := math.Min(count, skip)
+		skip -= dropped
+		count = math.Min(count-dropped, keep)
+		keep -= count
+		if count <= 0 {
+			continue
+		}
+		centre := minLogLuminance + (float64(bin)+0.5)/histogramBins*(maxLogLuminance-minLogLuminance)
+		sum += centre * count
+		weight += count
+	}
+	if weight == 0 {
+		return 1
+	}
+	return float32(math.Exp2(sum / weight))
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"math"
+	"testing"
+
+	"opgl-learn/headless"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+func TestHistogramAverage(t *testing.T) {
+	// a dark half that the low cut drops and a few highlights above the high cut
+	samples := []float32{}
+	for i := 0; i < 500; i++ {
+		samples = append(samples, -8)
+	}
+	for i := 0; i < 460; i++ {
+		samples = append(samples, 1)
+	}
+	for i := 0; i < 40; i++ {
+		samples = append(samples, 5)
+	}
+	got := histogramAverage(luminanceHistogram(samples), histogramLowCut, histogramHighCut)
+	if math.Abs(float64(got)-2) > 0.1 {
+		t.Errorf("got %v, want about 2", got)
+	}
+
+	if got := histogramAverage([histogramBins]int{}, histogramLowCut, histogramHighCut); got != 1 {
+		t.Errorf("empty histogram: got %v, want 1", got)
+	}
+}
+
+func TestToneMapping(t *testing.T) {
+	withGLContext(t)
+
+	target, err := headless.NewTarget(16, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Delete()
+	screen := *Screen
+	t.Cleanup(func() { *Screen = screen })
+	SetScreen(target.Framebuffer(), target.Width, target.Height)
+
+	now := 0.0
+	tm, err := NewToneMapping(func() float64 { return now })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tm.Delete()
+	p, err := NewPostProcessor([]*PostEffect{tm.ToneMapEffect, tm.GammaEffect})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Delete()
+
+	// a flat HDR image, brighter than the 8 bit framebuffer can hold
+	drawScene := func(brightness float32) uint8 {
+		p.Begin()
+		gl.ClearColor(brightness, brightness, brightness, 1)
+		gl.Clear(gl.COLOR_BUFFER_BIT)
+		p.End()
+		return target.ReadImage().NRGBAAt(8, 8).R
+	}
+
+	tests := []struct {
+		operator ToneMapOperator
+		gamma    float32
+		want     uint8
+	}{
+		{Reinhard, 1, 204},   // 4 / (1 + 4)
+		{Reinhard, 2.2, 230}, // 0.8^(1 / 2.2)
+		{ACESFilmic, 1, 248},
+		{Uncharted2, 1, 234},
+	}
+	for _, tt := range tests {
+		tm.Operator, tm.Gamma = tt.operator, tt.gamma
+		if got := drawScene(4); got < tt.want-2 || got > tt.want+2 {
+			t.Errorf("%v with gamma %v: got %d, want %d", tt.operator, tt.gamma, got, tt.want)
+		}
+	}
+
+	// auto exposure maps the average luminance to middle gray straight away, then adapts over time
+	tm.Operator, tm.Gamma, tm.AutoExposure = Reinhard, 1, true
+	drawScene(4)
+	if got := tm.CurrentExposure(); math.Abs(float64(got)-0.18/4) > 0.002 {
+		t.Errorf("exposure: got %v, want %v", got, 0.18/4)
+	}
+	drawScene(1)
+	if got := tm.CurrentExposure(); math.Abs(float64(got)-0.18/4) > 0.002 {
+		t.Errorf("exposure right after the change: got %v, want %v", got, 0.18/4)
+	}
+	now = 100
+	if got := drawScene(1); got < 37 || got > 41 {
+		// 0.18 / (1 + 0.18)
+		t.Errorf("adapted: got %d, want 39", got)
+	}
+}
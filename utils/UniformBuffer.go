@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Binding points of the uniform blocks shared between programs.
+const (
+	CameraBinding uint32 = 0
+	LightsBinding uint32 = 1
+)
+
+// Uniform block name -> binding point. Every program built by this package gets its
+// active blocks bound to these right after linking, GLSL 330 can't do it with layout(binding = ...).
+var uniformBlockBindings = map[string]uint32{
+	"Camera": CameraBinding,
+	"Lights": LightsBinding,
+}
+
+// Bind the uniform block called name to binding in every program built from now on.
+func SetUniformBlockBinding(name string, binding uint32) {
+	uniformBlockBindings[name] = binding
+}
+
+func bindUniformBlocks(program Program) {
+	for name, binding := range uniformBlockBindings {
+		index := gl.GetUniformBlockIndex(program, gl.Str(name+"\x00"))
+		if index != gl.INVALID_INDEX {
+			gl.UniformBlockBinding(program, index, binding)
+		}
+	}
+}
+
+// A uniform buffer object attached to a binding point, every program with a block bound to the same
+// point reads from it. Data is uploaded in the std140 layout, see Std140.
+type UniformBuffer struct {
+	ID      uint32
+	Binding uint32
+	size    int
+}
+
+func NewUniformBuffer(binding uint32) *UniformBuffer {
+	ub := &UniformBuffer{Binding: binding}
+	gl.GenBuffers(1, &ub.ID)
+	return ub
+}
+
+// Pack data with Std140 and upload it, the buffer grows when data gets bigger.
+func (ub *UniformBuffer) Update(data any) {
+	packed := Std140(data)
+
+	gl.BindBuffer(gl.UNIFORM_BUFFER, ub.ID)
+	if len(packed) != ub.size {
+		gl.BufferData(gl.UNIFORM_BUFFER, len(packed), gl.Ptr(packed), gl.DYNAMIC_DRAW)
+		ub.size = len(packed)
+		gl.BindBufferBase(gl.UNIFORM_BUFFER, ub.Binding, ub.ID)
+	} else {
+		gl.BufferSubData(gl.UNIFORM_BUFFER, 0, len(packed), gl.Ptr(packed))
+	}
+	gl.BindBuffer(gl.UNIFORM_BUFFER, 0)
+}
+
+func (ub *UniformBuffer) Delete() {
+	gl.DeleteBuffers(1, &ub.ID)
+}
+
+// Contents of the Camera block in shaders/common/camera.glsl.
+type CameraUniforms struct {
+	Projection mgl32.Mat4
+	View       mgl32.Mat4
+	ViewPos    mgl32.Vec3
+}
+
+// Go side of the light structs in shaders/Lighting/common/lightTypes.glsl, field order matches the GLSL.
+type DirLightUniform struct {
+	Direction                  mgl32.Vec3
+	Ambient, Diffuse, Specular mgl32.Vec3
+}
+
+type PointLightUniform struct {
+	Position                    mgl32.Vec3
+	Constant, Linear, Quadratic float32
+	Ambient, Diffuse, Specular  mgl32.Vec3
+}
+
+type SpotLightUniform struct {
+	Position, Direction         mgl32.Vec3
+	CutOff, OuterCutOff         float32
+	Constant, Linear, Quadratic float32
+	Ambient, Diffuse, Specular  mgl32.Vec3
+}
+
+// Contents of the Lights block in shaders/Lighting/common/lightsBlock.glsl, the shader has to declare
+// as many point lights as PointLights holds (NR_POINT_LIGHTS, 1 by default).
+type LightsUniforms struct {
+	DirLight    DirLightUniform
+	PointLights []PointLightUniform
+	SpotLight   SpotLightUniform
+}